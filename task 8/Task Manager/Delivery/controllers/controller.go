@@ -2,26 +2,104 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/go-playground/validator/v10"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"taskmanager/Domain"
-	"taskmanager/Infrastructure"
 	"taskmanager/Usecases"
 	"time"
 )
 
 type Controller struct {
-	UserUsecase Usecases.UserUsecase
-	TaskUsecase Usecases.TaskUsecase
+	UserUsecase    Usecases.UserUsecase
+	TaskUsecase    Usecases.TaskUsecase
+	AuthUsecase    Usecases.AuthUsecase
+	CommentUsecase Usecases.CommentUsecase
+	WebhookUsecase Usecases.WebhookUsecase
+	StreamUsecase  Usecases.TaskStreamUsecase
+	Policy         *Domain.Policy
 }
 
 type TaskDTO struct {
-	ID          string `json:"id" bson:"_id,omitempty"`
-	Title       string `json:"title" bson:"title"`
-	Description string `json:"description" bson:"description"`
-	DueDate     string `json:"due_date" bson:"due_date"`
-	Status      string `json:"status" bson:"status"`
+	ID          string   `json:"id" bson:"_id,omitempty"`
+	OwnerID     string   `json:"owner_id,omitempty" bson:"owner_id"`
+	Title       string   `json:"title" bson:"title"`
+	Description string   `json:"description" bson:"description"`
+	DueDate     string   `json:"due_date" bson:"due_date"`
+	Status      string   `json:"status" bson:"status"`
+	Priority    string   `json:"priority,omitempty" bson:"priority"` // low, medium (default), high or urgent
+	Labels      []string `json:"labels,omitempty" bson:"labels"`
+	AssigneeIDs []string `json:"assignee_ids,omitempty" bson:"assignee_ids"` // read-only, see POST /tasks/:id/assign
+	ParentID    string   `json:"parent_id,omitempty" bson:"parent_id"`
+	BlockedBy   []string `json:"blocked_by,omitempty" bson:"blocked_by"`
+	Recurrence  string   `json:"recurrence,omitempty" bson:"recurrence"` // RRULE subset, e.g. FREQ=WEEKLY;BYDAY=MO
+	Occurrence  int      `json:"occurrence,omitempty" bson:"occurrence"` // read-only, position in the recurring series
+	Version     int64    `json:"version,omitempty" bson:"version"`       // read-only, also sent as the ETag
+	DeletedAt   string   `json:"deleted_at,omitempty" bson:"deleted_at"` // read-only, RFC 3339, only set in the trash
+	DeletedBy   string   `json:"deleted_by,omitempty" bson:"deleted_by"`
+}
+
+// AuditEventDTO is one entry of GET /tasks/:id/history
+type AuditEventDTO struct {
+	ID            string           `json:"id"`
+	Action        string           `json:"action"`
+	ActorID       string           `json:"actor_id"`
+	ActorUsername string           `json:"actor_username"`
+	At            string           `json:"at"` // RFC 3339
+	Changes       []FieldChangeDTO `json:"changes"`
+}
+
+// FieldChangeDTO is the before and after value of one field, empty when the field was not set
+type FieldChangeDTO struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// TaskTreeDTO is a task with its subtasks, returned by GET /tasks/:id/tree
+type TaskTreeDTO struct {
+	TaskDTO
+	Subtasks []TaskTreeDTO `json:"subtasks"`
+}
+
+// AssignDTO is the body of POST /tasks/:id/assign and POST /tasks/:id/unassign
+type AssignDTO struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// CommentDTO is how comments are returned
+type CommentDTO struct {
+	ID             string `json:"id"`
+	TaskID         string `json:"task_id"`
+	AuthorID       string `json:"author_id"`
+	AuthorUsername string `json:"author_username"`
+	Body           string `json:"body"`
+	CreatedAt      string `json:"created_at"` // RFC 3339
+	UpdatedAt      string `json:"updated_at"` // RFC 3339
+	Edited         bool   `json:"edited"`
+}
+
+// CommentInputDTO is the body of POST /tasks/:id/comments and PATCH /comments/:id
+type CommentInputDTO struct {
+	Body string `json:"body"`
+}
+
+// LabelCountDTO is one entry of GET /labels
+type LabelCountDTO struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// TaskPageDTO is one page of GET /tasks results
+type TaskPageDTO struct {
+	Tasks []TaskDTO `json:"tasks"`
+	Next  string    `json:"next,omitempty"`
 }
 
 type UserDTO struct {
@@ -29,84 +107,489 @@ type UserDTO struct {
 	Username string `json:"username" bson:"username" binding:"required"`
 	Password string `json:"password" bson:"password" binding:"required"`
 	Email    string `json:"email" bson:"email"`
-	Role     string `json:"role" bson:"role"`
 }
 
-func NewController(userUsecase Usecases.UserUsecase, taskUsecase Usecases.TaskUsecase) *Controller {
+// UserResponseDTO is how users are returned by the admin endpoints, without the password hash
+type UserResponseDTO struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Team     string `json:"team,omitempty"`
+}
+
+// RoleDTO is the body of PATCH /users/:id/role
+type RoleDTO struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// TeamDTO is the body of PATCH /users/:id/team, an empty team removes the user from their team
+type TeamDTO struct {
+	Team string `json:"team"`
+}
+
+// TokenDTO is returned by POST /login and POST /token/refresh
+type TokenDTO struct {
+	Token        string `json:"token"` // access token
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds until the access token expires
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshTokenDTO is the body of POST /token/refresh and POST /logout
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// WebhookInputDTO is the body of POST /webhooks
+type WebhookInputDTO struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"` // signs the deliveries, never returned
+	Events []string `json:"events"` // e.g. ["task.created"], every event when empty
+}
+
+// WebhookDTO is how webhook subscriptions are returned, without their secret
+type WebhookDTO struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Events    []string `json:"events"`
+	CreatedBy string   `json:"created_by"`
+	CreatedAt string   `json:"created_at"` // RFC 3339
+}
+
+// DeadLetterDTO is one entry of GET /webhooks/dead-letters
+type DeadLetterDTO struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	URL            string          `json:"url"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"` // the body that was posted
+	Attempts       int             `json:"attempts"`
+	LastError      string          `json:"last_error"`
+	FailedAt       string          `json:"failed_at"` // RFC 3339
+}
+
+// TaskEventDTO is the data of an event sent on GET /tasks/stream
+type TaskEventDTO struct {
+	ID             string  `json:"id"`
+	Type           string  `json:"type"`
+	OccurredAt     string  `json:"occurred_at"` // RFC 3339
+	ActorID        string  `json:"actor_id"`
+	ActorUsername  string  `json:"actor_username"`
+	Task           TaskDTO `json:"task"`
+	PreviousStatus string  `json:"previous_status,omitempty"` // task.status_changed only
+}
+
+func NewController(userUsecase Usecases.UserUsecase, taskUsecase Usecases.TaskUsecase, authUsecase Usecases.AuthUsecase, commentUsecase Usecases.CommentUsecase, webhookUsecase Usecases.WebhookUsecase, streamUsecase Usecases.TaskStreamUsecase, policy *Domain.Policy) *Controller {
 	return &Controller{
-		UserUsecase: userUsecase,
-		TaskUsecase: taskUsecase,
+		UserUsecase:    userUsecase,
+		TaskUsecase:    taskUsecase,
+		AuthUsecase:    authUsecase,
+		CommentUsecase: commentUsecase,
+		WebhookUsecase: webhookUsecase,
+		StreamUsecase:  streamUsecase,
+		Policy:         policy,
+	}
+}
+
+func toTokenDTO(pair Domain.TokenPair) TokenDTO {
+	return TokenDTO{
+		Token:        pair.AccessToken.Token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(time.Until(pair.AccessToken.ExpiresAt).Seconds()),
+		RefreshToken: pair.RefreshToken,
 	}
 }
 
 func toTaskDTO(task Domain.Task) TaskDTO {
-	return TaskDTO{
-		ID:          task.ID.Hex(),
+	dto := TaskDTO{
+		ID:          task.ID.String(),
+		OwnerID:     task.OwnerID.String(),
 		Title:       task.Title,
 		Description: task.Description,
 		DueDate:     task.DueDate.Format("02-01-2006"),
-		Status:      task.Status,
+		Status:      string(task.Status),
+		Priority:    string(task.Priority),
+		Labels:      task.Labels,
+		Version:     task.Version,
+	}
+	for _, id := range task.AssigneeIDs {
+		dto.AssigneeIDs = append(dto.AssigneeIDs, id.String())
+	}
+	dto.ParentID = task.ParentID.String()
+	dto.Recurrence = task.Recurrence
+	dto.Occurrence = task.Occurrence
+	for _, id := range task.BlockedBy {
+		dto.BlockedBy = append(dto.BlockedBy, id.String())
 	}
+	if task.IsDeleted() {
+		dto.DeletedAt = task.DeletedAt.UTC().Format(time.RFC3339)
+		dto.DeletedBy = task.DeletedBy.String()
+	}
+	return dto
+}
+
+func toAuditEventDTO(event Domain.AuditEvent) AuditEventDTO {
+	dto := AuditEventDTO{
+		ID:            event.ID.String(),
+		Action:        string(event.Action),
+		ActorID:       event.ActorID.String(),
+		ActorUsername: event.ActorUsername,
+		At:            event.At.UTC().Format(time.RFC3339),
+		Changes:       make([]FieldChangeDTO, 0, len(event.Changes)),
+	}
+	for _, c := range event.Changes {
+		dto.Changes = append(dto.Changes, FieldChangeDTO{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return dto
+}
+
+func toCommentDTO(comment Domain.Comment) CommentDTO {
+	return CommentDTO{
+		ID:             comment.ID.String(),
+		TaskID:         comment.TaskID.String(),
+		AuthorID:       comment.AuthorID.String(),
+		AuthorUsername: comment.AuthorUsername,
+		Body:           comment.Body,
+		CreatedAt:      comment.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:      comment.UpdatedAt.UTC().Format(time.RFC3339),
+		Edited:         comment.IsEdited(),
+	}
+}
+
+func toWebhookDTO(sub Domain.WebhookSubscription) WebhookDTO {
+	dto := WebhookDTO{
+		ID:        sub.ID.String(),
+		URL:       sub.URL,
+		Events:    make([]string, 0, len(sub.Events)),
+		CreatedBy: sub.CreatedBy.String(),
+		CreatedAt: sub.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, event := range sub.Events {
+		dto.Events = append(dto.Events, string(event))
+	}
+	return dto
+}
+
+func toDeadLetterDTO(letter Domain.WebhookDeadLetter) DeadLetterDTO {
+	return DeadLetterDTO{
+		ID:             letter.ID.String(),
+		SubscriptionID: letter.SubscriptionID.String(),
+		URL:            letter.URL,
+		EventID:        letter.EventID.String(),
+		EventType:      string(letter.EventType),
+		Payload:        json.RawMessage(letter.Payload),
+		Attempts:       letter.Attempts,
+		LastError:      letter.LastError,
+		FailedAt:       letter.FailedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func toTaskEventDTO(event Domain.TaskEvent) TaskEventDTO {
+	return TaskEventDTO{
+		ID:             event.ID.String(),
+		Type:           string(event.Type),
+		OccurredAt:     event.OccurredAt.UTC().Format(time.RFC3339),
+		ActorID:        event.ActorID.String(),
+		ActorUsername:  event.ActorUsername,
+		Task:           toTaskDTO(event.Task),
+		PreviousStatus: string(event.PreviousStatus),
+	}
+}
+
+func toTaskTreeDTO(node Domain.TaskNode) TaskTreeDTO {
+	dto := TaskTreeDTO{TaskDTO: toTaskDTO(node.Task), Subtasks: make([]TaskTreeDTO, 0, len(node.Subtasks))}
+	for _, subtask := range node.Subtasks {
+		dto.Subtasks = append(dto.Subtasks, toTaskTreeDTO(subtask))
+	}
+	return dto
+}
+
+func toTaskPageDTO(page *Domain.TaskPage) TaskPageDTO {
+	taskDTOs := make([]TaskDTO, 0, len(page.Tasks))
+	for _, task := range page.Tasks {
+		taskDTOs = append(taskDTOs, toTaskDTO(task))
+	}
+	return TaskPageDTO{Tasks: taskDTOs, Next: page.NextCursor}
 }
 
 func toTaskDomain(dto TaskDTO) (Domain.Task, error) {
 	dueDate, err := time.Parse("02-01-2006", dto.DueDate)
 	if err != nil {
-		return Domain.Task{}, err
+		return Domain.Task{}, Domain.NewValidationError("due_date", "invalid due date, expected dd-mm-yyyy")
 	}
-	var id primitive.ObjectID
+	var id Domain.ID
 	if dto.ID != "" {
-		id, err = primitive.ObjectIDFromHex(dto.ID)
+		id, err = Domain.ParseID(dto.ID)
 		if err != nil {
-			return Domain.Task{}, err
+			return Domain.Task{}, Domain.NewValidationError("id", "invalid task ID")
 		}
-	} else {
-		id = primitive.NilObjectID
+	}
+	verr := &Domain.ValidationError{}
+	parentID := parseOptionalID(dto.ParentID, "parent_id", verr)
+	blockedBy := parseIDs(dto.BlockedBy, "blocked_by", verr)
+	if err := verr.OrNil(); err != nil {
+		return Domain.Task{}, err
 	}
 	return Domain.Task{
 		ID:          id,
 		Title:       dto.Title,
 		Description: dto.Description,
 		DueDate:     dueDate,
-		Status:      dto.Status,
+		Status:      Domain.TaskStatus(dto.Status),
+		Priority:    Domain.TaskPriority(dto.Priority),
+		Labels:      dto.Labels,
+		ParentID:    parentID,
+		BlockedBy:   blockedBy,
+		Recurrence:  dto.Recurrence,
 	}, nil
 }
 
-func toUserDomain(dto UserDTO) Domain.User {
-	var id primitive.ObjectID
-	if dto.ID != "" {
-		var err error
-		id, err = primitive.ObjectIDFromHex(dto.ID)
+// parseOptionalID parses a task ID given in field, "" meaning none
+func parseOptionalID(s, field string, verr *Domain.ValidationError) Domain.ID {
+	if s == "" {
+		return ""
+	}
+	id, err := Domain.ParseID(s)
+	if err != nil {
+		verr.Add(field, fmt.Sprintf("invalid task ID %q", s))
+	}
+	return id
+}
+
+// parseIDs parses the task IDs given in field, an empty list is returned as nil
+func parseIDs(values []string, field string, verr *Domain.ValidationError) []Domain.ID {
+	var ids []Domain.ID
+	for _, v := range values {
+		id, err := Domain.ParseID(v)
+		if err != nil {
+			verr.Add(field, fmt.Sprintf("invalid task ID %q", v))
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// toTaskPatch reads a JSON Merge Patch (RFC 7396) document. Only title, description, due_date,
+// status, priority, labels, parent_id, blocked_by and recurrence may be patched; null resets a field, which fails
+// validation for required ones. labels and blocked_by are replaced as a whole, as arrays are in a merge patch.
+// A null priority keeps the current one, see TaskUsecase.PatchTask.
+func toTaskPatch(body []byte) (Domain.TaskPatch, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return Domain.TaskPatch{}, Domain.NewValidationError("body", "invalid request body, expected a JSON object")
+	}
+	var patch Domain.TaskPatch
+	verr := &Domain.ValidationError{}
+	for name, raw := range members {
+		if name == "blocked_by" {
+			var values []string
+			if err := json.Unmarshal(raw, &values); err != nil {
+				verr.Add(name, "expected an array of task IDs or null")
+				continue
+			}
+			blockedBy := parseIDs(values, name, verr)
+			patch.BlockedBy = &blockedBy
+			continue
+		}
+		if name == "labels" {
+			var labels []string
+			if err := json.Unmarshal(raw, &labels); err != nil {
+				verr.Add(name, "expected an array of labels or null")
+				continue
+			}
+			patch.Labels = &labels
+			continue
+		}
+		var value string
+		if string(raw) != "null" {
+			if err := json.Unmarshal(raw, &value); err != nil {
+				verr.Add(name, "expected a string or null")
+				continue
+			}
+		}
+		switch name {
+		case "title":
+			patch.Title = &value
+		case "description":
+			patch.Description = &value
+		case "due_date":
+			var dueDate time.Time
+			if value != "" {
+				parsed, err := time.Parse("02-01-2006", value)
+				if err != nil {
+					verr.Add("due_date", "invalid due date, expected dd-mm-yyyy")
+					continue
+				}
+				dueDate = parsed
+			}
+			patch.DueDate = &dueDate
+		case "status":
+			status := Domain.TaskStatus(value)
+			patch.Status = &status
+		case "priority":
+			priority := Domain.TaskPriority(value)
+			patch.Priority = &priority
+		case "parent_id":
+			parentID := parseOptionalID(value, name, verr)
+			patch.ParentID = &parentID
+		case "recurrence":
+			patch.Recurrence = &value
+		case "id", "owner_id", "assignee_ids", "occurrence", "version":
+			verr.Add(name, "field is read-only")
+		default:
+			verr.Add(name, "unknown field")
+		}
+	}
+	return patch, verr.OrNil()
+}
+
+// errETagMismatch is reported when If-Match does not name a version of the task
+var errETagMismatch = Domain.NewError(Domain.ErrPreconditionFailed, "version_mismatch", "If-Match does not match the current version of the task")
+
+// taskETag is the strong entity tag of a task version, e.g. "3"
+func taskETag(task Domain.Task) string {
+	return strconv.Quote(strconv.FormatInt(task.Version, 10))
+}
+
+// expectedVersion reads the If-Match header. No header or "*" means any version (0).
+// Since tags are compared strongly, weak or unknown tags never match.
+// Every stored task has a version of at least 1, so "0" is unknown too.
+func expectedVersion(ctx *gin.Context) (int64, error) {
+	ifMatch := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+	tag, err := strconv.Unquote(ifMatch)
+	if err != nil {
+		return 0, errETagMismatch
+	}
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, errETagMismatch
+	}
+	return version, nil
+}
+
+// toTaskQuery reads the listing filters from the request query string.
+// Every invalid parameter is reported in the returned *Domain.ValidationError.
+func toTaskQuery(ctx *gin.Context) (Domain.TaskQuery, error) {
+	query := Domain.TaskQuery{
+		Status:   Domain.TaskStatus(ctx.Query("status")),
+		Priority: Domain.TaskPriority(ctx.Query("priority")),
+		Label:    strings.ToLower(strings.TrimSpace(ctx.Query("label"))), // labels are stored normalized
+		Title:    ctx.Query("title"),
+		Sort:     Domain.TaskSort(ctx.Query("sort")),
+		Cursor:   ctx.Query("cursor"),
+	}
+	verr := &Domain.ValidationError{}
+	if v := ctx.Query("due_after"); v != "" {
+		dueAfter, err := time.Parse("02-01-2006", v)
+		if err != nil {
+			verr.Add("due_after", "invalid due_after, expected dd-mm-yyyy")
+		}
+		query.DueAfter = dueAfter
+	}
+	if v := ctx.Query("due_before"); v != "" {
+		dueBefore, err := time.Parse("02-01-2006", v)
 		if err != nil {
-			id = primitive.NilObjectID
+			verr.Add("due_before", "invalid due_before, expected dd-mm-yyyy")
+		} else {
+			// Include the whole day
+			query.DueBefore = dueBefore.Add(24*time.Hour - time.Millisecond)
 		}
-	} else {
-		id = primitive.NilObjectID
+	}
+	if v := ctx.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			verr.Add("limit", "invalid limit, expected a positive number")
+		}
+		query.Limit = limit
+	}
+	return query, verr.OrNil()
+}
+
+// bindJSON decodes the request body and reports failures as a Domain validation error
+func bindJSON(ctx *gin.Context, obj interface{}) error {
+	err := ctx.ShouldBindJSON(obj)
+	if err == nil {
+		return nil
+	}
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		verr := &Domain.ValidationError{}
+		for _, fe := range fieldErrs {
+			verr.Add(strings.ToLower(fe.Field()), "failed on the '"+fe.Tag()+"' rule")
+		}
+		return verr
+	}
+	return Domain.NewValidationError("body", "invalid request body")
+}
+
+func toUserDomain(dto UserDTO) Domain.User {
+	var id Domain.ID
+	if dto.ID != "" {
+		// An invalid ID is simply ignored
+		id, _ = Domain.ParseID(dto.ID)
 	}
 	return Domain.User{
 		ID:       id,
 		Username: dto.Username,
 		Password: dto.Password,
 		Email:    dto.Email,
-		Role:     dto.Role,
 	}
 }
 
+func toUserResponseDTO(user Domain.User) UserResponseDTO {
+	return UserResponseDTO{
+		ID:       user.ID.String(),
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		Team:     user.Team,
+	}
+}
+
+// errInvalidTokenUser is reported when the user_id claim set by AuthenticateJWT is not a valid ID
+var errInvalidTokenUser = Domain.NewError(Domain.ErrUnauthorized, "invalid_token", "invalid user ID in token")
+
+// actorFromContext builds the calling Domain.Actor from the claims set by AuthenticateJWT
+func actorFromContext(ctx *gin.Context) (Domain.Actor, error) {
+	userID, err := Domain.ParseID(ctx.GetString("user_id"))
+	if err != nil {
+		return Domain.Actor{}, errInvalidTokenUser
+	}
+	return Domain.Actor{
+		UserID:   userID,
+		Username: ctx.GetString("username"),
+		Role:     ctx.GetString("role"),
+		Team:     ctx.GetString("team"),
+	}, nil
+}
+
+// accessTokenFromContext returns the jti and expiry of the request's access token, set by AuthenticateJWT
+func accessTokenFromContext(ctx *gin.Context) Domain.AccessToken {
+	return Domain.AccessToken{
+		JTI:       ctx.GetString("jti"),
+		ExpiresAt: ctx.GetTime("token_expires_at"),
+	}
+}
+
+// Handlers report failures with ctx.Error and return; middleware.ErrorHandler writes the response.
+
 func (c *Controller) RegisterUser(ctx *gin.Context) {
 	var newUserDTO UserDTO
-	if err := ctx.ShouldBindJSON(&newUserDTO); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(ctx, &newUserDTO); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
 	newUser := toUserDomain(newUserDTO)
 
 	if err := c.UserUsecase.RegisterUser(context.Background(), newUser); err != nil {
-		if err.Error() == "username already exists" {
-			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = ctx.Error(err)
 		return
 	}
 
@@ -118,128 +601,761 @@ func (c *Controller) LoginUser(ctx *gin.Context) {
 		Username string `json:"username" binding:"required"`
 		Password string `json:"password" binding:"required"`
 	}
-	if err := ctx.ShouldBindJSON(&loginCredentials); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := bindJSON(ctx, &loginCredentials); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
-	user, err := c.UserUsecase.AuthenticateUser(context.Background(), loginCredentials.Username, loginCredentials.Password)
+	tokens, err := c.AuthUsecase.Login(context.Background(), loginCredentials.Username, loginCredentials.Password)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, toTokenDTO(*tokens))
+}
+
+// RefreshToken handles POST /token/refresh
+// The refresh token is rotated, the response carries the one to use next time.
+func (c *Controller) RefreshToken(ctx *gin.Context) {
+	var input RefreshTokenDTO
+	if err := bindJSON(ctx, &input); err != nil || input.RefreshToken == "" {
+		_ = ctx.Error(Domain.NewValidationError("refresh_token", "refresh_token is required"))
 		return
 	}
 
-	// Generate JWT token using Infrastructure package
-	signedToken, err := Infrastructure.GenerateToken(user.ID.Hex(), user.Username, user.Role)
+	tokens, err := c.AuthUsecase.RefreshToken(context.Background(), input.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		_ = ctx.Error(err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"token": signedToken})
+	ctx.JSON(http.StatusOK, toTokenDTO(*tokens))
+}
+
+// Logout handles POST /logout
+// It revokes the access token of the request and the refresh token in the body, if any.
+func (c *Controller) Logout(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	// The body is optional
+	var input RefreshTokenDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := bindJSON(ctx, &input); err != nil {
+			_ = ctx.Error(err)
+			return
+		}
+	}
+
+	if err := c.AuthUsecase.Logout(context.Background(), actor, accessTokenFromContext(ctx), input.RefreshToken); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 // GetTasks handles GET /tasks
+// Supported query parameters: status, due_after, due_before (dd-mm-yyyy), title,
+// sort (due_date, -due_date, title), limit and cursor (the "next" token of a previous page).
 func (c *Controller) GetTasks(ctx *gin.Context) {
-	tasks, err := c.TaskUsecase.GetAllTasks(context.Background())
+	actor, err := actorFromContext(ctx)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve tasks", "error": err.Error()})
+		_ = ctx.Error(err)
 		return
 	}
 
-	if len(tasks) == 0 {
-		ctx.IndentedJSON(http.StatusOK, gin.H{"message": "There are no tasks yet"})
+	query, err := toTaskQuery(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
-	var taskDTOs []TaskDTO
-	for _, task := range tasks {
-		taskDTOs = append(taskDTOs, toTaskDTO(task))
+	page, err := c.TaskUsecase.ListTasks(context.Background(), actor, query)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, taskDTOs)
+	ctx.IndentedJSON(http.StatusOK, toTaskPageDTO(page))
+}
+
+// GetTrash handles GET /tasks/trash
+// It lists the deleted tasks the caller may read and takes the same query parameters as GET /tasks.
+func (c *Controller) GetTrash(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	query, err := toTaskQuery(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	page, err := c.TaskUsecase.ListDeletedTasks(context.Background(), actor, query)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, toTaskPageDTO(page))
 }
 
 // GetTask handles GET /tasks/:id
 func (c *Controller) GetTask(ctx *gin.Context) {
-	id := ctx.Param("id")
-	task, err := c.TaskUsecase.GetTaskByID(context.Background(), id)
+	actor, err := actorFromContext(ctx)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		_ = ctx.Error(err)
 		return
 	}
+	task, err := c.TaskUsecase.GetTaskByID(context.Background(), actor, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Header("ETag", taskETag(*task))
 	ctx.IndentedJSON(http.StatusOK, toTaskDTO(*task))
 }
 
 // UpdateTask handles PUT /tasks/:id
+// With an If-Match header the update only applies to that version of the task, otherwise it returns 412.
 func (c *Controller) UpdateTask(ctx *gin.Context) {
 	id := ctx.Param("id")
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
 
 	var input TaskDTO
-
-	if err := ctx.BindJSON(&input); err != nil {
-		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "Invalid request body"})
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
 	task, err := toTaskDomain(input)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "Invalid due date format"})
+		_ = ctx.Error(err)
+		return
+	}
+	task.Version, err = expectedVersion(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	updatedTask, err := c.TaskUsecase.UpdateTask(context.Background(), actor, id, task)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.Header("ETag", taskETag(*updatedTask))
+	ctx.IndentedJSON(http.StatusOK, toTaskDTO(*updatedTask))
+}
+
+// PatchTask handles PATCH /tasks/:id
+// The body is a JSON Merge Patch (application/merge-patch+json), If-Match works as for PUT.
+func (c *Controller) PatchTask(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		_ = ctx.Error(Domain.NewValidationError("body", "invalid request body"))
+		return
+	}
+	patch, err := toTaskPatch(body)
+	if err != nil {
+		_ = ctx.Error(err)
 		return
 	}
-	// Convert string id to primitive.ObjectID
-	objID, err := primitive.ObjectIDFromHex(id)
+	patch.Version, err = expectedVersion(ctx)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "Invalid task ID"})
+		_ = ctx.Error(err)
 		return
 	}
-	task.ID = objID
 
-	updatedTask, err := c.TaskUsecase.UpdateTask(context.Background(), id, task)
+	updatedTask, err := c.TaskUsecase.PatchTask(context.Background(), actor, ctx.Param("id"), patch)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": "Failed to update task"})
+		_ = ctx.Error(err)
 		return
 	}
 
+	ctx.Header("ETag", taskETag(*updatedTask))
 	ctx.IndentedJSON(http.StatusOK, toTaskDTO(*updatedTask))
 }
 
+// AssignTask handles POST /tasks/:id/assign, honouring If-Match like PUT
+func (c *Controller) AssignTask(ctx *gin.Context) {
+	c.changeAssignees(ctx, c.TaskUsecase.AssignTask)
+}
+
+// UnassignTask handles POST /tasks/:id/unassign, honouring If-Match like PUT
+func (c *Controller) UnassignTask(ctx *gin.Context) {
+	c.changeAssignees(ctx, c.TaskUsecase.UnassignTask)
+}
+
+type assigneeChange func(ctx context.Context, actor Domain.Actor, id string, userIDs []string, version int64) (*Domain.Task, error)
+
+func (c *Controller) changeAssignees(ctx *gin.Context, change assigneeChange) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var input AssignDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	version, err := expectedVersion(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	task, err := change(context.Background(), actor, ctx.Param("id"), input.UserIDs, version)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Header("ETag", taskETag(*task))
+	ctx.IndentedJSON(http.StatusOK, toTaskDTO(*task))
+}
+
+// GetTaskTree handles GET /tasks/:id/tree
+func (c *Controller) GetTaskTree(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	tree, err := c.TaskUsecase.GetTaskTree(context.Background(), actor, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, toTaskTreeDTO(*tree))
+}
+
+// GetLabels handles GET /labels
+// It counts the labels of the tasks the caller may read, most used first.
+func (c *Controller) GetLabels(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	counts, err := c.TaskUsecase.ListLabels(context.Background(), actor)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	labels := make([]LabelCountDTO, 0, len(counts))
+	for _, lc := range counts {
+		labels = append(labels, LabelCountDTO{Label: lc.Label, Count: lc.Count})
+	}
+	ctx.IndentedJSON(http.StatusOK, labels)
+}
+
+// GetMyTasks handles GET /me/tasks
+// It lists the tasks assigned to the caller and takes the same query parameters as GET /tasks.
+func (c *Controller) GetMyTasks(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	query, err := toTaskQuery(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	page, err := c.TaskUsecase.ListAssignedTasks(context.Background(), actor, query)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	ctx.IndentedJSON(http.StatusOK, toTaskPageDTO(page))
+}
+
+const (
+	// streamHeartbeat is how often an idle stream sends a comment, so proxies keep the connection open
+	streamHeartbeat = 15 * time.Second
+	// streamRetry is how long a client waits before reconnecting a stream that ended
+	streamRetry = 3 * time.Second
+)
+
+// StreamTasks handles GET /tasks/stream
+// It sends the changes of the tasks the caller may read as Server-Sent Events until the client disconnects.
+// A reconnecting client sends Last-Event-ID and gets the events it missed, or a reset event if some are
+// no longer buffered and it has to reload its tasks.
+// The stream ends when the access token expires or is revoked, so the client reconnects with a fresh
+// token and the role and team it is filtered by are those of the new token.
+func (c *Controller) StreamTasks(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	feed, err := c.StreamUsecase.StreamTasks(actor, ctx.GetHeader("Last-Event-ID"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	defer feed.Close()
+
+	// The server's write timeout would cut the stream off, it lasts as long as the client stays
+	_ = http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{})
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("X-Accel-Buffering", "no") // keeps nginx from buffering the events
+	ctx.Status(http.StatusOK)
+	w := ctx.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds())
+	if feed.Gap {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	if len(feed.Backlog) == 0 {
+		// An id without data sets the client's Last-Event-ID, so even a quiet stream resumes where it was
+		fmt.Fprintf(w, "id: %d\n\n", feed.Position)
+	}
+	for _, event := range feed.Backlog {
+		if err := writeTaskEvent(w, event); err != nil {
+			return
+		}
+	}
+	w.Flush()
+
+	var expired <-chan time.Time
+	if expiresAt := ctx.GetTime("token_expires_at"); !expiresAt.IsZero() {
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		expired = expiry.C
+	}
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case <-expired:
+			return
+		case <-heartbeat.C:
+			if c.streamTokenRevoked(ctx) {
+				return
+			}
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-feed.Events:
+			if !ok {
+				// The server shuts down or the client fell behind, it reconnects with its Last-Event-ID
+				return
+			}
+			if err := writeTaskEvent(w, event); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// streamTokenRevoked checks again whether the token of a stream was revoked, e.g. on logout.
+// A failed check counts as revoked, the reconnecting client finds out on authentication.
+func (c *Controller) streamTokenRevoked(ctx *gin.Context) bool {
+	jti := ctx.GetString("jti")
+	if jti == "" {
+		return false
+	}
+	revoked, err := c.AuthUsecase.IsTokenRevoked(ctx.Request.Context(), jti)
+	return err != nil || revoked
+}
+
+// writeTaskEvent writes one event of GET /tasks/stream.
+// An event without a type, of a task the caller cannot read, only moves the client's Last-Event-ID.
+func writeTaskEvent(w io.Writer, event Domain.TaskEvent) error {
+	if event.Type == "" {
+		_, err := fmt.Fprintf(w, "id: %d\n\n", event.Seq)
+		return err
+	}
+	data, err := json.Marshal(toTaskEventDTO(event))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
+
 // DeleteTask handles DELETE /tasks/:id
 func (c *Controller) DeleteTask(ctx *gin.Context) {
-	id := ctx.Param("id")
-	err := c.TaskUsecase.DeleteTask(context.Background(), id)
+	actor, err := actorFromContext(ctx)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusNotFound, gin.H{"message": "task not found"})
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.TaskUsecase.DeleteTask(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 	// Return 200 OK with message instead of 204 No Content with body
 	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "deleted task successfully"})
 }
 
+// GetTaskHistory handles GET /tasks/:id/history
+func (c *Controller) GetTaskHistory(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	events, err := c.TaskUsecase.GetTaskHistory(context.Background(), actor, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	eventDTOs := make([]AuditEventDTO, 0, len(events))
+	for _, event := range events {
+		eventDTOs = append(eventDTOs, toAuditEventDTO(event))
+	}
+	ctx.IndentedJSON(http.StatusOK, eventDTOs)
+}
+
+// RestoreTask handles POST /tasks/:id/restore
+func (c *Controller) RestoreTask(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	task, err := c.TaskUsecase.RestoreTask(context.Background(), actor, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.Header("ETag", taskETag(*task))
+	ctx.IndentedJSON(http.StatusOK, toTaskDTO(*task))
+}
+
+// PurgeTask handles DELETE /tasks/:id/purge (tasks:purge)
+func (c *Controller) PurgeTask(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.TaskUsecase.PurgeTask(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "purged task successfully"})
+}
+
 // AddTask handles POST /tasks
 func (c *Controller) AddTask(ctx *gin.Context) {
-	var input TaskDTO
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
 
-	if err := ctx.BindJSON(&input); err != nil {
-		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "Invalid request body"})
+	var input TaskDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
 		return
 	}
 
 	task, err := toTaskDomain(input)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "Invalid due date format"})
+		_ = ctx.Error(err)
 		return
 	}
 
-	if task.Status != "Pending" && task.Status != "In Progress" && task.Status != "Completed" {
-		ctx.IndentedJSON(http.StatusBadRequest, gin.H{"message": "Invalid status value"})
+	createdTask, err := c.TaskUsecase.AddTask(context.Background(), actor, task)
+	if err != nil {
+		_ = ctx.Error(err)
 		return
 	}
+	ctx.Header("ETag", taskETag(*createdTask))
+	ctx.IndentedJSON(http.StatusCreated, toTaskDTO(*createdTask))
+}
 
-	createdTask, err := c.TaskUsecase.AddTask(context.Background(), task)
+// GetComments handles GET /tasks/:id/comments
+func (c *Controller) GetComments(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
 	if err != nil {
-		ctx.IndentedJSON(http.StatusInternalServerError, gin.H{"message": "Failed to add task"})
+		_ = ctx.Error(err)
 		return
 	}
-	ctx.IndentedJSON(http.StatusCreated, toTaskDTO(*createdTask))
+	comments, err := c.CommentUsecase.ListComments(context.Background(), actor, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	commentDTOs := make([]CommentDTO, 0, len(comments))
+	for _, comment := range comments {
+		commentDTOs = append(commentDTOs, toCommentDTO(comment))
+	}
+	ctx.IndentedJSON(http.StatusOK, commentDTOs)
+}
+
+// AddComment handles POST /tasks/:id/comments
+func (c *Controller) AddComment(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var input CommentInputDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	comment, err := c.CommentUsecase.AddComment(context.Background(), actor, ctx.Param("id"), Domain.Comment{Body: input.Body})
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusCreated, toCommentDTO(*comment))
+}
+
+// UpdateComment handles PATCH /comments/:id, for the author or a moderator
+func (c *Controller) UpdateComment(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var input CommentInputDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	comment, err := c.CommentUsecase.UpdateComment(context.Background(), actor, ctx.Param("id"), input.Body)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, toCommentDTO(*comment))
+}
+
+// DeleteComment handles DELETE /comments/:id, for the author or a moderator
+func (c *Controller) DeleteComment(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.CommentUsecase.DeleteComment(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "deleted comment successfully"})
+}
+
+// GetUsers handles GET /users (users:admin)
+func (c *Controller) GetUsers(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	users, err := c.UserUsecase.ListUsers(context.Background(), actor)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	userDTOs := make([]UserResponseDTO, 0, len(users))
+	for _, user := range users {
+		userDTOs = append(userDTOs, toUserResponseDTO(user))
+	}
+	ctx.IndentedJSON(http.StatusOK, userDTOs)
+}
+
+// UpdateUserRole handles PATCH /users/:id/role (users:admin)
+func (c *Controller) UpdateUserRole(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var input RoleDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	user, err := c.UserUsecase.UpdateUserRole(context.Background(), actor, ctx.Param("id"), input.Role)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, toUserResponseDTO(*user))
+}
+
+// UpdateUserTeam handles PATCH /users/:id/team (users:admin)
+func (c *Controller) UpdateUserTeam(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var input TeamDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+
+	user, err := c.UserUsecase.UpdateUserTeam(context.Background(), actor, ctx.Param("id"), input.Team)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, toUserResponseDTO(*user))
+}
+
+// DeleteUser handles DELETE /users/:id (users:admin)
+func (c *Controller) DeleteUser(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.UserUsecase.DeleteUser(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "deleted user successfully"})
+}
+
+// GetWebhooks handles GET /webhooks (webhooks:admin)
+func (c *Controller) GetWebhooks(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	subs, err := c.WebhookUsecase.ListSubscriptions(context.Background(), actor)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	webhookDTOs := make([]WebhookDTO, 0, len(subs))
+	for _, sub := range subs {
+		webhookDTOs = append(webhookDTOs, toWebhookDTO(sub))
+	}
+	ctx.IndentedJSON(http.StatusOK, webhookDTOs)
+}
+
+// AddWebhook handles POST /webhooks (webhooks:admin)
+func (c *Controller) AddWebhook(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	var input WebhookInputDTO
+	if err := bindJSON(ctx, &input); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	sub := Domain.WebhookSubscription{URL: input.URL, Secret: input.Secret}
+	for _, event := range input.Events {
+		sub.Events = append(sub.Events, Domain.TaskEventType(event))
+	}
+	created, err := c.WebhookUsecase.CreateSubscription(context.Background(), actor, sub)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusCreated, toWebhookDTO(*created))
+}
+
+// GetWebhook handles GET /webhooks/:id (webhooks:admin)
+func (c *Controller) GetWebhook(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	sub, err := c.WebhookUsecase.GetSubscription(context.Background(), actor, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, toWebhookDTO(*sub))
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id (webhooks:admin)
+func (c *Controller) DeleteWebhook(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.WebhookUsecase.DeleteSubscription(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "deleted webhook successfully"})
+}
+
+// GetDeadLetters handles GET /webhooks/dead-letters (webhooks:admin)
+func (c *Controller) GetDeadLetters(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	letters, err := c.WebhookUsecase.ListDeadLetters(context.Background(), actor)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	letterDTOs := make([]DeadLetterDTO, 0, len(letters))
+	for _, letter := range letters {
+		letterDTOs = append(letterDTOs, toDeadLetterDTO(letter))
+	}
+	ctx.IndentedJSON(http.StatusOK, letterDTOs)
+}
+
+// RetryDeadLetter handles POST /webhooks/dead-letters/:id/retry (webhooks:admin).
+// The delivery happens in the background, a new dead letter is listed if it fails again.
+func (c *Controller) RetryDeadLetter(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.WebhookUsecase.RetryDeadLetter(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusAccepted, gin.H{"message": "delivery scheduled"})
+}
+
+// DeleteDeadLetter handles DELETE /webhooks/dead-letters/:id (webhooks:admin)
+func (c *Controller) DeleteDeadLetter(ctx *gin.Context) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	if err := c.WebhookUsecase.DeleteDeadLetter(context.Background(), actor, ctx.Param("id")); err != nil {
+		_ = ctx.Error(err)
+		return
+	}
+	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "deleted dead letter successfully"})
 }
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"taskmanager/Domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of RFC 7807 error responses
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document.
+// Code is an extension member with a stable identifier clients can branch on,
+// e.g. "task_not_found" or "validation_failed"; Title and Detail are for humans.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []FieldProblem `json:"errors,omitempty"`
+}
+
+// FieldProblem is one invalid input field of a validation problem
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// kinds maps the Domain error kinds to a status and the code used when the error has none of its own
+var kinds = []struct {
+	kind   error
+	status int
+	code   string
+}{
+	{Domain.ErrValidation, http.StatusBadRequest, "validation_failed"},
+	{Domain.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+	{Domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+	{Domain.ErrNotFound, http.StatusNotFound, "not_found"},
+	{Domain.ErrConflict, http.StatusConflict, "conflict"},
+	{Domain.ErrPreconditionFailed, http.StatusPreconditionFailed, "precondition_failed"},
+	{Domain.ErrUnavailable, http.StatusServiceUnavailable, "unavailable"},
+}
+
+// NewProblem translates an error into a Problem. Errors of no Domain kind become a 500
+// whose detail is not shown to the client.
+func NewProblem(err error) Problem {
+	p := Problem{Type: "about:blank", Status: http.StatusInternalServerError, Code: "internal_error"}
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			p.Status, p.Code, p.Detail = k.status, k.code, err.Error()
+			break
+		}
+	}
+	var domainErr *Domain.Error
+	if p.Status != http.StatusInternalServerError && errors.As(err, &domainErr) && domainErr.Code != "" {
+		p.Code = domainErr.Code
+	}
+	var verr *Domain.ValidationError
+	if errors.As(err, &verr) {
+		for _, f := range verr.Fields {
+			p.Errors = append(p.Errors, FieldProblem{Field: f.Field, Message: f.Message})
+		}
+	}
+	p.Title = http.StatusText(p.Status)
+	return p
+}
+
+// ErrorHandler writes the last error a handler attached with ctx.Error as application/problem+json.
+// Handlers only report errors; this is the single place that turns them into HTTP responses.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := c.Errors.Last().Err
+		p := NewProblem(err)
+		p.Instance = c.Request.URL.Path
+		if p.Status == http.StatusInternalServerError {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(p.Status, p)
+	}
+}
+
+// NoRoute answers unknown paths with a problem document too
+func NoRoute(c *gin.Context) {
+	_ = c.Error(Domain.NewError(Domain.ErrNotFound, "route_not_found", "no route for "+c.Request.Method+" "+c.Request.URL.Path))
+}
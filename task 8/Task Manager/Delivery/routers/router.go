@@ -2,27 +2,85 @@ package routers
 
 import (
 	"taskmanager/Delivery/controllers"
+	"taskmanager/Delivery/middleware"
+	"taskmanager/Domain"
 	"taskmanager/Infrastructure"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(ctrl *controllers.Controller) *gin.Engine {
-	r := gin.Default()
+// SetupRouter registers every route. readiness lists the dependencies GET /readyz checks, by name.
+func SetupRouter(ctrl *controllers.Controller, readiness map[string]Infrastructure.ReadinessCheck) *gin.Engine {
+	r := gin.New()
+	// Probes hit the health endpoints every few seconds, they would drown the request log
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/healthz", "/readyz"}}), gin.Recovery())
+	// Every error, including those of the auth middleware, is answered with application/problem+json
+	r.Use(middleware.ErrorHandler())
+	r.NoRoute(middleware.NoRoute)
+
+	// Health checks for the orchestrator, without authentication
+	r.GET("/healthz", Infrastructure.HealthzHandler())
+	r.GET("/readyz", Infrastructure.ReadyzHandler(readiness))
 
 	// Public routes
 	r.POST("/register", ctrl.RegisterUser)
 	r.POST("/login", ctrl.LoginUser)
+	r.POST("/token/refresh", ctrl.RefreshToken)
+	r.GET("/.well-known/jwks.json", Infrastructure.JWKSHandler())
 
 	// Protected routes
 	auth := r.Group("/")
-	auth.Use(Infrastructure.AuthenticateJWT())
+	auth.Use(Infrastructure.AuthenticateJWT(ctrl.AuthUsecase))
+
+	auth.POST("/logout", ctrl.Logout)
+
+	// Task routes need the base permission, the usecases check ownership and team
+	canRead := Infrastructure.RequirePermission(ctrl.Policy, Domain.PermTasksRead)
+	canWrite := Infrastructure.RequirePermission(ctrl.Policy, Domain.PermTasksWrite)
+
+	auth.GET("/tasks", canRead, ctrl.GetTasks)
+	auth.GET("/tasks/trash", canRead, ctrl.GetTrash)
+	auth.GET("/tasks/stream", canRead, ctrl.StreamTasks)
+	auth.GET("/tasks/:id", canRead, ctrl.GetTask)
+	auth.GET("/tasks/:id/history", canRead, ctrl.GetTaskHistory)
+	auth.GET("/tasks/:id/tree", canRead, ctrl.GetTaskTree)
+	auth.POST("/tasks", canWrite, ctrl.AddTask)
+	auth.PUT("/tasks/:id", canWrite, ctrl.UpdateTask)
+	auth.PATCH("/tasks/:id", canWrite, ctrl.PatchTask)
+	auth.DELETE("/tasks/:id", canWrite, ctrl.DeleteTask)
+	auth.POST("/tasks/:id/restore", canWrite, ctrl.RestoreTask)
+	auth.POST("/tasks/:id/assign", canWrite, ctrl.AssignTask)
+	auth.POST("/tasks/:id/unassign", canWrite, ctrl.UnassignTask)
+	auth.GET("/me/tasks", canRead, ctrl.GetMyTasks)
+	auth.GET("/labels", canRead, ctrl.GetLabels)
+	auth.DELETE("/tasks/:id/purge", Infrastructure.RequirePermission(ctrl.Policy, Domain.PermTasksPurge), ctrl.PurgeTask)
+
+	// Whoever can read a task can comment on it, the usecase checks authorship for changes
+	auth.GET("/tasks/:id/comments", canRead, ctrl.GetComments)
+	auth.POST("/tasks/:id/comments", canRead, ctrl.AddComment)
+	auth.PATCH("/comments/:id", canRead, ctrl.UpdateComment)
+	auth.DELETE("/comments/:id", canRead, ctrl.DeleteComment)
+
+	// User administration
+	admin := auth.Group("/users")
+	admin.Use(Infrastructure.RequirePermission(ctrl.Policy, Domain.PermUsersAdmin))
+
+	admin.GET("", ctrl.GetUsers)
+	admin.PATCH("/:id/role", ctrl.UpdateUserRole)
+	admin.PATCH("/:id/team", ctrl.UpdateUserTeam)
+	admin.DELETE("/:id", ctrl.DeleteUser)
+
+	// Webhook subscriptions and their failed deliveries
+	webhooks := auth.Group("/webhooks")
+	webhooks.Use(Infrastructure.RequirePermission(ctrl.Policy, Domain.PermWebhooksAdmin))
 
-	auth.GET("/tasks", ctrl.GetTasks)
-	auth.GET("/tasks/:id", ctrl.GetTask)
-	auth.POST("/tasks", ctrl.AddTask)
-	auth.PUT("/tasks/:id", ctrl.UpdateTask)
-	auth.DELETE("/tasks/:id", ctrl.DeleteTask)
+	webhooks.GET("", ctrl.GetWebhooks)
+	webhooks.POST("", ctrl.AddWebhook)
+	webhooks.GET("/dead-letters", ctrl.GetDeadLetters)
+	webhooks.POST("/dead-letters/:id/retry", ctrl.RetryDeadLetter)
+	webhooks.DELETE("/dead-letters/:id", ctrl.DeleteDeadLetter)
+	webhooks.GET("/:id", ctrl.GetWebhook)
+	webhooks.DELETE("/:id", ctrl.DeleteWebhook)
 
 	return r
 }
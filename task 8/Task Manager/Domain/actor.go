@@ -0,0 +1,10 @@
+package Domain
+
+// Actor identifies the authenticated user on whose behalf an operation runs.
+// What the actor may do is decided by a Policy from its Role, see permission.go.
+type Actor struct {
+	UserID   ID
+	Username string
+	Role     string
+	Team     string
+}
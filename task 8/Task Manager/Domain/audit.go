@@ -0,0 +1,92 @@
+package Domain
+
+import (
+	"strings"
+	"time"
+)
+
+// AuditAction names what happened to a task in an AuditEvent
+type AuditAction string
+
+const (
+	AuditCreated  AuditAction = "created"
+	AuditUpdated  AuditAction = "updated"
+	AuditDeleted  AuditAction = "deleted"
+	AuditRestored AuditAction = "restored"
+	AuditPurged   AuditAction = "purged"
+	// AuditAssigned and AuditUnassigned record changes of the assignees
+	AuditAssigned   AuditAction = "assigned"
+	AuditUnassigned AuditAction = "unassigned"
+)
+
+// FieldChange is the value of one task field before and after a change, formatted as text.
+// Before is empty for a created task.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// AuditEvent records one change of a task. Events are never modified once written.
+type AuditEvent struct {
+	ID            ID
+	TaskID        ID
+	Action        AuditAction
+	ActorID       ID
+	ActorUsername string
+	At            time.Time
+	Changes       []FieldChange
+}
+
+// NewAuditEvent creates the event for an actor changing a task from before to after
+func NewAuditEvent(action AuditAction, actor Actor, at time.Time, before, after Task) AuditEvent {
+	taskID := after.ID
+	if taskID.IsZero() {
+		taskID = before.ID
+	}
+	return AuditEvent{
+		TaskID:        taskID,
+		Action:        action,
+		ActorID:       actor.UserID,
+		ActorUsername: actor.Username,
+		At:            at,
+		Changes:       DiffTasks(before, after),
+	}
+}
+
+// DiffTasks lists the client editable fields that differ between two versions of a task
+func DiffTasks(before, after Task) []FieldChange {
+	var changes []FieldChange
+	add := func(field, b, a string) {
+		if b != a {
+			changes = append(changes, FieldChange{Field: field, Before: b, After: a})
+		}
+	}
+	add("title", before.Title, after.Title)
+	add("description", before.Description, after.Description)
+	add("due_date", formatAuditDate(before.DueDate), formatAuditDate(after.DueDate))
+	add("status", string(before.Status), string(after.Status))
+	add("priority", string(before.Priority), string(after.Priority))
+	add("labels", strings.Join(before.Labels, ","), strings.Join(after.Labels, ","))
+	add("recurrence", before.Recurrence, after.Recurrence)
+	add("assignee_ids", joinIDs(before.AssigneeIDs), joinIDs(after.AssigneeIDs))
+	add("parent_id", before.ParentID.String(), after.ParentID.String())
+	add("blocked_by", joinIDs(before.BlockedBy), joinIDs(after.BlockedBy))
+	return changes
+}
+
+// joinIDs formats a list of IDs as a comma separated string
+func joinIDs(ids []ID) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = id.String()
+	}
+	return strings.Join(s, ",")
+}
+
+func formatAuditDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
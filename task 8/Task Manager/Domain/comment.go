@@ -0,0 +1,42 @@
+package Domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxCommentLength is the longest comment body accepted by Comment.Validate
+const MaxCommentLength = 5000
+
+// ErrCommentNotFound is returned when a comment does not exist or its task cannot be read
+var ErrCommentNotFound = NewError(ErrNotFound, "comment_not_found", "comment not found")
+
+// Comment is a message posted on a task
+type Comment struct {
+	ID             ID
+	TaskID         ID
+	AuthorID       ID
+	AuthorUsername string
+	Body           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time // equals CreatedAt until the comment is edited
+}
+
+// Validate checks the fields a client provides. It returns a *ValidationError.
+func (c *Comment) Validate() error {
+	verr := &ValidationError{}
+	switch {
+	case strings.TrimSpace(c.Body) == "":
+		verr.Add("body", "body is required")
+	case utf8.RuneCountInString(c.Body) > MaxCommentLength:
+		verr.Add("body", fmt.Sprintf("body must be at most %d characters", MaxCommentLength))
+	}
+	return verr.OrNil()
+}
+
+// IsEdited checks if the comment was changed after it was posted
+func (c *Comment) IsEdited() bool {
+	return c.UpdatedAt.After(c.CreatedAt)
+}
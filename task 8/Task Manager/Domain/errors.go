@@ -0,0 +1,99 @@
+package Domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Error kinds. Every error the usecases return on purpose matches one of them with errors.Is,
+// and Delivery picks the HTTP status from the kind alone.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrPreconditionFailed is returned when a conditional write finds the data changed since it was read.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrForbidden is returned when the actor's role lacks the permission for an operation.
+	ErrForbidden = errors.New("permission denied")
+	// ErrUnavailable is returned when an operation cannot run right now, e.g. while the server shuts down.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is an error of a given kind with a stable code clients can branch on, e.g. "username_taken".
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+// NewError creates an Error, kind is one of the error kinds above
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap makes errors.Is(err, e.Kind) true
+func (e *Error) Unwrap() error { return e.Kind }
+
+// FieldError describes why one input field was rejected
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// ValidationError lists every invalid field of an input. It matches ErrValidation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError creates a ValidationError for a single field
+func NewValidationError(field, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records another invalid field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// OrNil returns nil when no field was added, so validators can collect errors and return the result
+func (e *ValidationError) OrNil() error {
+	if e == nil || len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// Errors returned by the task flow.
+var (
+	ErrTaskNotFound = NewError(ErrNotFound, "task_not_found", "task not found")
+	// ErrVersionConflict is returned when the task was changed by someone else since the expected version
+	ErrVersionConflict = NewError(ErrPreconditionFailed, "version_mismatch", "task was modified by someone else, fetch it again and retry")
+)
+
+// Errors returned by the user flow.
+var (
+	ErrUserNotFound       = NewError(ErrNotFound, "user_not_found", "user not found")
+	ErrUsernameTaken      = NewError(ErrConflict, "username_taken", "username already exists")
+	ErrEmailTaken         = NewError(ErrConflict, "email_taken", "email already exists")
+	ErrInvalidCredentials = NewError(ErrUnauthorized, "invalid_credentials", "invalid credentials")
+	ErrInvalidRole        = NewError(ErrValidation, "invalid_role", "invalid role")
+	ErrCannotModifySelf   = NewError(ErrConflict, "cannot_modify_self", "admins cannot change their own role or delete themselves")
+)
+
+// Errors returned by the token flow.
+var (
+	ErrInvalidRefreshToken = NewError(ErrUnauthorized, "invalid_refresh_token", "invalid or expired refresh token")
+)
@@ -0,0 +1,77 @@
+package Domain
+
+import "time"
+
+// TaskEventType names a kind of task change published to subscribers such as webhooks
+type TaskEventType string
+
+const (
+	EventTaskCreated TaskEventType = "task.created"
+	EventTaskUpdated TaskEventType = "task.updated"
+	// EventTaskStatusChanged is published together with EventTaskUpdated when an update changes the status
+	EventTaskStatusChanged TaskEventType = "task.status_changed"
+	EventTaskDeleted       TaskEventType = "task.deleted"
+	EventTaskRestored      TaskEventType = "task.restored"
+)
+
+// TaskEventTypes lists every event type
+var TaskEventTypes = []TaskEventType{EventTaskCreated, EventTaskUpdated, EventTaskStatusChanged, EventTaskDeleted, EventTaskRestored}
+
+// IsValid checks if the event type is one of TaskEventTypes
+func (t TaskEventType) IsValid() bool {
+	for _, known := range TaskEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// TaskEvent is a change made to a task through the task usecase
+type TaskEvent struct {
+	ID             ID
+	Seq            uint64 // position in the event stream of this process, set by the event bus
+	Type           TaskEventType
+	Task           Task       // the task after the change, or as it was deleted
+	PreviousStatus TaskStatus // only set for EventTaskStatusChanged
+	ActorID        ID
+	ActorUsername  string
+	OccurredAt     time.Time
+}
+
+// NewTaskEvents returns the events published for an audited change of a task from before to after.
+// Purging publishes nothing, the task already left the regular listings when it was deleted.
+func NewTaskEvents(action AuditAction, actor Actor, at time.Time, before, after Task) []TaskEvent {
+	var types []TaskEventType
+	switch action {
+	case AuditCreated:
+		types = []TaskEventType{EventTaskCreated}
+	case AuditUpdated, AuditAssigned, AuditUnassigned:
+		types = []TaskEventType{EventTaskUpdated}
+		if before.Status != after.Status {
+			types = append(types, EventTaskStatusChanged)
+		}
+	case AuditDeleted:
+		types = []TaskEventType{EventTaskDeleted}
+	case AuditRestored:
+		types = []TaskEventType{EventTaskRestored}
+	}
+	events := make([]TaskEvent, 0, len(types))
+	for _, t := range types {
+		event := TaskEvent{ID: NewID(), Type: t, Task: after, ActorID: actor.UserID, ActorUsername: actor.Username, OccurredAt: at}
+		if t == EventTaskStatusChanged {
+			event.PreviousStatus = before.Status
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// TaskEventFeed is a subscription to the task events published in this process
+type TaskEventFeed struct {
+	Position uint64      // Seq of the last event published before the subscription
+	Backlog  []TaskEvent // buffered events after the requested one, oldest first
+	Gap      bool        // some events after the requested one are no longer buffered, the subscriber has to reload
+	Events   <-chan TaskEvent
+	Close    func() // ends the subscription and closes Events, which is also closed when the subscriber falls behind
+}
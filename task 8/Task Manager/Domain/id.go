@@ -0,0 +1,68 @@
+package Domain
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ID identifies a stored entity independently of the storage backend.
+// IDs are 24 character lowercase hex strings, the same shape as a MongoDB ObjectID,
+// so every repository implementation can store and hand out the same values.
+type ID string
+
+// ErrInvalidID is returned when a string is not a well formed ID
+var ErrInvalidID = NewError(ErrValidation, "invalid_id", "invalid ID")
+
+var (
+	idProcessUnique [5]byte
+	idCounter       uint32
+)
+
+func init() {
+	var seed [4]byte
+	if _, err := rand.Read(idProcessUnique[:]); err != nil {
+		panic("cannot initialize ID generator: " + err.Error())
+	}
+	if _, err := rand.Read(seed[:]); err != nil {
+		panic("cannot initialize ID generator: " + err.Error())
+	}
+	idCounter = binary.BigEndian.Uint32(seed[:])
+}
+
+// NewID generates a new unique ID.
+// Like an ObjectID it starts with the creation time in seconds followed by a
+// per-process random value and a counter, so IDs sort in creation order.
+func NewID() ID {
+	var b [12]byte
+	binary.BigEndian.PutUint32(b[0:4], uint32(time.Now().Unix()))
+	copy(b[4:9], idProcessUnique[:])
+	c := atomic.AddUint32(&idCounter, 1)
+	b[9], b[10], b[11] = byte(c>>16), byte(c>>8), byte(c)
+	return ID(hex.EncodeToString(b[:]))
+}
+
+// ParseID validates the string form of an ID
+func ParseID(s string) (ID, error) {
+	s = strings.ToLower(s)
+	if len(s) != 24 {
+		return "", ErrInvalidID
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return "", ErrInvalidID
+	}
+	return ID(s), nil
+}
+
+// String returns the string form of the ID
+func (id ID) String() string {
+	return string(id)
+}
+
+// IsZero checks if the ID is unset
+func (id ID) IsZero() bool {
+	return id == ""
+}
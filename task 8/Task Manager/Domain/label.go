@@ -0,0 +1,58 @@
+package Domain
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLabelLength is the longest label accepted on a task
+const MaxLabelLength = 50
+
+// LabelCount is the number of readable tasks carrying a label, see TaskUsecase.ListLabels
+type LabelCount struct {
+	Label string
+	Count int
+}
+
+// NormalizeLabels trims and lower-cases labels and drops empty and repeated ones, keeping the first position.
+// Labels are matched exactly afterwards, so "Backend" and "backend " are the same label.
+func NormalizeLabels(labels []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label == "" || seen[label] {
+			continue
+		}
+		seen[label] = true
+		normalized = append(normalized, label)
+	}
+	return normalized
+}
+
+// IsValidLabel checks that a label is made of letters, digits and the separators - _ . : /
+// Quotes, spaces and other punctuation are excluded so labels can be matched inside stored lists.
+func IsValidLabel(label string) bool {
+	if label == "" || utf8.RuneCountInString(label) > MaxLabelLength {
+		return false
+	}
+	for _, r := range label {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.:/", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func validateLabels(labels []string, verr *ValidationError) {
+	if len(labels) > MaxTaskLabels {
+		verr.Add("labels", fmt.Sprintf("a task can have at most %d labels", MaxTaskLabels))
+	}
+	for _, label := range labels {
+		if !IsValidLabel(label) {
+			verr.Add("labels", fmt.Sprintf("invalid label %q: use at most %d letters, digits and - _ . : /", label, MaxLabelLength))
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package Domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Permission names an operation a role may perform.
+type Permission string
+
+const (
+	// PermTasksRead allows reading your own tasks
+	PermTasksRead Permission = "tasks:read"
+	// PermTasksWrite allows creating tasks and changing or deleting your own
+	PermTasksWrite Permission = "tasks:write"
+	// PermTasksReadTeam and PermTasksWriteTeam extend reading and writing to the tasks of your team
+	PermTasksReadTeam  Permission = "tasks:read:team"
+	PermTasksWriteTeam Permission = "tasks:write:team"
+	// PermTasksReadAny and PermTasksWriteAny extend reading and writing to every task
+	PermTasksReadAny  Permission = "tasks:read:any"
+	PermTasksWriteAny Permission = "tasks:write:any"
+	// PermTasksReopen allows moving a completed task back to Pending or In Progress
+	PermTasksReopen Permission = "tasks:reopen"
+	// PermTasksPurge allows deleting tasks from the trash for good
+	PermTasksPurge Permission = "tasks:purge"
+	// PermCommentsModerate allows editing and deleting the comments of other users
+	PermCommentsModerate Permission = "comments:moderate"
+	// PermUsersAdmin allows listing users, changing their role and team, and deleting them
+	PermUsersAdmin Permission = "users:admin"
+	// PermWebhooksAdmin allows managing webhook subscriptions and their failed deliveries
+	PermWebhooksAdmin Permission = "webhooks:admin"
+)
+
+// knownPermissions guards against typos in the roles configuration
+var knownPermissions = map[Permission]bool{
+	PermTasksRead:        true,
+	PermTasksWrite:       true,
+	PermTasksReadTeam:    true,
+	PermTasksWriteTeam:   true,
+	PermTasksReadAny:     true,
+	PermTasksWriteAny:    true,
+	PermTasksReopen:      true,
+	PermTasksPurge:       true,
+	PermCommentsModerate: true,
+	PermUsersAdmin:       true,
+	PermWebhooksAdmin:    true,
+}
+
+// DefaultRolePermissions is used when no roles configuration file is given
+var DefaultRolePermissions = map[string][]Permission{
+	RoleUser:    {PermTasksRead, PermTasksWrite},
+	RoleManager: {PermTasksRead, PermTasksWrite, PermTasksReadTeam, PermTasksWriteTeam},
+	RoleAdmin:   {PermTasksRead, PermTasksWrite, PermTasksReadAny, PermTasksWriteAny, PermTasksReopen, PermTasksPurge, PermCommentsModerate, PermUsersAdmin, PermWebhooksAdmin},
+}
+
+// Policy maps roles to the permissions they grant.
+// The zero value grants nothing, use NewPolicy.
+type Policy struct {
+	roles map[string]map[Permission]bool
+}
+
+// NewPolicy builds a Policy from a role to permissions mapping.
+// Unknown permissions are rejected, and the "user" role must exist since registration assigns it.
+func NewPolicy(rolePermissions map[string][]Permission) (*Policy, error) {
+	if _, ok := rolePermissions[RoleUser]; !ok {
+		return nil, fmt.Errorf("the %q role must be configured", RoleUser)
+	}
+	p := &Policy{roles: make(map[string]map[Permission]bool, len(rolePermissions))}
+	for role, perms := range rolePermissions {
+		granted := make(map[Permission]bool, len(perms))
+		for _, perm := range perms {
+			if !knownPermissions[perm] {
+				return nil, fmt.Errorf("role %q: unknown permission %q", role, perm)
+			}
+			granted[perm] = true
+		}
+		p.roles[role] = granted
+	}
+	return p, nil
+}
+
+// DefaultPolicy returns the Policy built from DefaultRolePermissions
+func DefaultPolicy() *Policy {
+	p, err := NewPolicy(DefaultRolePermissions)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// HasRole checks if the role is configured
+func (p *Policy) HasRole(role string) bool {
+	_, ok := p.roles[role]
+	return ok
+}
+
+// Roles returns the configured role names, sorted
+func (p *Policy) Roles() []string {
+	roles := make([]string, 0, len(p.roles))
+	for role := range p.roles {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Allows checks if the actor's role grants the permission
+func (p *Policy) Allows(a Actor, perm Permission) bool {
+	return p.roles[a.Role][perm]
+}
+
+// CanReadTask checks if the actor may see the task.
+// The assignees of a task may read it like its owner.
+func (p *Policy) CanReadTask(a Actor, t *Task) bool {
+	return p.taskAllowed(a, t, true, PermTasksRead, PermTasksReadTeam, PermTasksReadAny)
+}
+
+// CanUpdateTask checks if the actor may change the fields and the status of the task.
+// The assignees of a task may, like its owner.
+func (p *Policy) CanUpdateTask(a Actor, t *Task) bool {
+	return p.taskAllowed(a, t, true, PermTasksWrite, PermTasksWriteTeam, PermTasksWriteAny)
+}
+
+// CanWriteTask checks if the actor may delete, restore or assign the task.
+// Unlike CanUpdateTask, being assigned is not enough.
+func (p *Policy) CanWriteTask(a Actor, t *Task) bool {
+	return p.taskAllowed(a, t, false, PermTasksWrite, PermTasksWriteTeam, PermTasksWriteAny)
+}
+
+// taskAllowed checks the scopes of an action: any task, the tasks of the actor's team, or the actor's own
+// tasks, which include the tasks assigned to the actor if assignees is set
+func (p *Policy) taskAllowed(a Actor, t *Task, assignees bool, own, team, anyTask Permission) bool {
+	switch {
+	case p.Allows(a, anyTask):
+		return true
+	case p.Allows(a, team) && a.Team != "" && t.Team == a.Team:
+		return true
+	default:
+		return p.Allows(a, own) && (t.IsOwnedBy(a.UserID) || assignees && t.IsAssignedTo(a.UserID))
+	}
+}
+
+// CanChangeComment checks if the actor may edit or delete the comment: its author, or a moderator
+func (p *Policy) CanChangeComment(a Actor, c *Comment) bool {
+	return p.Allows(a, PermCommentsModerate) || (!a.UserID.IsZero() && c.AuthorID == a.UserID)
+}
+
+// errReopenForbidden is returned when the actor may change a task but not reopen it
+var errReopenForbidden = NewError(ErrForbidden, "reopen_forbidden", "reopening a completed task requires the tasks:reopen permission")
+
+// CheckStatusChange checks that a task may go from one status to another, from "" when it is created.
+// It returns ErrInvalidStatusTransition for a change the state machine does not allow,
+// and an ErrForbidden error when the actor may not reopen a completed task.
+func (p *Policy) CheckStatusChange(a Actor, from, to TaskStatus) error {
+	if !from.CanTransitionTo(to) {
+		return ErrInvalidStatusTransition
+	}
+	if from.IsReopen(to) && !p.Allows(a, PermTasksReopen) {
+		return errReopenForbidden
+	}
+	return nil
+}
+
+// ScopeTaskQuery restricts the query to the tasks the actor may read.
+// It returns ErrForbidden if the actor may not read any task.
+func (p *Policy) ScopeTaskQuery(a Actor, query TaskQuery) (TaskQuery, error) {
+	switch {
+	case p.Allows(a, PermTasksReadAny):
+		return query, nil
+	case p.Allows(a, PermTasksReadTeam) && a.Team != "":
+		query.OwnerID = a.UserID
+		query.Team = a.Team
+		return query, nil
+	case p.Allows(a, PermTasksRead):
+		query.OwnerID = a.UserID
+		query.Team = ""
+		return query, nil
+	default:
+		return query, ErrForbidden
+	}
+}
@@ -0,0 +1,296 @@
+package Domain
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the FREQ part of a recurrence rule
+type Frequency string
+
+const (
+	FreqDaily   Frequency = "DAILY"
+	FreqWeekly  Frequency = "WEEKLY"
+	FreqMonthly Frequency = "MONTHLY"
+	FreqYearly  Frequency = "YEARLY"
+)
+
+// MaxRecurrenceInterval bounds INTERVAL so that stepping through a rule stays cheap
+const MaxRecurrenceInterval = 1000
+
+// maxSkippedOccurrences bounds how many past occurrences NextOccurrence steps over
+const maxSkippedOccurrences = 100000
+
+type weekdayCode struct {
+	code string
+	day  time.Weekday
+}
+
+// weekdayCodes are the BYDAY values, in the order of a week starting on Monday as RFC 5545 does
+var weekdayCodes = []weekdayCode{
+	{"MO", time.Monday}, {"TU", time.Tuesday}, {"WE", time.Wednesday}, {"TH", time.Thursday},
+	{"FR", time.Friday}, {"SA", time.Saturday}, {"SU", time.Sunday},
+}
+
+// Recurrence is a subset of the RFC 5545 RRULE: FREQ, INTERVAL, BYDAY, COUNT and UNTIL.
+// BYDAY takes plain weekdays ("MO,WE") and is supported with DAILY and WEEKLY only.
+// Occurrences are dates, the time of day of the due date is kept as is.
+type Recurrence struct {
+	Freq     Frequency
+	Interval int            // 1 when not given
+	ByDay    []time.Weekday // in week order, Monday first
+	Count    int            // total number of occurrences, 0 for no limit
+	Until    time.Time      // last day an occurrence may fall on, at any time of it; zero for no limit
+}
+
+// ParseRecurrence reads a rule such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=10".
+// A leading "RRULE:" is accepted and names are case-insensitive. It returns a *ValidationError
+// listing every problem on the recurrence field.
+func ParseRecurrence(rule string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	var problems []string
+	seen := make(map[string]bool)
+	rule = strings.TrimSpace(rule)
+	if len(rule) >= 6 && strings.EqualFold(rule[:6], "RRULE:") {
+		rule = rule[6:]
+	}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			problems = append(problems, fmt.Sprintf("expected NAME=VALUE, got %q", part))
+			continue
+		}
+		if seen[name] {
+			problems = append(problems, name+" is given twice")
+			continue
+		}
+		seen[name] = true
+		switch name {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if !r.Freq.IsValid() {
+				problems = append(problems, fmt.Sprintf("invalid FREQ %q: must be one of DAILY, WEEKLY, MONTHLY, YEARLY", value))
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > MaxRecurrenceInterval {
+				problems = append(problems, fmt.Sprintf("INTERVAL must be a number from 1 to %d", MaxRecurrenceInterval))
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				problems = append(problems, "COUNT must be a positive number")
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseRecurrenceDate(value)
+			if err != nil {
+				problems = append(problems, "UNTIL must be a date such as 20251231 or 20251231T235959Z")
+			}
+			r.Until = until
+		case "BYDAY":
+			days, err := parseWeekdays(value)
+			if err != nil {
+				problems = append(problems, err.Error())
+			}
+			r.ByDay = days
+		default:
+			problems = append(problems, fmt.Sprintf("unsupported rule part %q", name))
+		}
+	}
+	switch {
+	case !seen["FREQ"]:
+		problems = append(problems, "FREQ is required")
+	case len(r.ByDay) > 0 && r.Freq != FreqDaily && r.Freq != FreqWeekly:
+		problems = append(problems, "BYDAY is only supported with FREQ=DAILY or FREQ=WEEKLY")
+	}
+	if seen["COUNT"] && seen["UNTIL"] {
+		problems = append(problems, "COUNT and UNTIL cannot be combined")
+	}
+	if len(problems) > 0 {
+		verr := &ValidationError{}
+		for _, p := range problems {
+			verr.Add("recurrence", p)
+		}
+		return Recurrence{}, verr
+	}
+	return r, nil
+}
+
+// IsValid checks if the frequency is one of the supported frequencies
+func (f Frequency) IsValid() bool {
+	return f == FreqDaily || f == FreqWeekly || f == FreqMonthly || f == FreqYearly
+}
+
+// String returns the rule in a canonical form, with the parts in a fixed order and the defaults left out
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var codes []string
+		for _, wc := range weekdayCodes {
+			if slices.Contains(r.ByDay, wc.day) {
+				codes = append(codes, wc.code)
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// After returns the first occurrence strictly after t, ignoring COUNT and UNTIL.
+// ok is false if the rule has no such occurrence, e.g. FREQ=DAILY;INTERVAL=7;BYDAY=MO from a Tuesday.
+// Months and years without the day of t, such as February 30, are skipped as RFC 5545 requires.
+func (r Recurrence) After(t time.Time) (next time.Time, ok bool) {
+	interval := max(r.Interval, 1)
+	switch r.Freq {
+	case FreqDaily:
+		// Stepping by the interval cycles through every weekday reachable within 7 steps
+		for k := 1; k <= 7; k++ {
+			next = t.AddDate(0, 0, k*interval)
+			if len(r.ByDay) == 0 || slices.Contains(r.ByDay, next.Weekday()) {
+				return next, true
+			}
+		}
+	case FreqWeekly:
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*interval), true
+		}
+		monday := t.AddDate(0, 0, -daysSinceMonday(t.Weekday()))
+		for _, wc := range weekdayCodes {
+			if slices.Contains(r.ByDay, wc.day) && daysSinceMonday(wc.day) > daysSinceMonday(t.Weekday()) {
+				return monday.AddDate(0, 0, daysSinceMonday(wc.day)), true
+			}
+		}
+		monday = monday.AddDate(0, 0, 7*interval)
+		for _, wc := range weekdayCodes {
+			if slices.Contains(r.ByDay, wc.day) {
+				return monday.AddDate(0, 0, daysSinceMonday(wc.day)), true
+			}
+		}
+	case FreqMonthly:
+		// A day exists at least every few months, a 31st at the latest 7 months on
+		for k := 1; k <= 12; k++ {
+			if next, ok = sameDayShifted(t, 0, k*interval); ok {
+				return next, true
+			}
+		}
+	case FreqYearly:
+		// February 29 recurs at least every 8 years, every 400 years with any interval
+		for k := 1; k <= 400; k++ {
+			if next, ok = sameDayShifted(t, k*interval, 0); ok {
+				return next, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// Allows checks that the occurrence-th occurrence, due at due, is within COUNT and UNTIL.
+// UNTIL includes its whole day, whatever the time of day of the due dates.
+func (r Recurrence) Allows(occurrence int, due time.Time) bool {
+	if r.Count > 0 && occurrence > r.Count {
+		return false
+	}
+	if !r.Until.IsZero() && !due.Before(r.Until.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// NextOccurrence returns the task that follows t in its recurring series, due on the next
+// date of the rule after t.DueDate that is not before the day of now. Occurrences missed
+// because t was completed late are skipped but still count towards COUNT.
+// ok is false if t does not recur or the series has ended.
+func (t *Task) NextOccurrence(now time.Time) (next Task, ok bool, err error) {
+	if t.Recurrence == "" {
+		return Task{}, false, nil
+	}
+	rule, err := ParseRecurrence(t.Recurrence)
+	if err != nil {
+		return Task{}, false, err
+	}
+	y, m, d := now.UTC().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	// Step in UTC, where due dates are days, so daylight saving time in other zones cannot shift them
+	due, occurrence := t.DueDate.UTC(), max(t.Occurrence, 1)
+	for i := 0; i < maxSkippedOccurrences; i++ {
+		if due, ok = rule.After(due); !ok {
+			return Task{}, false, nil
+		}
+		occurrence++
+		if !rule.Allows(occurrence, due) {
+			return Task{}, false, nil
+		}
+		if !due.Before(today) {
+			return Task{
+				OwnerID:     t.OwnerID,
+				Team:        t.Team,
+				Title:       t.Title,
+				Description: t.Description,
+				DueDate:     due,
+				Status:      StatusPending,
+				Priority:    t.Priority,
+				Labels:      slices.Clone(t.Labels),
+				AssigneeIDs: slices.Clone(t.AssigneeIDs),
+				ParentID:    t.ParentID,
+				Recurrence:  t.Recurrence,
+				Occurrence:  occurrence,
+			}, true, nil
+		}
+	}
+	return Task{}, false, nil
+}
+
+// parseRecurrenceDate reads an RFC 5545 DATE or DATE-TIME. A DATE-TIME only keeps its day,
+// as due dates are days.
+func parseRecurrenceDate(s string) (time.Time, error) {
+	if len(s) > 8 && s[8] == 'T' {
+		if _, err := time.Parse("20060102T150405", strings.TrimSuffix(s, "Z")); err != nil {
+			return time.Time{}, err
+		}
+		s = s[:8]
+	}
+	return time.Parse("20060102", s)
+}
+
+func parseWeekdays(s string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, code := range strings.Split(s, ",") {
+		i := slices.IndexFunc(weekdayCodes, func(wc weekdayCode) bool { return wc.code == code })
+		if i < 0 {
+			return nil, fmt.Errorf("invalid BYDAY %q: expected weekdays such as MO,WE,FR", code)
+		}
+		if !slices.Contains(days, weekdayCodes[i].day) {
+			days = append(days, weekdayCodes[i].day)
+		}
+	}
+	return days, nil
+}
+
+func daysSinceMonday(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
+
+// sameDayShifted moves t by years and months keeping its day of the month, ok is false
+// if the target month does not have that day
+func sameDayShifted(t time.Time, years, months int) (time.Time, bool) {
+	first := time.Date(t.Year()+years, t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	shifted := first.AddDate(0, 0, t.Day()-1)
+	return shifted, shifted.Month() == first.Month()
+}
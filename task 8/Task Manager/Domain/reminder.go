@@ -0,0 +1,55 @@
+package Domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReminderKind tells whether a reminder warns about an upcoming or a missed due date
+type ReminderKind string
+
+const (
+	ReminderDueSoon ReminderKind = "due_soon"
+	ReminderOverdue ReminderKind = "overdue"
+)
+
+// Recipient is someone a reminder is sent to, the owner or an assignee of the task.
+// Email is empty for users who did not give one.
+type Recipient struct {
+	UserID   ID
+	Username string
+	Email    string
+}
+
+// Reminder tells the people working on a task that it is due soon or overdue
+type Reminder struct {
+	Kind       ReminderKind
+	Task       Task
+	Recipients []Recipient
+}
+
+// ReminderKey identifies a reminder. A task is reminded once per kind and due date,
+// so moving the due date brings new reminders.
+type ReminderKey struct {
+	TaskID  ID
+	Kind    ReminderKind
+	DueDate time.Time
+}
+
+// Key returns the key the reminder is deduplicated with
+func (r Reminder) Key() ReminderKey {
+	return ReminderKey{TaskID: r.Task.ID, Kind: r.Kind, DueDate: r.Task.DueDate}
+}
+
+// Subject is a one line summary of the reminder, used as the email subject
+func (r Reminder) Subject() string {
+	if r.Kind == ReminderOverdue {
+		return fmt.Sprintf("Task %q is overdue", r.Task.Title)
+	}
+	return fmt.Sprintf("Task %q is due on %s", r.Task.Title, r.Task.DueDate.Format("02-01-2006"))
+}
+
+// IsOverdueAt checks if the task's due date is before at
+func (t *Task) IsOverdueAt(at time.Time) bool {
+	return at.After(t.DueDate)
+}
@@ -1,21 +1,267 @@
 package Domain
 
-// import "time"
-
 import (
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
+)
+
+// TaskStatus is the state of a task, see CanTransitionTo for the allowed changes.
+type TaskStatus string
+
+const (
+	StatusPending    TaskStatus = "Pending"
+	StatusInProgress TaskStatus = "In Progress"
+	StatusCompleted  TaskStatus = "Completed"
+)
+
+// TaskPriority ranks how urgent a task is. Tasks created before priorities existed have none ("").
+type TaskPriority string
+
+const (
+	PriorityLow    TaskPriority = "low"
+	PriorityMedium TaskPriority = "medium"
+	PriorityHigh   TaskPriority = "high"
+	PriorityUrgent TaskPriority = "urgent"
+)
+
+// IsValid checks if the priority is one of the known priorities
+func (p TaskPriority) IsValid() bool {
+	return p == PriorityLow || p == PriorityMedium || p == PriorityHigh || p == PriorityUrgent
+}
+
+// Limits checked by Task.Validate
+const (
+	MaxTaskTitleLength       = 200
+	MaxTaskDescriptionLength = 2000
+	MaxTaskAssignees         = 20
+	MaxTaskBlockers          = 50
+	MaxTaskLabels            = 20
 )
 
+// ErrDependencyCycle is returned when a parent or blocker would make a task wait for itself
+var ErrDependencyCycle = NewError(ErrValidation, "dependency_cycle", "the parent or blockers would create a dependency cycle")
+
+// ErrTaskBlocked is returned when a task is completed while a blocker or subtask is not
+var ErrTaskBlocked = NewError(ErrConflict, "task_blocked", "the task has incomplete blockers or subtasks")
+
+// ErrInvalidStatusTransition is returned when a task cannot move from its current status to the requested one
+var ErrInvalidStatusTransition = NewError(ErrValidation, "invalid_status_transition", "status change not allowed")
+
+// statusTransitions lists where a task may go from each status, "" being a task that is created.
+// Staying in the same status is always allowed. Leaving Completed reopens the task, which
+// needs the tasks:reopen permission on top, see Policy.CheckStatusChange.
+var statusTransitions = map[TaskStatus]map[TaskStatus]bool{
+	"":               {StatusPending: true, StatusInProgress: true},
+	StatusPending:    {StatusInProgress: true, StatusCompleted: true},
+	StatusInProgress: {StatusPending: true, StatusCompleted: true},
+	StatusCompleted:  {StatusPending: true, StatusInProgress: true},
+}
+
+// IsValid checks if the status is one of the known statuses
+func (s TaskStatus) IsValid() bool {
+	return s == StatusPending || s == StatusInProgress || s == StatusCompleted
+}
+
+// CanTransitionTo checks if a task in status s may be changed to status to
+func (s TaskStatus) CanTransitionTo(to TaskStatus) bool {
+	if s == to && s != "" {
+		return true
+	}
+	return statusTransitions[s][to]
+}
+
+// IsReopen checks if going from s to to reopens a completed task
+func (s TaskStatus) IsReopen(to TaskStatus) bool {
+	return s == StatusCompleted && to != StatusCompleted
+}
+
 type Task struct {
-	ID          primitive.ObjectID
+	ID          ID
+	OwnerID     ID
+	Team        string // team of the owner when the task was created
 	Title       string
 	Description string
 	DueDate     time.Time
-	Status      string
+	Status      TaskStatus
+	Priority    TaskPriority
+	Labels      []string  // see NormalizeLabels
+	AssigneeIDs []ID      // users the task is assigned to, changed with Assign and Unassign only
+	ParentID    ID        // the task this one is a subtask of, zero for a top level task
+	BlockedBy   []ID      // tasks that must be completed before this one
+	Recurrence  string    // RRULE subset, see ParseRecurrence; "" for a one-off task
+	Occurrence  int       // position of the task in its recurring series, 1 for the first
+	Version     int64     // incremented by the repository on every write, 1 for a new task
+	DeletedAt   time.Time // zero unless the task is in the trash
+	DeletedBy   ID
+}
+
+// TaskPatch is a partial update, nil fields are left unchanged.
+// Version is the version the client last saw, 0 skips the check.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Status      *TaskStatus
+	Priority    *TaskPriority
+	Labels      *[]string
+	ParentID    *ID
+	BlockedBy   *[]ID
+	Recurrence  *string
+	Version     int64
+}
+
+// Apply returns the task with the patched fields replaced
+func (p TaskPatch) Apply(t Task) Task {
+	if p.Title != nil {
+		t.Title = *p.Title
+	}
+	if p.Description != nil {
+		t.Description = *p.Description
+	}
+	if p.DueDate != nil {
+		t.DueDate = *p.DueDate
+	}
+	if p.Status != nil {
+		t.Status = *p.Status
+	}
+	if p.Priority != nil {
+		t.Priority = *p.Priority
+	}
+	if p.Labels != nil {
+		t.Labels = *p.Labels
+	}
+	if p.ParentID != nil {
+		t.ParentID = *p.ParentID
+	}
+	if p.BlockedBy != nil {
+		t.BlockedBy = *p.BlockedBy
+	}
+	if p.Recurrence != nil {
+		t.Recurrence = *p.Recurrence
+	}
+	return t
+}
+
+// Validate checks the fields a client provides. It returns a *ValidationError listing every invalid field.
+func (t *Task) Validate() error {
+	verr := &ValidationError{}
+	t.validate(verr)
+	return verr.OrNil()
+}
+
+// ValidateNew validates a task about to be created. On top of Validate, the due date
+// must not be earlier than the day of now; existing tasks may of course become overdue.
+func (t *Task) ValidateNew(now time.Time) error {
+	verr := &ValidationError{}
+	t.validate(verr)
+	y, m, d := now.UTC().Date()
+	if !t.DueDate.IsZero() && t.DueDate.Before(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)) {
+		verr.Add("due_date", "due date must not be in the past")
+	}
+	return verr.OrNil()
+}
+
+func (t *Task) validate(verr *ValidationError) {
+	title := strings.TrimSpace(t.Title)
+	switch {
+	case title == "":
+		verr.Add("title", "title is required")
+	case utf8.RuneCountInString(title) > MaxTaskTitleLength:
+		verr.Add("title", fmt.Sprintf("title must be at most %d characters", MaxTaskTitleLength))
+	}
+	if utf8.RuneCountInString(t.Description) > MaxTaskDescriptionLength {
+		verr.Add("description", fmt.Sprintf("description must be at most %d characters", MaxTaskDescriptionLength))
+	}
+	if t.DueDate.IsZero() {
+		verr.Add("due_date", "due date is required")
+	}
+	if !t.Status.IsValid() {
+		verr.Add("status", fmt.Sprintf("invalid status %q: must be one of Pending, In Progress, Completed", t.Status))
+	}
+	if t.Priority != "" && !t.Priority.IsValid() {
+		verr.Add("priority", fmt.Sprintf("invalid priority %q: must be one of low, medium, high, urgent", t.Priority))
+	}
+	validateLabels(t.Labels, verr)
+	if len(t.AssigneeIDs) > MaxTaskAssignees {
+		verr.Add("assignee_ids", fmt.Sprintf("a task can have at most %d assignees", MaxTaskAssignees))
+	}
+	switch {
+	case len(t.BlockedBy) > MaxTaskBlockers:
+		verr.Add("blocked_by", fmt.Sprintf("a task can have at most %d blockers", MaxTaskBlockers))
+	case hasDuplicates(t.BlockedBy):
+		verr.Add("blocked_by", "blocked_by must not contain duplicates")
+	}
+	if t.Recurrence != "" {
+		var rerr *ValidationError
+		if _, err := ParseRecurrence(t.Recurrence); errors.As(err, &rerr) {
+			verr.Fields = append(verr.Fields, rerr.Fields...)
+		}
+	}
+	if !t.ID.IsZero() && t.ParentID == t.ID {
+		verr.Add("parent_id", "a task cannot be its own parent")
+	}
+	if !t.ID.IsZero() && slices.Contains(t.BlockedBy, t.ID) {
+		verr.Add("blocked_by", "a task cannot block itself")
+	}
+}
+
+func hasDuplicates(ids []ID) bool {
+	seen := make(map[ID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return true
+		}
+		seen[id] = true
+	}
+	return false
 }
 
 // IsOverdue checks if the task's due date is in the past.
 func (t *Task) IsOverdue() bool {
-	return time.Now().After(t.DueDate)
+	return t.IsOverdueAt(time.Now())
+}
+
+// IsDeleted checks if the task was moved to the trash
+func (t *Task) IsDeleted() bool {
+	return !t.DeletedAt.IsZero()
+}
+
+// IsOwnedBy checks if the task belongs to the given user.
+func (t *Task) IsOwnedBy(userID ID) bool {
+	return !userID.IsZero() && t.OwnerID == userID
+}
+
+// IsAssignedTo checks if the given user is one of the task's assignees.
+func (t *Task) IsAssignedTo(userID ID) bool {
+	return !userID.IsZero() && slices.Contains(t.AssigneeIDs, userID)
+}
+
+// Assign adds the users that are not assigned yet, keeping the existing order
+func (t *Task) Assign(userIDs ...ID) {
+	for _, id := range userIDs {
+		if !slices.Contains(t.AssigneeIDs, id) {
+			t.AssigneeIDs = append(slices.Clip(t.AssigneeIDs), id)
+		}
+	}
+}
+
+// Unassign removes the users from the assignees, users that are not assigned are ignored
+func (t *Task) Unassign(userIDs ...ID) {
+	var kept []ID
+	for _, id := range t.AssigneeIDs {
+		if !slices.Contains(userIDs, id) {
+			kept = append(kept, id)
+		}
+	}
+	t.AssigneeIDs = kept
+}
+
+// TaskNode is a task with its subtasks, as returned by TaskUsecase.GetTaskTree
+type TaskNode struct {
+	Task     Task
+	Subtasks []TaskNode
 }
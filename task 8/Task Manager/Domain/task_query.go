@@ -0,0 +1,82 @@
+package Domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// TaskSort selects the ordering of a task listing.
+type TaskSort string
+
+const (
+	// SortByCreation lists tasks in the order they were created
+	SortByCreation    TaskSort = ""
+	SortByDueDate     TaskSort = "due_date"
+	SortByDueDateDesc TaskSort = "-due_date"
+	SortByTitle       TaskSort = "title"
+)
+
+const (
+	DefaultTaskPageSize = 20
+	MaxTaskPageSize     = 100
+)
+
+// ErrInvalidCursor is returned when a pagination token cannot be decoded
+// or was issued for a different sort order.
+var ErrInvalidCursor = NewError(ErrValidation, "invalid_cursor", "invalid pagination cursor")
+
+// TaskQuery describes a filtered, sorted and paginated task listing.
+// Zero values mean "no constraint".
+// When both OwnerID and Team are set, tasks matching either of them are listed.
+type TaskQuery struct {
+	OwnerID    ID
+	Team       string
+	AssigneeID ID // only the tasks assigned to this user
+	Status     TaskStatus
+	Priority   TaskPriority
+	Label      string // tasks carrying this label
+	DueAfter   time.Time
+	DueBefore  time.Time
+	Title      string // case-insensitive substring match
+	Sort       TaskSort
+	Cursor     string // opaque token taken from a previous TaskPage
+	Limit      int
+	Deleted    bool // list the tasks in the trash instead of the live ones
+}
+
+// TaskPage is one page of a task listing.
+// NextCursor is empty when there are no more results.
+type TaskPage struct {
+	Tasks      []Task
+	NextCursor string
+}
+
+// Validate checks the query and fills in the default page size.
+// It returns a *ValidationError listing every invalid parameter.
+func (q *TaskQuery) Validate() error {
+	verr := &ValidationError{}
+	switch q.Sort {
+	case SortByCreation, SortByDueDate, SortByDueDateDesc, SortByTitle:
+	default:
+		verr.Add("sort", fmt.Sprintf("invalid sort %q: must be one of due_date, -due_date, title", q.Sort))
+	}
+	if q.Status != "" && !q.Status.IsValid() {
+		verr.Add("status", fmt.Sprintf("invalid status %q: must be one of Pending, In Progress, Completed", q.Status))
+	}
+	if q.Priority != "" && !q.Priority.IsValid() {
+		verr.Add("priority", fmt.Sprintf("invalid priority %q: must be one of low, medium, high, urgent", q.Priority))
+	}
+	if q.Label != "" && !IsValidLabel(q.Label) {
+		verr.Add("label", fmt.Sprintf("invalid label %q", q.Label))
+	}
+	if q.Limit < 0 || q.Limit > MaxTaskPageSize {
+		verr.Add("limit", fmt.Sprintf("invalid limit %d: must be between 1 and %d", q.Limit, MaxTaskPageSize))
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultTaskPageSize
+	}
+	if !q.DueAfter.IsZero() && !q.DueBefore.IsZero() && q.DueBefore.Before(q.DueAfter) {
+		verr.Add("due_before", "due_before must not be earlier than due_after")
+	}
+	return verr.OrNil()
+}
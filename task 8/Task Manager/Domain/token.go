@@ -0,0 +1,31 @@
+package Domain
+
+import "time"
+
+// AccessToken is a signed, short-lived access token.
+// JTI identifies it so it can be revoked before it expires.
+type AccessToken struct {
+	Token     string
+	JTI       string
+	ExpiresAt time.Time
+}
+
+// RefreshToken is the server side record of an issued refresh token.
+// Only a hash of the token is stored, never the token itself.
+type RefreshToken struct {
+	TokenHash string
+	UserID    ID
+	ExpiresAt time.Time
+}
+
+// IsExpired checks if the refresh token can no longer be used at the given time
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// TokenPair is what a client receives on login and on every refresh
+type TokenPair struct {
+	AccessToken      AccessToken
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
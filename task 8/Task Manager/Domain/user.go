@@ -1,22 +1,57 @@
 package Domain
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
+)
+
+// Roles of the default Policy. Registration always creates RoleUser, only admins hand out other roles.
+// More roles can be defined in the roles configuration file.
+const (
+	RoleUser    = "user"
+	RoleManager = "manager" // edits any task on their team without being an admin
+	RoleAdmin   = "admin"
+)
 
-	"go.mongodb.org/mongo-driver/bson/primitive"
+// Password limits checked before hashing. bcrypt only uses the first 72 bytes of a password.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
 )
 
 type User struct {
-	ID       primitive.ObjectID
+	ID       ID
 	Username string
 	Password string // Hashed password
 	Email    string
 	Role     string // e.g., "admin", "user"
+	Team     string // optional, managers act on the tasks of their team
+}
+
+// ValidateNew validates a user about to be created, while Password still holds the plain password.
+// The email is optional but must be valid when given. It returns a *ValidationError listing every invalid field.
+func (u *User) ValidateNew() error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(u.Username) == "" {
+		verr.Add("username", "username is required")
+	}
+	switch {
+	case len(u.Password) < MinPasswordLength:
+		verr.Add("password", fmt.Sprintf("password must be at least %d characters", MinPasswordLength))
+	case len(u.Password) > MaxPasswordLength:
+		verr.Add("password", fmt.Sprintf("password must be at most %d bytes", MaxPasswordLength))
+	}
+	if u.Email != "" && !u.IsValidEmail() {
+		verr.Add("email", "email is not a valid address")
+	}
+	return verr.OrNil()
 }
 
 // IsValidEmail checks if the user's email is valid.
 func (u *User) IsValidEmail() bool {
 	// Simple regex for demonstration; you can use a more robust one.
 	re := regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
-	return re.MatchString(u.Email)
+	// The address may end up in mail headers, so line breaks are never allowed
+	return !strings.ContainsAny(u.Email, "\r\n") && re.MatchString(u.Email)
 }
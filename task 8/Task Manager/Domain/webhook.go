@@ -0,0 +1,66 @@
+package Domain
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// MinWebhookSecretLength is the shortest secret accepted to sign webhook deliveries
+const MinWebhookSecretLength = 16
+
+// Errors returned by the webhook flow.
+var (
+	ErrWebhookNotFound    = NewError(ErrNotFound, "webhook_not_found", "webhook subscription not found")
+	ErrDeadLetterNotFound = NewError(ErrNotFound, "dead_letter_not_found", "dead letter not found")
+	ErrWebhooksStopped    = NewError(ErrUnavailable, "webhooks_stopped", "webhook deliveries are stopped while the server shuts down")
+)
+
+// WebhookSubscription asks for task events to be posted to a URL.
+// The deliveries are signed with Secret, which is never returned by the API.
+type WebhookSubscription struct {
+	ID        ID
+	URL       string
+	Secret    string
+	Events    []TaskEventType // the events to deliver, every event when empty
+	CreatedBy ID
+	CreatedAt time.Time
+}
+
+// Validate checks the fields an admin provides. It returns a *ValidationError.
+func (s *WebhookSubscription) Validate() error {
+	verr := &ValidationError{}
+	if u, err := url.Parse(s.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "url must be an absolute http or https URL")
+	}
+	if utf8.RuneCountInString(s.Secret) < MinWebhookSecretLength {
+		verr.Add("secret", fmt.Sprintf("secret must be at least %d characters", MinWebhookSecretLength))
+	}
+	for _, event := range s.Events {
+		if !event.IsValid() {
+			verr.Add("events", fmt.Sprintf("unknown event %q", event))
+		}
+	}
+	return verr.OrNil()
+}
+
+// Wants checks if the subscription asked for events of type t
+func (s *WebhookSubscription) Wants(t TaskEventType) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, t)
+}
+
+// WebhookDeadLetter is a delivery that still failed after every retry.
+// Payload is the exact body that was posted, so retrying it sends the same event.
+type WebhookDeadLetter struct {
+	ID             ID
+	SubscriptionID ID
+	URL            string
+	EventID        ID
+	EventType      TaskEventType
+	Payload        []byte
+	Attempts       int
+	LastError      string
+	FailedAt       time.Time
+}
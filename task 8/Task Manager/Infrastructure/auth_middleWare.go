@@ -1,74 +1,91 @@
 package Infrastructure
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"strings"
+	"taskmanager/Domain"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// JWT secret key should be stored securely, e.g., environment variable
-// Use package-level jwtSecret from jwt_services.go
+// JWT keys should be stored securely, e.g., environment variable or PEM files
+// Use the package-level KeySet from jwt_keys.go
 
-func AuthenticateJWT() gin.HandlerFunc {
+// RevocationChecker reports whether an access token was revoked before it expired, e.g. on logout
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// Errors of the authentication middleware, they are written as problem documents by the Delivery error handler
+var (
+	errMissingToken = Domain.NewError(Domain.ErrUnauthorized, "missing_token", "Authorization header required")
+	errTokenRevoked = Domain.NewError(Domain.ErrUnauthorized, "token_revoked", "Token has been revoked")
+)
+
+func invalidToken(message string) error {
+	return Domain.NewError(Domain.ErrUnauthorized, "invalid_token", message)
+}
+
+// abort stops the handler chain and leaves err for the error handler to report
+func abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// AuthenticateJWT validates the bearer token and rejects tokens whose jti has been revoked
+func AuthenticateJWT(revocations RevocationChecker) gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+			abort(c, errMissingToken)
 			return
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid Authorization header format"})
-			c.Abort()
+			abort(c, invalidToken("Invalid Authorization header format"))
 			return
 		}
 
 		tokenString := parts[1]
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate the signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				// Log the unexpected method for debugging, but don't expose too much info to client
-				log.Printf("Unexpected signing method: %v", token.Header["alg"])
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return jwtSecret, nil
-		})
+		ks, err := currentKeySet()
+		if err != nil {
+			log.Printf("Error parsing token: %v", err)
+			abort(c, err)
+			return
+		}
+		// keyFunc selects the key by kid and rejects a signing method that does not match it
+		token, err := jwt.Parse(tokenString, ks.keyFunc, jwt.WithExpirationRequired())
 
 		// CRITICAL: Handle the error from jwt.Parse
 		if err != nil {
 			log.Printf("Error parsing token: %v", err) // Log the actual error for server-side debugging
 			// Provide a generic error message to the client for security
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			abort(c, invalidToken("Invalid or expired token"))
 			return
 		}
 
 		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
 			// Set user information in context for subsequent handlers
 			// Type assertion for safety
-			// if userID, ok := claims["user_id"].(float64); ok { // JWT numbers are float64
-			// 	c.Set("user_id", int(userID)) // Store as int if appropriate
-			// } else {
-			// 	log.Printf("user_id claim is not a number: %v", claims["user_id"])
-			// 	c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
-			// 	c.Abort()
-			// 	return
-			// }
+			if userID, ok := claims["user_id"].(string); ok {
+				c.Set("user_id", userID)
+			} else {
+				log.Printf("user_id claim is not a string: %v", claims["user_id"])
+				abort(c, invalidToken("Invalid user ID in token"))
+				return
+			}
 
 			if username, ok := claims["username"].(string); ok {
 				c.Set("username", username)
 			} else {
 				log.Printf("username claim is not a string: %v", claims["username"])
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username in token"})
-				c.Abort()
+				abort(c, invalidToken("Invalid username in token"))
 				return
 			}
 
@@ -76,18 +93,43 @@ func AuthenticateJWT() gin.HandlerFunc {
 				c.Set("role", role)
 			} else {
 				log.Printf("role claim is not a string: %v", claims["role"])
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid role in token"})
-				c.Abort()
+				abort(c, invalidToken("Invalid role in token"))
 				return
 			}
 
+			// team is optional, users outside any team have none
+			if team, ok := claims["team"].(string); ok {
+				c.Set("team", team)
+			}
+
+			jti, ok := claims["jti"].(string)
+			if !ok || jti == "" {
+				log.Printf("jti claim is missing or not a string: %v", claims["jti"])
+				abort(c, invalidToken("Invalid token ID in token"))
+				return
+			}
+			revoked, err := revocations.IsTokenRevoked(c.Request.Context(), jti)
+			if err != nil {
+				log.Printf("Error checking token revocation: %v", err)
+				abort(c, fmt.Errorf("failed to check token revocation: %w", err))
+				return
+			}
+			if revoked {
+				abort(c, errTokenRevoked)
+				return
+			}
+			c.Set("jti", jti)
+			// jwt.Parse already rejected tokens without a valid exp (WithExpirationRequired)
+			if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+				c.Set("token_expires_at", exp.Time)
+			}
+
 			c.Next()
 		} else {
 			// This block should ideally be rarely hit if jwt.Parse error handling is robust,
 			// but it catches cases where token is not valid *after* parsing (e.g., claims issues).
 			log.Printf("Token is not valid or claims are invalid: %v", token.Valid)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+			abort(c, invalidToken("Invalid token claims"))
 			return
 		}
 	}
@@ -96,16 +138,29 @@ func AuthorizeRole(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{"error": "User role not found in context"})
-			c.Abort()
+			abort(c, Domain.NewError(Domain.ErrForbidden, "forbidden", "User role not found in context"))
 			return
 		}
 
 		if userRole != requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Access denied. Requires '%s' role.", requiredRole)})
-			c.Abort()
+			abort(c, Domain.NewError(Domain.ErrForbidden, "missing_role", fmt.Sprintf("Access denied. Requires '%s' role.", requiredRole)))
 			return
 		}
 		c.Next()
 	}
 }
+
+// RequirePermission rejects with 403 unless the role set by AuthenticateJWT grants every one of perms.
+// Finer checks, e.g. whether a task belongs to the caller's team, are left to the usecases.
+func RequirePermission(policy *Domain.Policy, perms ...Domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := Domain.Actor{Role: c.GetString("role"), Team: c.GetString("team")}
+		for _, perm := range perms {
+			if !policy.Allows(actor, perm) {
+				abort(c, Domain.NewError(Domain.ErrForbidden, "missing_permission", fmt.Sprintf("Access denied. Requires the '%s' permission.", perm)))
+				return
+			}
+		}
+		c.Next()
+	}
+}
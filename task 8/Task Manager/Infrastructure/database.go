@@ -42,6 +42,11 @@ func (m *MongoDBClient) Disconnect() error {
 	return m.Client.Disconnect(ctx)
 }
 
+// Ping checks that the database answers, it backs the readiness check
+func (m *MongoDBClient) Ping(ctx context.Context) error {
+	return m.Client.Ping(ctx, nil)
+}
+
 // GetCollection returns a collection from the database
 func (m *MongoDBClient) GetCollection(dbName, collectionName string) *mongo.Collection {
 	return m.Client.Database(dbName).Collection(collectionName)
@@ -0,0 +1,129 @@
+package Infrastructure
+
+import (
+	"log"
+	"sync"
+	"taskmanager/Domain"
+)
+
+// TaskEventListener receives every event published on an EventBus, such as the WebhookDispatcher.
+// It is called while the event is published, so it must not block.
+type TaskEventListener interface {
+	PublishTaskEvent(event Domain.TaskEvent)
+}
+
+const (
+	// DefaultEventReplaySize is the number of events an EventBus keeps for resuming subscribers
+	DefaultEventReplaySize = 1000
+	// subscriberBuffer is the number of events a subscriber may lag behind before it is dropped
+	subscriberBuffer = 64
+)
+
+// EventBus hands the task events published by the task usecase to its listeners and subscribers in this process.
+// It implements Usecases.TaskEventPublisher and Usecases.TaskEventSource.
+// Every event gets the next sequence number, the last events are kept so a subscriber can resume after
+// a reconnect. A subscriber that does not keep up is dropped rather than slowing down the publishers.
+type EventBus struct {
+	mu          sync.Mutex
+	listeners   []TaskEventListener
+	seq         uint64
+	replay      []Domain.TaskEvent // the last replaySize events, oldest first
+	replaySize  int
+	subscribers map[*busSubscriber]struct{}
+	closed      bool
+}
+
+type busSubscriber struct {
+	events chan Domain.TaskEvent
+}
+
+// NewEventBus creates an EventBus keeping replaySize events, DefaultEventReplaySize if it is not positive
+func NewEventBus(replaySize int, listeners ...TaskEventListener) *EventBus {
+	if replaySize <= 0 {
+		replaySize = DefaultEventReplaySize
+	}
+	return &EventBus{
+		listeners:   listeners,
+		replaySize:  replaySize,
+		subscribers: make(map[*busSubscriber]struct{}),
+	}
+}
+
+// PublishTaskEvent numbers the event and passes it on without blocking
+func (b *EventBus) PublishTaskEvent(event Domain.TaskEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	event.Seq = b.seq
+	if len(b.replay) == b.replaySize {
+		b.replay = b.replay[1:]
+	}
+	b.replay = append(b.replay, event)
+
+	for _, listener := range b.listeners {
+		listener.PublishTaskEvent(event)
+	}
+	for s := range b.subscribers {
+		select {
+		case s.events <- event:
+		default:
+			log.Printf("Event stream subscriber fell %d events behind, dropping it", subscriberBuffer)
+			b.remove(s)
+		}
+	}
+}
+
+// Subscribe returns a feed of the events published from now on. With resume, the feed starts
+// with the buffered events after the one numbered after; Gap is set if some of them are gone,
+// or if after is unknown because it was handed out before a restart.
+func (b *EventBus) Subscribe(after uint64, resume bool) *Domain.TaskEventFeed {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	feed := &Domain.TaskEventFeed{Position: b.seq}
+	if resume {
+		feed.Backlog, feed.Gap = b.since(after)
+	}
+
+	s := &busSubscriber{events: make(chan Domain.TaskEvent, subscriberBuffer)}
+	if b.closed {
+		close(s.events)
+	} else {
+		b.subscribers[s] = struct{}{}
+	}
+	feed.Events = s.events
+	feed.Close = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(s)
+	}
+	return feed
+}
+
+// since returns a copy of the buffered events after the one numbered after
+func (b *EventBus) since(after uint64) ([]Domain.TaskEvent, bool) {
+	// evicted is the number of the last event no longer buffered
+	evicted := b.seq - uint64(len(b.replay))
+	if after > b.seq || after < evicted {
+		return append([]Domain.TaskEvent(nil), b.replay...), true
+	}
+	return append([]Domain.TaskEvent(nil), b.replay[after-evicted:]...), false
+}
+
+// remove closes the subscriber's channel unless that already happened, b.mu must be held
+func (b *EventBus) remove(s *busSubscriber) {
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
+
+// Close ends every subscription, so open streams return before the server shuts down.
+// Published events are still passed on to the listeners.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for s := range b.subscribers {
+		b.remove(s)
+	}
+}
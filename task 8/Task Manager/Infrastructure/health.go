@@ -0,0 +1,47 @@
+package Infrastructure
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReadinessCheck reports whether a dependency, such as the database, can serve requests
+type ReadinessCheck func(ctx context.Context) error
+
+// readinessTimeout bounds GET /readyz, so a hanging database fails the check instead of the probe
+const readinessTimeout = 2 * time.Second
+
+// HealthzHandler answers GET /healthz, the liveness check: the process is up and serves HTTP
+func HealthzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	}
+}
+
+// ReadyzHandler answers GET /readyz with 200 when every check passes and 503 otherwise.
+// The reasons of failed checks are logged, the response only names them.
+func ReadyzHandler(checks map[string]ReadinessCheck) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessTimeout)
+		defer cancel()
+
+		status, results := "ok", make(map[string]string, len(checks))
+		for name, check := range checks {
+			if err := check(ctx); err != nil {
+				log.Printf("Readiness check %s failed: %v", name, err)
+				status, results[name] = "unavailable", "unavailable"
+				continue
+			}
+			results[name] = "ok"
+		}
+		code := http.StatusOK
+		if status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{"status": status, "checks": results})
+	}
+}
@@ -0,0 +1,307 @@
+package Infrastructure
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sort"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA modulus accepted for RS256
+const minRSAKeyBits = 2048
+
+// SigningKey is the private key new tokens are signed with
+type SigningKey struct {
+	KID    string
+	Method jwt.SigningMethod
+	key    interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey
+}
+
+// VerificationKey is a key tokens are accepted with. During a rotation the
+// previous keys stay here until the tokens they signed have expired.
+type VerificationKey struct {
+	KID    string
+	Method jwt.SigningMethod
+	key    interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// KeySet is the signing key plus every key accepted for verification, indexed by kid
+type KeySet struct {
+	signing      *SigningKey
+	verification map[string]*VerificationKey
+}
+
+// keys holds the active KeySet, it is swapped as a whole so readers never see a half-updated set
+var keys atomic.Pointer[KeySet]
+
+// SetKeySet makes ks the set used by GenerateToken, ValidateToken and AuthenticateJWT
+func SetKeySet(ks *KeySet) {
+	keys.Store(ks)
+}
+
+func currentKeySet() (*KeySet, error) {
+	ks := keys.Load()
+	if ks == nil {
+		return nil, errors.New("no JWT keys configured")
+	}
+	return ks, nil
+}
+
+// NewKeySet creates a KeySet. The signing key is always accepted for verification too.
+func NewKeySet(signing *SigningKey, verification ...*VerificationKey) (*KeySet, error) {
+	ks := &KeySet{signing: signing, verification: make(map[string]*VerificationKey)}
+	all := append([]*VerificationKey{signing.verificationKey()}, verification...)
+	for _, vk := range all {
+		if existing, ok := ks.verification[vk.KID]; ok && existing.Method != vk.Method {
+			return nil, fmt.Errorf("two different keys use kid %q", vk.KID)
+		}
+		ks.verification[vk.KID] = vk
+	}
+	return ks, nil
+}
+
+// newHMACKeySet is the symmetric HS256 configuration used with JWT_SECRET.
+// Its tokens carry no kid, and the secret is never published in the JWKS.
+func newHMACKeySet(secret []byte) *KeySet {
+	ks, _ := NewKeySet(&SigningKey{Method: jwt.SigningMethodHS256, key: secret})
+	return ks
+}
+
+// LoadKeySetFromFiles reads the PEM encoded signing key and the keys only used for verification,
+// e.g. the previous signing key during a rotation. Verification files may hold public or private keys.
+func LoadKeySetFromFiles(signingKeyFile string, verificationKeyFiles []string) (*KeySet, error) {
+	data, err := os.ReadFile(signingKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing key: %w", err)
+	}
+	signing, err := ParseSigningKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", signingKeyFile, err)
+	}
+	var verification []*VerificationKey
+	for _, file := range verificationKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read verification key: %w", err)
+		}
+		vk, err := ParseVerificationKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		verification = append(verification, vk)
+	}
+	return NewKeySet(signing, verification...)
+}
+
+// ParseSigningKeyPEM parses an RSA (PKCS#1 or PKCS#8) or Ed25519 (PKCS#8) private key.
+// RSA keys sign with RS256 and Ed25519 keys with EdDSA. The kid is the RFC 7638 thumbprint of the public key.
+func ParseSigningKeyPEM(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	var (
+		key interface{}
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q, expected a private key", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	vk, err := newVerificationKey(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{KID: vk.KID, Method: vk.Method, key: key}, nil
+}
+
+// ParseVerificationKeyPEM parses a public key (PKIX, or PKCS#1 for RSA) or a private key, of which only the public part is kept
+func ParseVerificationKeyPEM(data []byte) (*VerificationKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return newVerificationKey(pub)
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return newVerificationKey(pub)
+	default:
+		signing, err := ParseSigningKeyPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return signing.verificationKey(), nil
+	}
+}
+
+func newVerificationKey(pub crypto.PublicKey) (*VerificationKey, error) {
+	vk := &VerificationKey{key: pub}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key is %d bits, at least %d are required", k.N.BitLen(), minRSAKeyBits)
+		}
+		vk.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		vk.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, expected RSA or Ed25519", pub)
+	}
+	thumbprint, err := json.Marshal(vk.jwk().thumbprintMembers())
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(thumbprint)
+	vk.KID = base64.RawURLEncoding.EncodeToString(sum[:])
+	return vk, nil
+}
+
+func (k *SigningKey) verificationKey() *VerificationKey {
+	switch key := k.key.(type) {
+	case []byte:
+		return &VerificationKey{KID: k.KID, Method: k.Method, key: key}
+	case crypto.Signer:
+		return &VerificationKey{KID: k.KID, Method: k.Method, key: key.Public()}
+	}
+	return &VerificationKey{KID: k.KID, Method: k.Method}
+}
+
+// sign signs the claims with the signing key and sets the kid header
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	if ks.signing.KID != "" {
+		token.Header["kid"] = ks.signing.KID
+	}
+	return token.SignedString(ks.signing.key)
+}
+
+// keyFunc picks the verification key by the kid header. The algorithm must match the key,
+// so e.g. an HS256 token can never be checked against a published RSA key.
+func (ks *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	vk, ok := ks.verification[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != vk.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return vk.key, nil
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	KTY string `json:"kty"`
+	KID string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	CRV string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (vk *VerificationKey) jwk() JWK {
+	jwk := JWK{KID: vk.KID, Use: "sig", Alg: vk.Method.Alg()}
+	switch key := vk.key.(type) {
+	case *rsa.PublicKey:
+		jwk.KTY = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KTY = "OKP"
+		jwk.CRV = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	}
+	return jwk
+}
+
+// thumbprintMembers returns the required members in lexicographic order, see RFC 7638
+func (j JWK) thumbprintMembers() interface{} {
+	if j.KTY == "RSA" {
+		return struct {
+			E   string `json:"e"`
+			KTY string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.KTY, j.N}
+	}
+	return struct {
+		CRV string `json:"crv"`
+		KTY string `json:"kty"`
+		X   string `json:"x"`
+	}{j.CRV, j.KTY, j.X}
+}
+
+// JWKS returns the public verification keys. Symmetric secrets are never included.
+func (ks *KeySet) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, vk := range ks.verification {
+		if _, symmetric := vk.key.([]byte); symmetric {
+			continue
+		}
+		set.Keys = append(set.Keys, vk.jwk())
+	}
+	// Serve the current signing key first, then the others in a stable order
+	sort.Slice(set.Keys, func(i, j int) bool {
+		if (set.Keys[i].KID == ks.signing.KID) != (set.Keys[j].KID == ks.signing.KID) {
+			return set.Keys[i].KID == ks.signing.KID
+		}
+		return set.Keys[i].KID < set.Keys[j].KID
+	})
+	return set
+}
+
+// JWKSHandler serves GET /.well-known/jwks.json so other services can verify our tokens
+func JWKSHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ks, err := currentKeySet()
+		if err != nil {
+			_ = c.Error(err)
+			return
+		}
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, ks.JWKS())
+	}
+}
@@ -1,42 +1,65 @@
 package Infrastructure
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"taskmanager/Domain"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret []byte
+// AccessTokenTTL is how long an access token is valid. Clients use a refresh token to get a new one.
+const AccessTokenTTL = 15 * time.Minute
 
+// SetJWTSecret configures symmetric HS256 signing with the shared secret.
+// Use SetKeySet for RS256 or EdDSA keys that other services can verify through the JWKS.
 func SetJWTSecret(secret string) {
-	jwtSecret = []byte(secret)
+	SetKeySet(newHMACKeySet([]byte(secret)))
 }
 
-// GenerateToken generates a JWT token for a given user ID, username, and role
-func GenerateToken(userID, username, role string) (string, error) {
+// GenerateToken generates a short-lived JWT access token for a given user ID, username, role and team.
+// Every token gets a random jti claim so it can be revoked on logout. An empty team is left out.
+func GenerateToken(userID, username, role, team string) (Domain.AccessToken, error) {
+	ks, err := currentKeySet()
+	if err != nil {
+		return Domain.AccessToken{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	jti, err := randomToken(16)
+	if err != nil {
+		return Domain.AccessToken{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL)
 	claims := jwt.MapClaims{
 		"user_id":  userID,
 		"username": username,
 		"role":     role,
-		"exp":      time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+		"jti":      jti,
+		"iat":      now.Unix(),
+		"exp":      expiresAt.Unix(),
+	}
+	if team != "" {
+		claims["team"] = team
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(jwtSecret)
+	signedToken, err := ks.sign(claims)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return Domain.AccessToken{}, fmt.Errorf("failed to generate token: %w", err)
 	}
-	return signedToken, nil
+	// exp has second precision, keep the same value the token carries
+	return Domain.AccessToken{Token: signedToken, JTI: jti, ExpiresAt: time.Unix(expiresAt.Unix(), 0)}, nil
 }
 
 // ValidateToken validates a JWT token string and returns the claims if valid
 func ValidateToken(tokenString string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return jwtSecret, nil
-	})
+	ks, err := currentKeySet()
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.Parse(tokenString, ks.keyFunc, jwt.WithExpirationRequired())
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid or expired token: %w", err)
@@ -48,3 +71,40 @@ func ValidateToken(tokenString string) (jwt.MapClaims, error) {
 
 	return nil, fmt.Errorf("invalid token claims")
 }
+
+// JWTTokenService implements Usecases.TokenService with JWT access tokens
+// and opaque random refresh tokens
+type JWTTokenService struct{}
+
+// NewJWTTokenService creates a new JWTTokenService
+func NewJWTTokenService() *JWTTokenService {
+	return &JWTTokenService{}
+}
+
+func (JWTTokenService) GenerateAccessToken(user Domain.User) (Domain.AccessToken, error) {
+	return GenerateToken(user.ID.String(), user.Username, user.Role, user.Team)
+}
+
+// GenerateRefreshToken returns 32 random bytes, base64url encoded
+func (JWTTokenService) GenerateRefreshToken() (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// HashRefreshToken returns the SHA-256 of the token. Refresh tokens are random,
+// so a fast unsalted hash is enough to keep a database leak from exposing usable tokens.
+func (JWTTokenService) HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,219 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"taskmanager/Domain"
+	"time"
+)
+
+// defaultNotifyTimeout bounds a delivery when the context has no deadline
+const defaultNotifyTimeout = 10 * time.Second
+
+// LogNotifier writes reminders to a logger, useful in development and as a fallback
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier creates a LogNotifier writing to logger, or to the standard logger when nil
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	names := make([]string, 0, len(reminder.Recipients))
+	for _, r := range reminder.Recipients {
+		names = append(names, r.Username)
+	}
+	n.logger.Printf("Reminder: %s (task %s), for %s", reminder.Subject(), reminder.Task.ID, strings.Join(names, ", "))
+	return nil
+}
+
+// SMTPConfig is where and as whom the SMTPNotifier sends emails. Username may be empty for servers without authentication.
+type SMTPConfig struct {
+	Addr     string `key:"addr" env:"SMTP_ADDR"` // host:port
+	Username string `key:"username" env:"SMTP_USERNAME"`
+	Password string `key:"password" env:"SMTP_PASSWORD" redact:"secret"`
+	From     string `key:"from" env:"SMTP_FROM"`
+}
+
+// SMTPNotifier emails reminders to the recipients that have an email address.
+// STARTTLS is used when the server offers it.
+type SMTPNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier creates a new SMTPNotifier
+func NewSMTPNotifier(config SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{config: config}
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	var to []string
+	for _, r := range reminder.Recipients {
+		if r.Email != "" {
+			to = append(to, r.Email)
+		}
+	}
+	if len(to) == 0 {
+		return nil
+	}
+	if err := n.send(ctx, to, reminderEmail(n.config.From, to, reminder)); err != nil {
+		return fmt.Errorf("failed to send reminder email: %w", err)
+	}
+	return nil
+}
+
+// send is smtp.SendMail with a deadline taken from ctx
+func (n *SMTPNotifier) send(ctx context.Context, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(n.config.Addr)
+	if err != nil {
+		return err
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", n.config.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultNotifyTimeout)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if n.config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", n.config.Username, n.config.Password, host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(n.config.From); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// reminderEmail builds a plain text email. The subject is MIME encoded, so task titles cannot inject headers.
+func reminderEmail(from string, to []string, reminder Domain.Reminder) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", reminder.Subject()))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s.\r\n\r\n", reminder.Subject())
+	fmt.Fprintf(&b, "Status: %s\r\nDue date: %s\r\nTask ID: %s\r\n", reminder.Task.Status, reminder.Task.DueDate.Format("02-01-2006"), reminder.Task.ID)
+	return []byte(b.String())
+}
+
+// WebhookNotifier posts reminders as JSON to a URL, e.g. a chat integration
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier posting to url with client, or with a client with a timeout when nil
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: defaultNotifyTimeout}
+	}
+	return &WebhookNotifier{url: url, client: client}
+}
+
+// reminderPayload is the JSON body posted by the WebhookNotifier
+type reminderPayload struct {
+	Kind       string             `json:"kind"`
+	Subject    string             `json:"subject"`
+	Task       reminderTask       `json:"task"`
+	Recipients []reminderAudience `json:"recipients"`
+}
+
+type reminderTask struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	DueDate  string `json:"due_date"` // dd-mm-yyyy, as in the API
+	Status   string `json:"status"`
+	Priority string `json:"priority,omitempty"`
+}
+
+type reminderAudience struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	payload := reminderPayload{
+		Kind:    string(reminder.Kind),
+		Subject: reminder.Subject(),
+		Task: reminderTask{
+			ID:       reminder.Task.ID.String(),
+			Title:    reminder.Task.Title,
+			DueDate:  reminder.Task.DueDate.Format("02-01-2006"),
+			Status:   string(reminder.Task.Status),
+			Priority: string(reminder.Task.Priority),
+		},
+		Recipients: make([]reminderAudience, 0, len(reminder.Recipients)),
+	}
+	for _, r := range reminder.Recipients {
+		payload.Recipients = append(payload.Recipients, reminderAudience{UserID: r.UserID.String(), Username: r.Username, Email: r.Email})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode reminder: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build reminder request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post reminder: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("reminder webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -15,3 +15,19 @@ func CheckPasswordHash(hashedPassword, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	return err == nil
 }
+
+// BcryptPasswordService implements Usecases.PasswordService with bcrypt
+type BcryptPasswordService struct{}
+
+// NewBcryptPasswordService creates a new BcryptPasswordService
+func NewBcryptPasswordService() *BcryptPasswordService {
+	return &BcryptPasswordService{}
+}
+
+func (BcryptPasswordService) HashPassword(password string) (string, error) {
+	return HashPassword(password)
+}
+
+func (BcryptPasswordService) CheckPasswordHash(hashedPassword, password string) bool {
+	return CheckPasswordHash(hashedPassword, password)
+}
@@ -0,0 +1,32 @@
+package Infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"taskmanager/Domain"
+)
+
+// rolesFile is the format of the roles configuration, e.g.
+//
+//	{"roles": {"user": ["tasks:read", "tasks:write"], "admin": ["tasks:read:any", ...]}}
+type rolesFile struct {
+	Roles map[string][]Domain.Permission `json:"roles"`
+}
+
+// LoadPolicyFile reads the role to permissions mapping from a JSON file
+func LoadPolicyFile(path string) (*Domain.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roles config: %w", err)
+	}
+	var file rolesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse roles config %s: %w", path, err)
+	}
+	policy, err := Domain.NewPolicy(file.Roles)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policy, nil
+}
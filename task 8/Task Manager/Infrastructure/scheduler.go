@@ -0,0 +1,60 @@
+package Infrastructure
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Scheduler runs a job in a background goroutine, right away and then every interval, until it is stopped
+type Scheduler struct {
+	name     string
+	interval time.Duration
+	job      func(ctx context.Context) error
+	stop     chan struct{}
+	done     chan struct{}
+	cancel   context.CancelFunc
+}
+
+// NewScheduler creates a Scheduler. name only appears in the logs.
+func NewScheduler(name string, interval time.Duration, job func(ctx context.Context) error) *Scheduler {
+	return &Scheduler{name: name, interval: interval, job: job, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+// Start launches the background goroutine, it must be called once
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.run(ctx)
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.job(ctx); err != nil {
+			log.Printf("%s failed: %v", s.name, err)
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop lets a running job finish and stops the scheduler. If ctx is done first, the job's
+// context is cancelled and ctx.Err() is returned once the job has returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	close(s.stop)
+	defer s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-s.done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,361 @@
+package Infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"taskmanager/Domain"
+	"time"
+)
+
+// Headers sent with every webhook delivery
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery" // the event ID, the same for every attempt
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookStore is the part of Repositories.WebhookRepository the dispatcher needs
+type WebhookStore interface {
+	ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error)
+	AddDeadLetter(ctx context.Context, letter Domain.WebhookDeadLetter) (*Domain.WebhookDeadLetter, error)
+}
+
+// WebhookConfig tunes the deliveries of a WebhookDispatcher, zero fields take the defaults
+type WebhookConfig struct {
+	MaxAttempts int           `key:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`       // attempts before a delivery becomes a dead letter, 5 by default
+	BaseDelay   time.Duration `key:"retry_delay" env:"WEBHOOK_RETRY_DELAY"`         // wait before the first retry, doubled for each further retry, 1s by default
+	MaxDelay    time.Duration `key:"max_retry_delay" env:"WEBHOOK_MAX_RETRY_DELAY"` // longest wait between two attempts, 5m by default
+	Timeout     time.Duration `key:"timeout" env:"WEBHOOK_TIMEOUT"`                 // timeout of one attempt, 10s by default
+	Concurrency int           // attempts in flight at once, 8 by default
+	QueueSize   int           // events waiting to be dispatched, 1000 by default
+	Client      *http.Client
+}
+
+func (c *WebhookConfig) setDefaults() {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseDelay <= 0 {
+		c.BaseDelay = time.Second
+	}
+	if c.MaxDelay <= 0 {
+		c.MaxDelay = 5 * time.Minute
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 10 * time.Second
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 8
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 1000
+	}
+	if c.Client == nil {
+		c.Client = &http.Client{}
+	}
+}
+
+// webhookDelivery is one event on its way to one subscription
+type webhookDelivery struct {
+	subscriptionID Domain.ID
+	url            string
+	secret         string
+	eventID        Domain.ID
+	eventType      Domain.TaskEventType
+	payload        []byte
+}
+
+// WebhookDispatcher posts task events to the subscribed URLs in the background.
+// It implements Usecases.TaskEventPublisher and Usecases.WebhookRedeliverer.
+// Failed attempts are retried with exponential backoff; a delivery that still fails,
+// or still waits for a retry at shutdown, is stored as a dead letter.
+type WebhookDispatcher struct {
+	store    WebhookStore
+	config   WebhookConfig
+	events   chan Domain.TaskEvent
+	slots    chan struct{} // bounds the attempts in flight
+	stopping chan struct{} // closed by Stop, retries are no longer waited for
+	done     chan struct{} // closed when every queued event was dispatched
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.RWMutex // guards stopped against PublishTaskEvent and Redeliver
+	stopped  bool
+	wg       sync.WaitGroup // deliveries in progress
+}
+
+// NewWebhookDispatcher creates a WebhookDispatcher, Start must be called before events are delivered
+func NewWebhookDispatcher(store WebhookStore, config WebhookConfig) *WebhookDispatcher {
+	config.setDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookDispatcher{
+		store:    store,
+		config:   config,
+		events:   make(chan Domain.TaskEvent, config.QueueSize),
+		slots:    make(chan struct{}, config.Concurrency),
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start launches the goroutine handing the published events out to the subscriptions
+func (d *WebhookDispatcher) Start() {
+	go d.run()
+}
+
+// PublishTaskEvent queues an event for delivery without blocking. When the queue is full the event is dropped and logged.
+func (d *WebhookDispatcher) PublishTaskEvent(event Domain.TaskEvent) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return
+	}
+	select {
+	case d.events <- event:
+	default:
+		log.Printf("Webhook queue is full, dropped %s event %s", event.Type, event.ID)
+	}
+}
+
+// Redeliver sends the payload of a dead letter to the subscription again.
+// It returns Domain.ErrWebhooksStopped once Stop was called.
+func (d *WebhookDispatcher) Redeliver(sub Domain.WebhookSubscription, letter Domain.WebhookDeadLetter) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.stopped {
+		return Domain.ErrWebhooksStopped
+	}
+	d.wg.Add(1)
+	go d.deliver(webhookDelivery{
+		subscriptionID: sub.ID,
+		url:            sub.URL,
+		secret:         sub.Secret,
+		eventID:        letter.EventID,
+		eventType:      letter.EventType,
+		payload:        letter.Payload,
+	})
+	return nil
+}
+
+// Stop dispatches the events still queued, makes their first attempt, and stores every delivery
+// waiting for a retry as a dead letter. When ctx is done first, the attempts in flight are aborted.
+func (d *WebhookDispatcher) Stop(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.events)
+		close(d.stopping)
+	}
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		<-d.done
+		d.wg.Wait()
+		close(finished)
+	}()
+	defer d.cancel()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		<-finished
+		return ctx.Err()
+	}
+}
+
+func (d *WebhookDispatcher) run() {
+	defer close(d.done)
+	for event := range d.events {
+		payload, err := json.Marshal(newWebhookPayload(event))
+		if err != nil {
+			log.Printf("Failed to encode %s event %s: %v", event.Type, event.ID, err)
+			continue
+		}
+		subs, err := d.store.ListSubscriptions(d.ctx)
+		if err != nil {
+			log.Printf("Failed to load webhook subscriptions, %s event %s not delivered: %v", event.Type, event.ID, err)
+			continue
+		}
+		for _, sub := range subs {
+			if !sub.Wants(event.Type) {
+				continue
+			}
+			d.wg.Add(1)
+			go d.deliver(webhookDelivery{
+				subscriptionID: sub.ID,
+				url:            sub.URL,
+				secret:         sub.Secret,
+				eventID:        event.ID,
+				eventType:      event.Type,
+				payload:        payload,
+			})
+		}
+	}
+}
+
+// deliver makes up to MaxAttempts attempts and stores a dead letter if none succeeded
+func (d *WebhookDispatcher) deliver(delivery webhookDelivery) {
+	defer d.wg.Done()
+	var err error
+	attempts := 0
+	for attempts < d.config.MaxAttempts {
+		if attempts > 0 && !d.wait(d.backoff(attempts)) {
+			break
+		}
+		attempts++
+		if err = d.attempt(delivery); err == nil {
+			return
+		}
+	}
+	d.deadLetter(delivery, attempts, err)
+}
+
+// backoff is the wait after the given number of failed attempts: BaseDelay, doubled each time, at most MaxDelay
+func (d *WebhookDispatcher) backoff(failed int) time.Duration {
+	delay := d.config.BaseDelay
+	for i := 1; i < failed && delay < d.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, d.config.MaxDelay)
+}
+
+// wait sleeps for delay and returns false if the dispatcher is stopped meanwhile
+func (d *WebhookDispatcher) wait(delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-d.stopping:
+		return false
+	}
+}
+
+func (d *WebhookDispatcher) attempt(delivery webhookDelivery) error {
+	select {
+	case d.slots <- struct{}{}:
+		defer func() { <-d.slots }()
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	}
+	ctx, cancel := context.WithTimeout(d.ctx, d.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.url, bytes.NewReader(delivery.payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, string(delivery.eventType))
+	req.Header.Set(WebhookDeliveryHeader, delivery.eventID.String())
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(delivery.secret, delivery.payload))
+	resp, err := d.config.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (d *WebhookDispatcher) deadLetter(delivery webhookDelivery, attempts int, cause error) {
+	// The dispatcher context may be cancelled by now, the letter must still be stored
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	letter := Domain.WebhookDeadLetter{
+		SubscriptionID: delivery.subscriptionID,
+		URL:            delivery.url,
+		EventID:        delivery.eventID,
+		EventType:      delivery.eventType,
+		Payload:        delivery.payload,
+		Attempts:       attempts,
+		FailedAt:       time.Now(),
+	}
+	if cause != nil {
+		letter.LastError = cause.Error()
+	}
+	if _, err := d.store.AddDeadLetter(ctx, letter); err != nil {
+		log.Printf("Failed to store dead letter for %s event %s to %s: %v", delivery.eventType, delivery.eventID, delivery.url, err)
+		return
+	}
+	log.Printf("Webhook delivery of %s event %s to %s failed after %d attempt(s): %v", delivery.eventType, delivery.eventID, delivery.url, attempts, cause)
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature of a payload: "sha256=" and the hex HMAC-SHA256 of the body.
+// Receivers recompute it with the shared secret and compare in constant time.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookPayload is the JSON body of a delivery
+type webhookPayload struct {
+	ID             string       `json:"id"`
+	Type           string       `json:"type"`
+	OccurredAt     string       `json:"occurred_at"` // RFC 3339
+	Actor          webhookActor `json:"actor"`
+	Task           webhookTask  `json:"task"`
+	PreviousStatus string       `json:"previous_status,omitempty"` // task.status_changed only
+}
+
+type webhookActor struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type webhookTask struct {
+	ID          string   `json:"id"`
+	OwnerID     string   `json:"owner_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	DueDate     string   `json:"due_date"` // dd-mm-yyyy, as in the API
+	Status      string   `json:"status"`
+	Priority    string   `json:"priority,omitempty"`
+	Labels      []string `json:"labels,omitempty"`
+	AssigneeIDs []string `json:"assignee_ids,omitempty"`
+	ParentID    string   `json:"parent_id,omitempty"`
+	Version     int64    `json:"version"`
+}
+
+func newWebhookPayload(event Domain.TaskEvent) webhookPayload {
+	task := event.Task
+	assignees := make([]string, 0, len(task.AssigneeIDs))
+	for _, id := range task.AssigneeIDs {
+		assignees = append(assignees, id.String())
+	}
+	return webhookPayload{
+		ID:         event.ID.String(),
+		Type:       string(event.Type),
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339),
+		Actor:      webhookActor{ID: event.ActorID.String(), Username: event.ActorUsername},
+		Task: webhookTask{
+			ID:          task.ID.String(),
+			OwnerID:     task.OwnerID.String(),
+			Title:       task.Title,
+			Description: task.Description,
+			DueDate:     task.DueDate.Format("02-01-2006"),
+			Status:      string(task.Status),
+			Priority:    string(task.Priority),
+			Labels:      task.Labels,
+			AssigneeIDs: assignees,
+			ParentID:    task.ParentID.String(),
+			Version:     task.Version,
+		},
+		PreviousStatus: string(event.PreviousStatus),
+	}
+}
@@ -0,0 +1,45 @@
+package Repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type AuditMongoCollectionAdapter struct {
+	Coll *mongo.Collection
+}
+
+func (a *AuditMongoCollectionAdapter) InsertOne(ctx context.Context, document interface{}, opts ...interface{}) (*InsertOneResult, error) {
+	var mongoOpts []*options.InsertOneOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.InsertOneOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.InsertOne(ctx, document, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &InsertOneResult{InsertedID: res.InsertedID}, nil
+}
+
+func (a *AuditMongoCollectionAdapter) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error) {
+	var mongoOpts []*options.FindOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	cursor, err := a.Coll.Find(ctx, filter, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (a *AuditMongoCollectionAdapter) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := a.Coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -0,0 +1,130 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"taskmanager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository stores the change history of tasks. It is append only, events are never changed or removed.
+type AuditRepository interface {
+	AddEvent(ctx context.Context, event Domain.AuditEvent) (*Domain.AuditEvent, error)
+	// ListTaskEvents returns the events of a task, oldest first
+	ListTaskEvents(ctx context.Context, taskID Domain.ID) ([]Domain.AuditEvent, error)
+}
+
+// AuditEventEntity is the persistence model for Domain.AuditEvent
+type AuditEventEntity struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	TaskID        primitive.ObjectID  `bson:"task_id" json:"task_id"`
+	Action        string              `bson:"action" json:"action"`
+	ActorID       primitive.ObjectID  `bson:"actor_id" json:"actor_id"`
+	ActorUsername string              `bson:"actor_username" json:"actor_username"`
+	At            primitive.DateTime  `bson:"at" json:"at"`
+	Changes       []FieldChangeEntity `bson:"changes" json:"changes"`
+}
+
+// FieldChangeEntity is the persistence model for Domain.FieldChange
+type FieldChangeEntity struct {
+	Field  string `bson:"field" json:"field"`
+	Before string `bson:"before" json:"before"`
+	After  string `bson:"after" json:"after"`
+}
+
+// ToDomain converts AuditEventEntity to Domain.AuditEvent
+func (e *AuditEventEntity) ToDomain() Domain.AuditEvent {
+	event := Domain.AuditEvent{
+		ID:            DomainIDFromObjectID(e.ID),
+		TaskID:        DomainIDFromObjectID(e.TaskID),
+		Action:        Domain.AuditAction(e.Action),
+		ActorID:       DomainIDFromObjectID(e.ActorID),
+		ActorUsername: e.ActorUsername,
+		At:            e.At.Time(),
+	}
+	for _, c := range e.Changes {
+		event.Changes = append(event.Changes, Domain.FieldChange{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return event
+}
+
+// AuditEventFromDomain converts Domain.AuditEvent to AuditEventEntity
+func AuditEventFromDomain(event Domain.AuditEvent) AuditEventEntity {
+	e := AuditEventEntity{
+		ID:            objectIDOrNil(event.ID),
+		TaskID:        objectIDOrNil(event.TaskID),
+		Action:        string(event.Action),
+		ActorID:       objectIDOrNil(event.ActorID),
+		ActorUsername: event.ActorUsername,
+		At:            primitive.NewDateTimeFromTime(event.At),
+		Changes:       []FieldChangeEntity{},
+	}
+	for _, c := range event.Changes {
+		e.Changes = append(e.Changes, FieldChangeEntity{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return e
+}
+
+// AuditCollection defines the minimal collection interface for the audit repository
+type AuditCollection interface {
+	InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*InsertOneResult, error)
+	Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
+}
+
+// MongoAuditRepository implements AuditRepository using MongoDB
+type MongoAuditRepository struct {
+	collection AuditCollection
+}
+
+// NewMongoAuditRepository creates a new MongoAuditRepository
+func NewMongoAuditRepository(collection AuditCollection) *MongoAuditRepository {
+	return &MongoAuditRepository{collection: collection}
+}
+
+// EnsureIndexes creates the index used to list the history of a task
+func (r *MongoAuditRepository) EnsureIndexes(ctx context.Context) error {
+	err := r.collection.CreateIndexes(ctx, []mongo.IndexModel{{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "at", Value: 1}},
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoAuditRepository) AddEvent(ctx context.Context, event Domain.AuditEvent) (*Domain.AuditEvent, error) {
+	entity := AuditEventFromDomain(event)
+	entity.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, entity); err != nil {
+		return nil, fmt.Errorf("failed to insert audit event: %w", err)
+	}
+	event = entity.ToDomain()
+	return &event, nil
+}
+
+func (r *MongoAuditRepository) ListTaskEvents(ctx context.Context, taskID Domain.ID) ([]Domain.AuditEvent, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"task_id": objectIDOrNil(taskID)}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find audit events: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var events []Domain.AuditEvent
+	for cursor.Next(ctx) {
+		var entity AuditEventEntity
+		if err := cursor.Decode(&entity); err != nil {
+			return nil, fmt.Errorf("error decoding audit event: %w", err)
+		}
+		events = append(events, entity.ToDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,79 @@
+package Repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CommentMongoCollectionAdapter struct {
+	Coll *mongo.Collection
+}
+
+func (a *CommentMongoCollectionAdapter) InsertOne(ctx context.Context, document interface{}, opts ...interface{}) (*InsertOneResult, error) {
+	var mongoOpts []*options.InsertOneOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.InsertOneOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.InsertOne(ctx, document, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &InsertOneResult{InsertedID: res.InsertedID}, nil
+}
+
+func (a *CommentMongoCollectionAdapter) FindOne(ctx context.Context, filter interface{}, opts ...interface{}) SingleResult {
+	var mongoOpts []*options.FindOneOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOneOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	return a.Coll.FindOne(ctx, filter, mongoOpts...)
+}
+
+func (a *CommentMongoCollectionAdapter) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error) {
+	var mongoOpts []*options.FindOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	cursor, err := a.Coll.Find(ctx, filter, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (a *CommentMongoCollectionAdapter) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) SingleResult {
+	var mongoOpts []*options.FindOneAndUpdateOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOneAndUpdateOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	return a.Coll.FindOneAndUpdate(ctx, filter, update, mongoOpts...)
+}
+
+func (a *CommentMongoCollectionAdapter) DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error) {
+	var mongoOpts []*options.DeleteOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.DeleteOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.DeleteOne(ctx, filter, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoDeleteResultAdapter{res}, nil
+}
+
+func (a *CommentMongoCollectionAdapter) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := a.Coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -0,0 +1,173 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CommentRepository stores the comments posted on tasks
+type CommentRepository interface {
+	AddComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error)
+	GetCommentByID(ctx context.Context, id Domain.ID) (*Domain.Comment, error)
+	// ListTaskComments returns the comments of a task, oldest first
+	ListTaskComments(ctx context.Context, taskID Domain.ID) ([]Domain.Comment, error)
+	// UpdateComment replaces the body and UpdatedAt of a comment
+	UpdateComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error)
+	DeleteComment(ctx context.Context, id Domain.ID) error
+}
+
+// CommentEntity is the persistence model for Domain.Comment
+type CommentEntity struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TaskID         primitive.ObjectID `bson:"task_id" json:"task_id"`
+	AuthorID       primitive.ObjectID `bson:"author_id" json:"author_id"`
+	AuthorUsername string             `bson:"author_username" json:"author_username"`
+	Body           string             `bson:"body" json:"body"`
+	CreatedAt      primitive.DateTime `bson:"created_at" json:"created_at"`
+	UpdatedAt      primitive.DateTime `bson:"updated_at" json:"updated_at"`
+}
+
+// ToDomain converts CommentEntity to Domain.Comment
+func (e *CommentEntity) ToDomain() Domain.Comment {
+	return Domain.Comment{
+		ID:             DomainIDFromObjectID(e.ID),
+		TaskID:         DomainIDFromObjectID(e.TaskID),
+		AuthorID:       DomainIDFromObjectID(e.AuthorID),
+		AuthorUsername: e.AuthorUsername,
+		Body:           e.Body,
+		CreatedAt:      e.CreatedAt.Time(),
+		UpdatedAt:      e.UpdatedAt.Time(),
+	}
+}
+
+// CommentFromDomain converts Domain.Comment to CommentEntity
+func CommentFromDomain(comment Domain.Comment) CommentEntity {
+	return CommentEntity{
+		ID:             objectIDOrNil(comment.ID),
+		TaskID:         objectIDOrNil(comment.TaskID),
+		AuthorID:       objectIDOrNil(comment.AuthorID),
+		AuthorUsername: comment.AuthorUsername,
+		Body:           comment.Body,
+		CreatedAt:      primitive.NewDateTimeFromTime(comment.CreatedAt),
+		UpdatedAt:      primitive.NewDateTimeFromTime(comment.UpdatedAt),
+	}
+}
+
+// CommentCollection defines the minimal collection interface for the comment repository
+type CommentCollection interface {
+	InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*InsertOneResult, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...interface{}) SingleResult
+	Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error)
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) SingleResult
+	DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
+}
+
+// MongoCommentRepository implements CommentRepository using MongoDB
+type MongoCommentRepository struct {
+	collection CommentCollection
+}
+
+// NewMongoCommentRepository creates a new MongoCommentRepository
+func NewMongoCommentRepository(collection CommentCollection) *MongoCommentRepository {
+	return &MongoCommentRepository{collection: collection}
+}
+
+// EnsureIndexes creates the index used to list the comments of a task
+func (r *MongoCommentRepository) EnsureIndexes(ctx context.Context) error {
+	err := r.collection.CreateIndexes(ctx, []mongo.IndexModel{{
+		Keys: bson.D{{Key: "task_id", Value: 1}, {Key: "_id", Value: 1}},
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to create comment indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoCommentRepository) AddComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error) {
+	entity := CommentFromDomain(comment)
+	entity.ID = primitive.NilObjectID
+	res, err := r.collection.InsertOne(ctx, entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert comment: %w", err)
+	}
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+	entity.ID = id
+	created := entity.ToDomain()
+	return &created, nil
+}
+
+func (r *MongoCommentRepository) GetCommentByID(ctx context.Context, id Domain.ID) (*Domain.Comment, error) {
+	var entity CommentEntity
+	err := r.collection.FindOne(ctx, bson.M{"_id": objectIDOrNil(id)}).Decode(&entity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find comment: %w", err)
+	}
+	comment := entity.ToDomain()
+	return &comment, nil
+}
+
+func (r *MongoCommentRepository) ListTaskComments(ctx context.Context, taskID Domain.ID) ([]Domain.Comment, error) {
+	// ObjectIDs grow with the creation time, so _id order is posting order
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"task_id": objectIDOrNil(taskID)}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find comments: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var comments []Domain.Comment
+	for cursor.Next(ctx) {
+		var entity CommentEntity
+		if err := cursor.Decode(&entity); err != nil {
+			return nil, fmt.Errorf("error decoding comment: %w", err)
+		}
+		comments = append(comments, entity.ToDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+	return comments, nil
+}
+
+func (r *MongoCommentRepository) UpdateComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error) {
+	update := bson.M{"$set": bson.M{
+		"body":       comment.Body,
+		"updated_at": primitive.NewDateTimeFromTime(comment.UpdatedAt),
+	}}
+	var entity CommentEntity
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectIDOrNil(comment.ID)}, update, opts).Decode(&entity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	updated := entity.ToDomain()
+	return &updated, nil
+}
+
+func (r *MongoCommentRepository) DeleteComment(ctx context.Context, id Domain.ID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectIDOrNil(id)})
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	if res.DeletedCount() == 0 {
+		return Domain.ErrCommentNotFound
+	}
+	return nil
+}
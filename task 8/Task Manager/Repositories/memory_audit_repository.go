@@ -0,0 +1,65 @@
+package Repositories
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"taskmanager/Domain"
+)
+
+// MemoryAuditRepository implements AuditRepository with an in-memory list per task.
+// The history is part of the memory snapshot, like the tasks it belongs to.
+type MemoryAuditRepository struct {
+	mu     sync.RWMutex
+	events map[Domain.ID][]Domain.AuditEvent // by task ID, oldest first
+}
+
+// NewMemoryAuditRepository creates an empty MemoryAuditRepository
+func NewMemoryAuditRepository() *MemoryAuditRepository {
+	return &MemoryAuditRepository{events: make(map[Domain.ID][]Domain.AuditEvent)}
+}
+
+func (r *MemoryAuditRepository) AddEvent(ctx context.Context, event Domain.AuditEvent) (*Domain.AuditEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.ID = Domain.NewID()
+	event.At = roundToMillis(event.At)
+	event.Changes = append([]Domain.FieldChange(nil), event.Changes...)
+	r.events[event.TaskID] = append(r.events[event.TaskID], event)
+	return &event, nil
+}
+
+func (r *MemoryAuditRepository) ListTaskEvents(ctx context.Context, taskID Domain.ID) ([]Domain.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Domain.AuditEvent(nil), r.events[taskID]...), nil
+}
+
+// snapshot returns the events of every task as entities, grouped by task and oldest first
+func (r *MemoryAuditRepository) snapshot() []AuditEventEntity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	taskIDs := make([]Domain.ID, 0, len(r.events))
+	for id := range r.events {
+		taskIDs = append(taskIDs, id)
+	}
+	slices.Sort(taskIDs)
+	entities := []AuditEventEntity{}
+	for _, id := range taskIDs {
+		for _, event := range r.events[id] {
+			entities = append(entities, AuditEventFromDomain(event))
+		}
+	}
+	return entities
+}
+
+// restore replaces the stored events with the given entities, which keep their order per task
+func (r *MemoryAuditRepository) restore(entities []AuditEventEntity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = make(map[Domain.ID][]Domain.AuditEvent)
+	for _, e := range entities {
+		event := e.ToDomain()
+		r.events[event.TaskID] = append(r.events[event.TaskID], event)
+	}
+}
@@ -0,0 +1,106 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"taskmanager/Domain"
+)
+
+// MemoryCommentRepository implements CommentRepository with an in-memory map.
+// Like the audit history, comments are part of the memory snapshot.
+type MemoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments map[Domain.ID]Domain.Comment
+	order    []Domain.ID // posting order
+}
+
+// NewMemoryCommentRepository creates an empty MemoryCommentRepository
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{comments: make(map[Domain.ID]Domain.Comment)}
+}
+
+func (r *MemoryCommentRepository) AddComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comment.ID = Domain.NewID()
+	comment.CreatedAt = roundToMillis(comment.CreatedAt)
+	comment.UpdatedAt = roundToMillis(comment.UpdatedAt)
+	r.comments[comment.ID] = comment
+	r.order = append(r.order, comment.ID)
+	return &comment, nil
+}
+
+func (r *MemoryCommentRepository) GetCommentByID(ctx context.Context, id Domain.ID) (*Domain.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, Domain.ErrCommentNotFound
+	}
+	return &comment, nil
+}
+
+func (r *MemoryCommentRepository) ListTaskComments(ctx context.Context, taskID Domain.ID) ([]Domain.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var comments []Domain.Comment
+	for _, id := range r.order {
+		if comment := r.comments[id]; comment.TaskID == taskID {
+			comments = append(comments, comment)
+		}
+	}
+	return comments, nil
+}
+
+func (r *MemoryCommentRepository) UpdateComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.comments[comment.ID]
+	if !ok {
+		return nil, Domain.ErrCommentNotFound
+	}
+	stored.Body = comment.Body
+	stored.UpdatedAt = roundToMillis(comment.UpdatedAt)
+	r.comments[stored.ID] = stored
+	return &stored, nil
+}
+
+func (r *MemoryCommentRepository) DeleteComment(ctx context.Context, id Domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.comments[id]; !ok {
+		return Domain.ErrCommentNotFound
+	}
+	delete(r.comments, id)
+	for i, existing := range r.order {
+		if existing == id {
+			r.order = append(r.order[:i], r.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// snapshot returns the comments as entities in posting order
+func (r *MemoryCommentRepository) snapshot() []CommentEntity {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entities := make([]CommentEntity, 0, len(r.order))
+	for _, id := range r.order {
+		entities = append(entities, CommentFromDomain(r.comments[id]))
+	}
+	return entities
+}
+
+// restore replaces the stored comments with the given entities, which are in posting order
+func (r *MemoryCommentRepository) restore(entities []CommentEntity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.comments = make(map[Domain.ID]Domain.Comment, len(entities))
+	r.order = make([]Domain.ID, 0, len(entities))
+	for _, e := range entities {
+		comment := e.ToDomain()
+		r.comments[comment.ID] = comment
+		r.order = append(r.order, comment.ID)
+	}
+}
@@ -0,0 +1,82 @@
+package Repositories
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"taskmanager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryReminderRepository implements ReminderRepository with an in-memory map.
+// Sent reminders are part of the memory snapshot, so they are not sent again after a restart.
+type MemoryReminderRepository struct {
+	mu   sync.Mutex
+	sent map[Domain.ReminderKey]time.Time
+}
+
+// NewMemoryReminderRepository creates an empty MemoryReminderRepository
+func NewMemoryReminderRepository() *MemoryReminderRepository {
+	return &MemoryReminderRepository{sent: make(map[Domain.ReminderKey]time.Time)}
+}
+
+func (r *MemoryReminderRepository) ClaimReminder(ctx context.Context, key Domain.ReminderKey, at time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key = memoryReminderKey(key)
+	if _, ok := r.sent[key]; ok {
+		return false, nil
+	}
+	r.sent[key] = at
+	return true, nil
+}
+
+func (r *MemoryReminderRepository) ReleaseReminder(ctx context.Context, key Domain.ReminderKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sent, memoryReminderKey(key))
+	return nil
+}
+
+// memoryReminderKey makes keys comparable as map keys whatever the location and precision of the due date
+func memoryReminderKey(key Domain.ReminderKey) Domain.ReminderKey {
+	key.DueDate = roundToMillis(key.DueDate).UTC()
+	return key
+}
+
+// snapshot returns the sent reminders as entities, ordered by task, kind and due date
+func (r *MemoryReminderRepository) snapshot() []ReminderEntity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entities := make([]ReminderEntity, 0, len(r.sent))
+	for key, at := range r.sent {
+		entities = append(entities, ReminderEntity{
+			TaskID:  objectIDOrNil(key.TaskID),
+			Kind:    string(key.Kind),
+			DueDate: primitive.NewDateTimeFromTime(key.DueDate),
+			SentAt:  primitive.NewDateTimeFromTime(at),
+		})
+	}
+	slices.SortFunc(entities, func(a, b ReminderEntity) int {
+		return cmp.Or(
+			cmp.Compare(a.TaskID.Hex(), b.TaskID.Hex()),
+			cmp.Compare(a.Kind, b.Kind),
+			cmp.Compare(a.DueDate, b.DueDate),
+		)
+	})
+	return entities
+}
+
+// restore replaces the sent reminders with the given entities
+func (r *MemoryReminderRepository) restore(entities []ReminderEntity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = make(map[Domain.ReminderKey]time.Time, len(entities))
+	for _, e := range entities {
+		key := Domain.ReminderKey{TaskID: DomainIDFromObjectID(e.TaskID), Kind: Domain.ReminderKind(e.Kind), DueDate: e.DueDate.Time()}
+		r.sent[memoryReminderKey(key)] = e.SentAt.Time()
+	}
+}
@@ -0,0 +1,86 @@
+package Repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MemorySnapshot is the JSON document the in-memory repositories are persisted to
+type MemorySnapshot struct {
+	Tasks []TaskEntity `json:"tasks"`
+	Users []UserEntity `json:"users"`
+	// The lists below are missing from snapshots written before they were kept
+	Audit       []AuditEventEntity `json:"audit"`
+	Reminders   []ReminderEntity   `json:"reminders"`
+	Comments    []CommentEntity    `json:"comments"`
+	Webhooks    []WebhookEntity    `json:"webhooks"`
+	DeadLetters []DeadLetterEntity `json:"dead_letters"`
+}
+
+// MemoryStores are the in-memory repositories persisted to a MemorySnapshot
+type MemoryStores struct {
+	Tasks     *MemoryTaskRepository
+	Users     *MemoryUserRepository
+	Audit     *MemoryAuditRepository
+	Reminders *MemoryReminderRepository
+	Comments  *MemoryCommentRepository
+	Webhooks  *MemoryWebhookRepository
+}
+
+// SaveMemorySnapshot writes the content of the in-memory repositories to path.
+// The file is written to a temporary sibling first and renamed, so a crash never leaves a partial snapshot.
+func SaveMemorySnapshot(path string, stores MemoryStores) error {
+	webhooks, deadLetters := stores.Webhooks.snapshot()
+	data, err := json.MarshalIndent(MemorySnapshot{
+		Tasks:       stores.Tasks.snapshot(),
+		Users:       stores.Users.snapshot(),
+		Audit:       stores.Audit.snapshot(),
+		Reminders:   stores.Reminders.snapshot(),
+		Comments:    stores.Comments.snapshot(),
+		Webhooks:    webhooks,
+		DeadLetters: deadLetters,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// LoadMemorySnapshot fills the in-memory repositories from a snapshot at path.
+// A missing file is not an error, the repositories are simply left empty.
+func LoadMemorySnapshot(path string, stores MemoryStores) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	var snapshot MemorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	stores.Tasks.restore(snapshot.Tasks)
+	stores.Users.restore(snapshot.Users)
+	stores.Audit.restore(snapshot.Audit)
+	stores.Reminders.restore(snapshot.Reminders)
+	stores.Comments.restore(snapshot.Comments)
+	stores.Webhooks.restore(snapshot.Webhooks, snapshot.DeadLetters)
+	return nil
+}
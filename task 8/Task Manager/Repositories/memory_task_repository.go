@@ -0,0 +1,293 @@
+package Repositories
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"taskmanager/Domain"
+	"time"
+)
+
+// MemoryTaskRepository implements TaskRepository with an in-memory map.
+// It is safe for concurrent use and is meant for local runs and CI without MongoDB.
+type MemoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[Domain.ID]Domain.Task
+}
+
+// NewMemoryTaskRepository creates an empty MemoryTaskRepository
+func NewMemoryTaskRepository() *MemoryTaskRepository {
+	return &MemoryTaskRepository{tasks: make(map[Domain.ID]Domain.Task)}
+}
+
+func (r *MemoryTaskRepository) GetAllTasks(ctx context.Context) ([]Domain.Task, error) {
+	return r.filterTasks(func(t Domain.Task) bool { return !t.IsDeleted() }), nil
+}
+
+func (r *MemoryTaskRepository) GetTasksByOwner(ctx context.Context, ownerID Domain.ID) ([]Domain.Task, error) {
+	return r.filterTasks(func(t Domain.Task) bool { return !t.IsDeleted() && t.OwnerID == ownerID }), nil
+}
+
+func (r *MemoryTaskRepository) GetSubtasks(ctx context.Context, parentID Domain.ID) ([]Domain.Task, error) {
+	return r.filterTasks(func(t Domain.Task) bool { return !t.IsDeleted() && !parentID.IsZero() && t.ParentID == parentID }), nil
+}
+
+// ListTasks applies the same filtering, ordering and keyset pagination as MongoTaskRepository
+func (r *MemoryTaskRepository) ListTasks(ctx context.Context, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	var after *taskCursor
+	if query.Cursor != "" {
+		c, err := decodeTaskCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		after = c
+	}
+
+	tasks := r.filterTasks(matchesTaskQuery(query))
+	sort.Slice(tasks, func(i, j int) bool {
+		return compareTasks(query.Sort, tasks[i], tasks[j]) < 0
+	})
+
+	if after != nil {
+		start := sort.Search(len(tasks), func(i int) bool {
+			return compareTaskToCursor(tasks[i], after) > 0
+		})
+		tasks = tasks[start:]
+	}
+
+	page := &Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > query.Limit {
+		page.Tasks = tasks[:query.Limit]
+		page.NextCursor = encodeTaskCursor(query.Sort, page.Tasks[query.Limit-1])
+	}
+	return page, nil
+}
+
+func (r *MemoryTaskRepository) CountLabels(ctx context.Context, query Domain.TaskQuery) ([]Domain.LabelCount, error) {
+	return countTaskLabels(r.filterTasks(matchesTaskQuery(query))), nil
+}
+
+// matchesTaskQuery reports whether a task passes the filters of the query, ignoring the cursor
+func matchesTaskQuery(query Domain.TaskQuery) func(Domain.Task) bool {
+	title := strings.ToLower(query.Title)
+	return func(t Domain.Task) bool {
+		switch {
+		case t.IsDeleted() != query.Deleted:
+			return false
+		case !query.OwnerID.IsZero() && query.Team != "" && t.OwnerID != query.OwnerID && t.Team != query.Team:
+			return false
+		case !query.OwnerID.IsZero() && query.Team == "" && t.OwnerID != query.OwnerID:
+			return false
+		case query.Team != "" && query.OwnerID.IsZero() && t.Team != query.Team:
+			return false
+		case !query.AssigneeID.IsZero() && !t.IsAssignedTo(query.AssigneeID):
+			return false
+		case query.Status != "" && t.Status != query.Status:
+			return false
+		case query.Priority != "" && t.Priority != query.Priority:
+			return false
+		case query.Label != "" && !slices.Contains(t.Labels, query.Label):
+			return false
+		case !query.DueAfter.IsZero() && t.DueDate.Before(query.DueAfter):
+			return false
+		case !query.DueBefore.IsZero() && t.DueDate.After(query.DueBefore):
+			return false
+		case title != "" && !strings.Contains(strings.ToLower(t.Title), title):
+			return false
+		}
+		return true
+	}
+}
+
+// countTaskLabels counts the tasks per label, most used first and ties by label
+func countTaskLabels(tasks []Domain.Task) []Domain.LabelCount {
+	counts := make(map[string]int)
+	for _, t := range tasks {
+		for _, label := range t.Labels {
+			counts[label]++
+		}
+	}
+	var labels []Domain.LabelCount
+	for label, count := range counts {
+		labels = append(labels, Domain.LabelCount{Label: label, Count: count})
+	}
+	slices.SortFunc(labels, func(a, b Domain.LabelCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return cmp.Compare(a.Label, b.Label)
+	})
+	return labels
+}
+
+func (r *MemoryTaskRepository) GetTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	task, ok := r.tasks[id]
+	if !ok || task.IsDeleted() {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) GetDeletedTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	task, ok := r.tasks[id]
+	if !ok || !task.IsDeleted() {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task.ID = Domain.NewID()
+	task.DueDate = roundToMillis(task.DueDate)
+	task.AssigneeIDs = slices.Clone(task.AssigneeIDs)
+	task.BlockedBy = slices.Clone(task.BlockedBy)
+	task.Labels = slices.Clone(task.Labels)
+	task.Version = 1
+	r.tasks[task.ID] = task
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) UpdateTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.tasks[task.ID]
+	if !ok || existing.IsDeleted() {
+		return nil, Domain.ErrTaskNotFound
+	}
+	if existing.Version != task.Version {
+		return nil, Domain.ErrVersionConflict
+	}
+	task.DueDate = roundToMillis(task.DueDate)
+	task.AssigneeIDs = slices.Clone(task.AssigneeIDs)
+	task.BlockedBy = slices.Clone(task.BlockedBy)
+	task.Labels = slices.Clone(task.Labels)
+	task.Version++
+	r.tasks[task.ID] = task
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) DeleteTask(ctx context.Context, id Domain.ID, deletedBy Domain.ID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok || task.IsDeleted() {
+		return Domain.ErrTaskNotFound
+	}
+	task.DeletedAt = roundToMillis(at)
+	task.DeletedBy = deletedBy
+	task.Version++
+	r.tasks[id] = task
+	return nil
+}
+
+func (r *MemoryTaskRepository) RestoreTask(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok || !task.IsDeleted() {
+		return nil, Domain.ErrTaskNotFound
+	}
+	task.DeletedAt = time.Time{}
+	task.DeletedBy = ""
+	task.Version++
+	r.tasks[id] = task
+	return &task, nil
+}
+
+func (r *MemoryTaskRepository) PurgeTask(ctx context.Context, id Domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok || !task.IsDeleted() {
+		return Domain.ErrTaskNotFound
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+// filterTasks returns the matching tasks in creation order
+func (r *MemoryTaskRepository) filterTasks(keep func(Domain.Task) bool) []Domain.Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tasks := make([]Domain.Task, 0, len(r.tasks))
+	for _, t := range r.tasks {
+		if keep(t) {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return compareIDs(tasks[i].ID, tasks[j].ID) < 0
+	})
+	return tasks
+}
+
+// snapshot returns a copy of every stored task as persistence entities
+func (r *MemoryTaskRepository) snapshot() []TaskEntity {
+	tasks := r.filterTasks(func(Domain.Task) bool { return true })
+	entities := make([]TaskEntity, 0, len(tasks))
+	for _, t := range tasks {
+		entities = append(entities, FromDomain(t))
+	}
+	return entities
+}
+
+// restore replaces the stored tasks with the given entities
+func (r *MemoryTaskRepository) restore(entities []TaskEntity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks = make(map[Domain.ID]Domain.Task, len(entities))
+	for _, te := range entities {
+		task := te.ToDomain()
+		r.tasks[task.ID] = task
+	}
+}
+
+// roundToMillis truncates the due date to the millisecond precision of the other backends,
+// so cursors and comparisons behave identically to the Mongo and SQL repositories
+func roundToMillis(t time.Time) time.Time {
+	return time.UnixMilli(t.UnixMilli())
+}
+
+// compareTasks orders two tasks by the given sort, breaking ties by ID
+func compareTasks(s Domain.TaskSort, a, b Domain.Task) int {
+	return compareTaskToCursor(a, &taskCursor{
+		Sort:  s,
+		ID:    b.ID,
+		DueMs: b.DueDate.UnixMilli(),
+		Title: b.Title,
+	})
+}
+
+// compareTaskToCursor reports whether the task sorts before (<0), at (0) or after (>0) the cursor
+func compareTaskToCursor(t Domain.Task, c *taskCursor) int {
+	switch c.Sort {
+	case Domain.SortByDueDate:
+		if d := cmp.Compare(t.DueDate.UnixMilli(), c.DueMs); d != 0 {
+			return d
+		}
+	case Domain.SortByDueDateDesc:
+		if d := cmp.Compare(c.DueMs, t.DueDate.UnixMilli()); d != 0 {
+			return d
+		}
+		return compareIDs(c.ID, t.ID)
+	case Domain.SortByTitle:
+		if d := strings.Compare(t.Title, c.Title); d != 0 {
+			return d
+		}
+	}
+	return compareIDs(t.ID, c.ID)
+}
+
+// compareIDs orders IDs by creation time, see Domain.NewID
+func compareIDs(a, b Domain.ID) int {
+	return strings.Compare(a.String(), b.String())
+}
@@ -0,0 +1,81 @@
+package Repositories
+
+import (
+	"context"
+	"sync"
+	"taskmanager/Domain"
+	"time"
+)
+
+// MemoryTokenRepository implements TokenRepository with in-memory maps.
+// Expired entries are dropped whenever a new one is written.
+type MemoryTokenRepository struct {
+	mu            sync.RWMutex
+	refreshTokens map[string]Domain.RefreshToken
+	revokedTokens map[string]time.Time // jti -> access token expiry
+}
+
+// NewMemoryTokenRepository creates an empty MemoryTokenRepository
+func NewMemoryTokenRepository() *MemoryTokenRepository {
+	return &MemoryTokenRepository{
+		refreshTokens: make(map[string]Domain.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+	}
+}
+
+func (r *MemoryTokenRepository) SaveRefreshToken(ctx context.Context, token Domain.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.purgeExpired(time.Now())
+	r.refreshTokens[token.TokenHash] = token
+	return nil
+}
+
+func (r *MemoryTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*Domain.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.refreshTokens[tokenHash]
+	if !ok {
+		return nil, Domain.ErrInvalidRefreshToken
+	}
+	delete(r.refreshTokens, tokenHash)
+	return &token, nil
+}
+
+func (r *MemoryTokenRepository) DeleteRefreshToken(ctx context.Context, tokenHash string, userID Domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if token, ok := r.refreshTokens[tokenHash]; ok && token.UserID == userID {
+		delete(r.refreshTokens, tokenHash)
+	}
+	return nil
+}
+
+func (r *MemoryTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.purgeExpired(time.Now())
+	r.revokedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *MemoryTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.revokedTokens[jti]
+	return ok, nil
+}
+
+// purgeExpired drops tokens that can no longer be used anyway. The caller must hold the write lock.
+func (r *MemoryTokenRepository) purgeExpired(now time.Time) {
+	for hash, token := range r.refreshTokens {
+		if token.IsExpired(now) {
+			delete(r.refreshTokens, hash)
+		}
+	}
+	for jti, expiresAt := range r.revokedTokens {
+		if !now.Before(expiresAt) {
+			delete(r.revokedTokens, jti)
+		}
+	}
+}
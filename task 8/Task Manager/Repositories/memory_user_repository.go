@@ -0,0 +1,122 @@
+package Repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"taskmanager/Domain"
+)
+
+// MemoryUserRepository implements UserRepository with an in-memory map.
+// It is safe for concurrent use and is meant for local runs and CI without MongoDB.
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[Domain.ID]Domain.User
+}
+
+// NewMemoryUserRepository creates an empty MemoryUserRepository
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[Domain.ID]Domain.User)}
+}
+
+func (r *MemoryUserRepository) RegisterUser(ctx context.Context, user Domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Username == user.Username {
+			return Domain.ErrUsernameTaken
+		}
+		if user.Email != "" && existing.Email == user.Email {
+			return Domain.ErrEmailTaken
+		}
+	}
+	user.ID = Domain.NewID()
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) GetUserByUsername(ctx context.Context, username string) (*Domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, Domain.ErrUserNotFound
+}
+
+func (r *MemoryUserRepository) GetUserByID(ctx context.Context, id Domain.ID) (*Domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, Domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// ListUsers returns every user in creation order
+func (r *MemoryUserRepository) ListUsers(ctx context.Context) ([]Domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := make([]Domain.User, 0, len(r.users))
+	for _, u := range r.users {
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return compareIDs(users[i].ID, users[j].ID) < 0
+	})
+	return users, nil
+}
+
+func (r *MemoryUserRepository) UpdateUserRole(ctx context.Context, id Domain.ID, role string) (*Domain.User, error) {
+	return r.updateUser(id, func(user *Domain.User) { user.Role = role })
+}
+
+func (r *MemoryUserRepository) UpdateUserTeam(ctx context.Context, id Domain.ID, team string) (*Domain.User, error) {
+	return r.updateUser(id, func(user *Domain.User) { user.Team = team })
+}
+
+func (r *MemoryUserRepository) updateUser(id Domain.ID, update func(*Domain.User)) (*Domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, Domain.ErrUserNotFound
+	}
+	update(&user)
+	r.users[id] = user
+	return &user, nil
+}
+
+func (r *MemoryUserRepository) DeleteUser(ctx context.Context, id Domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return Domain.ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// snapshot returns a copy of every stored user as persistence entities
+func (r *MemoryUserRepository) snapshot() []UserEntity {
+	users, _ := r.ListUsers(context.Background())
+	entities := make([]UserEntity, 0, len(users))
+	for _, u := range users {
+		entities = append(entities, UserFromDomain(u))
+	}
+	return entities
+}
+
+// restore replaces the stored users with the given entities
+func (r *MemoryUserRepository) restore(entities []UserEntity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users = make(map[Domain.ID]Domain.User, len(entities))
+	for _, ue := range entities {
+		user := ue.ToDomain()
+		r.users[user.ID] = user
+	}
+}
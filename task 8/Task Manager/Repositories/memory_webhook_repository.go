@@ -0,0 +1,153 @@
+package Repositories
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"taskmanager/Domain"
+)
+
+// MemoryWebhookRepository implements WebhookRepository with in-memory maps.
+// Subscriptions and dead letters are part of the memory snapshot, secrets included.
+type MemoryWebhookRepository struct {
+	mu            sync.RWMutex
+	subscriptions map[Domain.ID]Domain.WebhookSubscription
+	subOrder      []Domain.ID // creation order
+	deadLetters   map[Domain.ID]Domain.WebhookDeadLetter
+	letterOrder   []Domain.ID // order of failure
+}
+
+// NewMemoryWebhookRepository creates an empty MemoryWebhookRepository
+func NewMemoryWebhookRepository() *MemoryWebhookRepository {
+	return &MemoryWebhookRepository{
+		subscriptions: make(map[Domain.ID]Domain.WebhookSubscription),
+		deadLetters:   make(map[Domain.ID]Domain.WebhookDeadLetter),
+	}
+}
+
+func (r *MemoryWebhookRepository) AddSubscription(ctx context.Context, sub Domain.WebhookSubscription) (*Domain.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sub.ID = Domain.NewID()
+	sub.Events = slices.Clone(sub.Events)
+	sub.CreatedAt = roundToMillis(sub.CreatedAt)
+	r.subscriptions[sub.ID] = sub
+	r.subOrder = append(r.subOrder, sub.ID)
+	return &sub, nil
+}
+
+func (r *MemoryWebhookRepository) GetSubscription(ctx context.Context, id Domain.ID) (*Domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sub, ok := r.subscriptions[id]
+	if !ok {
+		return nil, Domain.ErrWebhookNotFound
+	}
+	sub.Events = slices.Clone(sub.Events)
+	return &sub, nil
+}
+
+func (r *MemoryWebhookRepository) ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subs := make([]Domain.WebhookSubscription, 0, len(r.subOrder))
+	for _, id := range r.subOrder {
+		sub := r.subscriptions[id]
+		sub.Events = slices.Clone(sub.Events)
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+func (r *MemoryWebhookRepository) DeleteSubscription(ctx context.Context, id Domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subscriptions[id]; !ok {
+		return Domain.ErrWebhookNotFound
+	}
+	delete(r.subscriptions, id)
+	r.subOrder = slices.DeleteFunc(r.subOrder, func(existing Domain.ID) bool { return existing == id })
+	return nil
+}
+
+func (r *MemoryWebhookRepository) AddDeadLetter(ctx context.Context, letter Domain.WebhookDeadLetter) (*Domain.WebhookDeadLetter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	letter.ID = Domain.NewID()
+	letter.Payload = slices.Clone(letter.Payload)
+	letter.FailedAt = roundToMillis(letter.FailedAt)
+	r.deadLetters[letter.ID] = letter
+	r.letterOrder = append(r.letterOrder, letter.ID)
+	return &letter, nil
+}
+
+func (r *MemoryWebhookRepository) GetDeadLetter(ctx context.Context, id Domain.ID) (*Domain.WebhookDeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	letter, ok := r.deadLetters[id]
+	if !ok {
+		return nil, Domain.ErrDeadLetterNotFound
+	}
+	letter.Payload = slices.Clone(letter.Payload)
+	return &letter, nil
+}
+
+func (r *MemoryWebhookRepository) ListDeadLetters(ctx context.Context) ([]Domain.WebhookDeadLetter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	letters := make([]Domain.WebhookDeadLetter, 0, len(r.letterOrder))
+	for i := len(r.letterOrder) - 1; i >= 0; i-- {
+		letter := r.deadLetters[r.letterOrder[i]]
+		letter.Payload = slices.Clone(letter.Payload)
+		letters = append(letters, letter)
+	}
+	// Most recent failure first, letters of the same millisecond stay in reverse insertion order
+	slices.SortStableFunc(letters, func(a, b Domain.WebhookDeadLetter) int { return b.FailedAt.Compare(a.FailedAt) })
+	return letters, nil
+}
+
+func (r *MemoryWebhookRepository) DeleteDeadLetter(ctx context.Context, id Domain.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.deadLetters[id]; !ok {
+		return Domain.ErrDeadLetterNotFound
+	}
+	delete(r.deadLetters, id)
+	r.letterOrder = slices.DeleteFunc(r.letterOrder, func(existing Domain.ID) bool { return existing == id })
+	return nil
+}
+
+// snapshot returns the subscriptions in creation order and the dead letters in order of failure
+func (r *MemoryWebhookRepository) snapshot() ([]WebhookEntity, []DeadLetterEntity) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	subs := make([]WebhookEntity, 0, len(r.subOrder))
+	for _, id := range r.subOrder {
+		subs = append(subs, WebhookFromDomain(r.subscriptions[id]))
+	}
+	letters := make([]DeadLetterEntity, 0, len(r.letterOrder))
+	for _, id := range r.letterOrder {
+		letters = append(letters, DeadLetterFromDomain(r.deadLetters[id]))
+	}
+	return subs, letters
+}
+
+// restore replaces the stored subscriptions and dead letters, given in the order of snapshot
+func (r *MemoryWebhookRepository) restore(subs []WebhookEntity, letters []DeadLetterEntity) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions = make(map[Domain.ID]Domain.WebhookSubscription, len(subs))
+	r.subOrder = make([]Domain.ID, 0, len(subs))
+	for _, e := range subs {
+		sub := e.ToDomain()
+		r.subscriptions[sub.ID] = sub
+		r.subOrder = append(r.subOrder, sub.ID)
+	}
+	r.deadLetters = make(map[Domain.ID]Domain.WebhookDeadLetter, len(letters))
+	r.letterOrder = make([]Domain.ID, 0, len(letters))
+	for _, e := range letters {
+		letter := e.ToDomain()
+		r.deadLetters[letter.ID] = letter
+		r.letterOrder = append(r.letterOrder, letter.ID)
+	}
+}
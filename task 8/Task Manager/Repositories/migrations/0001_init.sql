@@ -0,0 +1,24 @@
+-- Initial schema for the SQL storage backend.
+-- Keep statements portable between SQLite and PostgreSQL.
+-- IDs are 24 character hex strings so they stay interchangeable with MongoDB ObjectIDs.
+-- Due dates are stored as unix milliseconds, the same precision as a BSON DateTime.
+
+CREATE TABLE users (
+    id       TEXT PRIMARY KEY,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    email    TEXT NOT NULL DEFAULT '',
+    role     TEXT NOT NULL DEFAULT 'user'
+);
+
+CREATE TABLE tasks (
+    id          TEXT PRIMARY KEY,
+    owner_id    TEXT NOT NULL,
+    title       TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    due_date    BIGINT NOT NULL,
+    status      TEXT NOT NULL
+);
+
+CREATE INDEX idx_tasks_owner_id ON tasks (owner_id);
+CREATE INDEX idx_tasks_due_date ON tasks (due_date, id);
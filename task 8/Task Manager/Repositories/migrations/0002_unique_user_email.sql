@@ -0,0 +1,2 @@
+-- Email is optional, so only non-empty addresses have to be unique.
+CREATE UNIQUE INDEX idx_users_email ON users (email) WHERE email <> '';
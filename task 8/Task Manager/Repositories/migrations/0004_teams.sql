@@ -0,0 +1,8 @@
+-- Teams for the permission model: managers act on the tasks of their team.
+-- A task keeps the team its owner was in when it was created.
+
+ALTER TABLE users ADD COLUMN team TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tasks ADD COLUMN team TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_tasks_team ON tasks (team);
@@ -0,0 +1,4 @@
+-- Optimistic concurrency: every write to a task increments its version,
+-- and an update only applies if the version is still the one the client read.
+
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,8 @@
+-- Soft delete: a deleted task stays in the table with deleted_at set (unix milliseconds)
+-- until it is restored or purged. Every regular read filters on deleted_at IS NULL.
+
+ALTER TABLE tasks ADD COLUMN deleted_at BIGINT;
+
+ALTER TABLE tasks ADD COLUMN deleted_by TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_tasks_deleted_at ON tasks (deleted_at);
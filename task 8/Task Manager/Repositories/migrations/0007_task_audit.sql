@@ -0,0 +1,14 @@
+-- Change history of tasks. Rows are only ever inserted.
+-- changes holds the field level diff as a JSON array of {"field", "before", "after"} objects.
+
+CREATE TABLE task_audit_events (
+    id             TEXT PRIMARY KEY,
+    task_id        TEXT NOT NULL,
+    action         TEXT NOT NULL,
+    actor_id       TEXT NOT NULL,
+    actor_username TEXT NOT NULL DEFAULT '',
+    at             BIGINT NOT NULL,
+    changes        TEXT NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_task_audit_events_task_id ON task_audit_events (task_id, at, id);
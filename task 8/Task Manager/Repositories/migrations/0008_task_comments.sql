@@ -0,0 +1,13 @@
+-- Comments posted on tasks, listed oldest first.
+
+CREATE TABLE task_comments (
+    id              TEXT PRIMARY KEY,
+    task_id         TEXT NOT NULL,
+    author_id       TEXT NOT NULL,
+    author_username TEXT NOT NULL DEFAULT '',
+    body            TEXT NOT NULL,
+    created_at      BIGINT NOT NULL,
+    updated_at      BIGINT NOT NULL
+);
+
+CREATE INDEX idx_task_comments_task_id ON task_comments (task_id, created_at, id);
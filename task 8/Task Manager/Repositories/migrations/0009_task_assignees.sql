@@ -0,0 +1,4 @@
+-- Task assignees, stored as a JSON array of user IDs such as ["66f...","670..."].
+-- Listing the tasks of an assignee matches the quoted ID inside the array.
+
+ALTER TABLE tasks ADD COLUMN assignee_ids TEXT NOT NULL DEFAULT '[]';
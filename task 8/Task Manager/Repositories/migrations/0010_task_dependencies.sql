@@ -0,0 +1,8 @@
+-- Subtasks and dependencies. parent_id is '' for a top level task,
+-- blocked_by is a JSON array of the IDs of the tasks that must be completed first.
+
+ALTER TABLE tasks ADD COLUMN parent_id TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tasks ADD COLUMN blocked_by TEXT NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_tasks_parent_id ON tasks (parent_id);
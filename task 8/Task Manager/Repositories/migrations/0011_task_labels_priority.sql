@@ -0,0 +1,8 @@
+-- Task priority and labels. priority is '' for tasks created before priorities existed,
+-- labels is a JSON array of strings such as ["backend","bug"].
+
+ALTER TABLE tasks ADD COLUMN priority TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tasks ADD COLUMN labels TEXT NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_tasks_priority ON tasks (priority);
@@ -0,0 +1,6 @@
+-- Recurring tasks. recurrence holds the rule, such as FREQ=WEEKLY, and is '' for a one-off task.
+-- occurrence is the position of the task in its series.
+
+ALTER TABLE tasks ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE tasks ADD COLUMN occurrence INTEGER NOT NULL DEFAULT 0;
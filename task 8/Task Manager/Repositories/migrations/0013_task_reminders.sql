@@ -0,0 +1,9 @@
+-- Reminders already sent by the scheduler, one per task, kind and due date.
+
+CREATE TABLE task_reminders (
+    task_id  TEXT NOT NULL,
+    kind     TEXT NOT NULL,
+    due_date BIGINT NOT NULL,
+    sent_at  BIGINT NOT NULL,
+    PRIMARY KEY (task_id, kind, due_date)
+);
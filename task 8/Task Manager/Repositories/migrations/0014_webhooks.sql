@@ -0,0 +1,26 @@
+-- Webhook subscriptions, events is a JSON array of event types, empty for every event.
+
+CREATE TABLE webhook_subscriptions (
+    id         TEXT PRIMARY KEY,
+    url        TEXT NOT NULL,
+    secret     TEXT NOT NULL,
+    events     TEXT NOT NULL DEFAULT '[]',
+    created_by TEXT NOT NULL,
+    created_at BIGINT NOT NULL
+);
+
+-- Deliveries that failed after every retry, listed most recent first.
+
+CREATE TABLE webhook_dead_letters (
+    id              TEXT PRIMARY KEY,
+    subscription_id TEXT NOT NULL,
+    url             TEXT NOT NULL,
+    event_id        TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         TEXT NOT NULL,
+    attempts        INTEGER NOT NULL,
+    last_error      TEXT NOT NULL DEFAULT '',
+    failed_at       BIGINT NOT NULL
+);
+
+CREATE INDEX idx_webhook_dead_letters_failed_at ON webhook_dead_letters (failed_at, id);
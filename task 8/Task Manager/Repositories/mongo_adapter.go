@@ -73,6 +73,33 @@ func (a *MongoCollectionAdapter) DeleteOne(ctx context.Context, filter interface
 	return &MongoDeleteResultAdapter{res}, nil
 }
 
+func (a *MongoCollectionAdapter) Aggregate(ctx context.Context, pipeline interface{}, opts ...interface{}) (Cursor, error) {
+	var mongoOpts []*options.AggregateOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.AggregateOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	cursor, err := a.Coll.Aggregate(ctx, pipeline, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (a *MongoCollectionAdapter) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := a.Coll.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+func (a *MongoCollectionAdapter) UpdateMany(ctx context.Context, filter interface{}, update interface{}) (int64, error) {
+	res, err := a.Coll.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
 type MongoDeleteResultAdapter struct {
 	res *mongo.DeleteResult
 }
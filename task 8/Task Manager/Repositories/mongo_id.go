@@ -0,0 +1,60 @@
+package Repositories
+
+import (
+	"taskmanager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ObjectIDFromDomain converts a Domain.ID into the ObjectID stored in MongoDB.
+// The zero ID maps to primitive.NilObjectID.
+func ObjectIDFromDomain(id Domain.ID) (primitive.ObjectID, error) {
+	if id.IsZero() {
+		return primitive.NilObjectID, nil
+	}
+	oid, err := primitive.ObjectIDFromHex(id.String())
+	if err != nil {
+		return primitive.NilObjectID, Domain.ErrInvalidID
+	}
+	return oid, nil
+}
+
+// DomainIDFromObjectID converts a MongoDB ObjectID into a Domain.ID.
+// primitive.NilObjectID maps to the zero ID.
+func DomainIDFromObjectID(oid primitive.ObjectID) Domain.ID {
+	if oid.IsZero() {
+		return ""
+	}
+	return Domain.ID(oid.Hex())
+}
+
+// objectIDOrNil converts IDs that were already validated by the domain layer.
+// Malformed IDs map to primitive.NilObjectID, which never matches a stored document.
+func objectIDOrNil(id Domain.ID) primitive.ObjectID {
+	oid, _ := ObjectIDFromDomain(id)
+	return oid
+}
+
+// objectIDsOrNil converts a list of IDs with objectIDOrNil, nil stays nil
+func objectIDsOrNil(ids []Domain.ID) []primitive.ObjectID {
+	if ids == nil {
+		return nil
+	}
+	oids := make([]primitive.ObjectID, len(ids))
+	for i, id := range ids {
+		oids[i] = objectIDOrNil(id)
+	}
+	return oids
+}
+
+// domainIDsFromObjectIDs converts a list of ObjectIDs, an empty list maps to nil
+func domainIDsFromObjectIDs(oids []primitive.ObjectID) []Domain.ID {
+	if len(oids) == 0 {
+		return nil
+	}
+	ids := make([]Domain.ID, len(oids))
+	for i, oid := range oids {
+		ids[i] = DomainIDFromObjectID(oid)
+	}
+	return ids
+}
@@ -0,0 +1,45 @@
+package Repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ReminderMongoCollectionAdapter struct {
+	Coll *mongo.Collection
+}
+
+func (a *ReminderMongoCollectionAdapter) InsertOne(ctx context.Context, document interface{}, opts ...interface{}) (*InsertOneResult, error) {
+	var mongoOpts []*options.InsertOneOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.InsertOneOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.InsertOne(ctx, document, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &InsertOneResult{InsertedID: res.InsertedID}, nil
+}
+
+func (a *ReminderMongoCollectionAdapter) DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error) {
+	var mongoOpts []*options.DeleteOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.DeleteOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.DeleteOne(ctx, filter, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoDeleteResultAdapter{res}, nil
+}
+
+func (a *ReminderMongoCollectionAdapter) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := a.Coll.Indexes().CreateMany(ctx, models)
+	return err
+}
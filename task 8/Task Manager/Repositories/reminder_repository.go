@@ -0,0 +1,91 @@
+package Repositories
+
+import (
+	"context"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReminderRepository remembers which reminders were sent, so that each is sent once
+// even with several instances of the scheduler running
+type ReminderRepository interface {
+	// ClaimReminder records the reminder as sent at at. It returns false if it already was.
+	ClaimReminder(ctx context.Context, key Domain.ReminderKey, at time.Time) (bool, error)
+	// ReleaseReminder forgets a claimed reminder whose delivery failed, so it is tried again
+	ReleaseReminder(ctx context.Context, key Domain.ReminderKey) error
+}
+
+// ReminderEntity is the persistence model of a sent reminder
+type ReminderEntity struct {
+	TaskID  primitive.ObjectID `bson:"task_id" json:"task_id"`
+	Kind    string             `bson:"kind" json:"kind"`
+	DueDate primitive.DateTime `bson:"due_date" json:"due_date"`
+	SentAt  primitive.DateTime `bson:"sent_at" json:"sent_at"`
+}
+
+// ReminderCollection defines the minimal collection interface for the reminder repository
+type ReminderCollection interface {
+	InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*InsertOneResult, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
+}
+
+// MongoReminderRepository implements ReminderRepository using MongoDB
+type MongoReminderRepository struct {
+	collection ReminderCollection
+}
+
+// NewMongoReminderRepository creates a new MongoReminderRepository
+func NewMongoReminderRepository(collection ReminderCollection) *MongoReminderRepository {
+	return &MongoReminderRepository{collection: collection}
+}
+
+// EnsureIndexes creates the unique index that makes claiming a reminder atomic
+func (r *MongoReminderRepository) EnsureIndexes(ctx context.Context) error {
+	err := r.collection.CreateIndexes(ctx, []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "task_id", Value: 1}, {Key: "kind", Value: 1}, {Key: "due_date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to create reminder indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoReminderRepository) ClaimReminder(ctx context.Context, key Domain.ReminderKey, at time.Time) (bool, error) {
+	_, err := r.collection.InsertOne(ctx, ReminderEntity{
+		TaskID:  objectIDOrNil(key.TaskID),
+		Kind:    string(key.Kind),
+		DueDate: primitive.NewDateTimeFromTime(key.DueDate),
+		SentAt:  primitive.NewDateTimeFromTime(at),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+	return true, nil
+}
+
+func (r *MongoReminderRepository) ReleaseReminder(ctx context.Context, key Domain.ReminderKey) error {
+	_, err := r.collection.DeleteOne(ctx, reminderFilter(key))
+	if err != nil {
+		return fmt.Errorf("failed to release reminder: %w", err)
+	}
+	return nil
+}
+
+func reminderFilter(key Domain.ReminderKey) bson.M {
+	return bson.M{
+		"task_id":  objectIDOrNil(key.TaskID),
+		"kind":     string(key.Kind),
+		"due_date": primitive.NewDateTimeFromTime(key.DueDate),
+	}
+}
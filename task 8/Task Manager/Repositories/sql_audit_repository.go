@@ -0,0 +1,71 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+)
+
+// SQLAuditRepository implements AuditRepository on top of database/sql
+type SQLAuditRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLAuditRepository creates a new SQLAuditRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLAuditRepository(db *sql.DB, dialect SQLDialect) *SQLAuditRepository {
+	return &SQLAuditRepository{db: db, dialect: dialect}
+}
+
+func (r *SQLAuditRepository) AddEvent(ctx context.Context, event Domain.AuditEvent) (*Domain.AuditEvent, error) {
+	event.ID = Domain.NewID()
+	event.At = roundToMillis(event.At)
+	// The JSON layout is the one of the Mongo entity
+	changes, err := json.Marshal(AuditEventFromDomain(event).Changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO task_audit_events (id, task_id, action, actor_id, actor_username, at, changes) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		event.ID.String(), event.TaskID.String(), string(event.Action), event.ActorID.String(), event.ActorUsername, event.At.UnixMilli(), string(changes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert audit event: %w", err)
+	}
+	return &event, nil
+}
+
+func (r *SQLAuditRepository) ListTaskEvents(ctx context.Context, taskID Domain.ID) ([]Domain.AuditEvent, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`SELECT id, task_id, action, actor_id, actor_username, at, changes FROM task_audit_events WHERE task_id = ? ORDER BY at, id`), taskID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Domain.AuditEvent
+	for rows.Next() {
+		var (
+			event   Domain.AuditEvent
+			atMs    int64
+			changes string
+		)
+		if err := rows.Scan(&event.ID, &event.TaskID, &event.Action, &event.ActorID, &event.ActorUsername, &atMs, &changes); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		var entities []FieldChangeEntity
+		if err := json.Unmarshal([]byte(changes), &entities); err != nil {
+			return nil, fmt.Errorf("failed to decode audit changes: %w", err)
+		}
+		for _, c := range entities {
+			event.Changes = append(event.Changes, Domain.FieldChange{Field: c.Field, Before: c.Before, After: c.After})
+		}
+		event.At = time.UnixMilli(atMs)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	return events, nil
+}
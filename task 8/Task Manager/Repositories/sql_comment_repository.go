@@ -0,0 +1,103 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+)
+
+// SQLCommentRepository implements CommentRepository on top of database/sql
+type SQLCommentRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLCommentRepository creates a new SQLCommentRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLCommentRepository(db *sql.DB, dialect SQLDialect) *SQLCommentRepository {
+	return &SQLCommentRepository{db: db, dialect: dialect}
+}
+
+const commentColumns = `id, task_id, author_id, author_username, body, created_at, updated_at`
+
+func (r *SQLCommentRepository) AddComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error) {
+	comment.ID = Domain.NewID()
+	comment.CreatedAt = roundToMillis(comment.CreatedAt)
+	comment.UpdatedAt = roundToMillis(comment.UpdatedAt)
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO task_comments (`+commentColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)`),
+		comment.ID.String(), comment.TaskID.String(), comment.AuthorID.String(), comment.AuthorUsername, comment.Body,
+		comment.CreatedAt.UnixMilli(), comment.UpdatedAt.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert comment: %w", err)
+	}
+	return &comment, nil
+}
+
+func (r *SQLCommentRepository) GetCommentByID(ctx context.Context, id Domain.ID) (*Domain.Comment, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+commentColumns+` FROM task_comments WHERE id = ?`), id.String())
+	comment, err := scanComment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Domain.ErrCommentNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find comment: %w", err)
+	}
+	return comment, nil
+}
+
+func (r *SQLCommentRepository) ListTaskComments(ctx context.Context, taskID Domain.ID) ([]Domain.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`SELECT `+commentColumns+` FROM task_comments WHERE task_id = ? ORDER BY created_at, id`), taskID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []Domain.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+		comments = append(comments, *comment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	return comments, nil
+}
+
+func (r *SQLCommentRepository) UpdateComment(ctx context.Context, comment Domain.Comment) (*Domain.Comment, error) {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`UPDATE task_comments SET body = ?, updated_at = ? WHERE id = ?`),
+		comment.Body, roundToMillis(comment.UpdatedAt).UnixMilli(), comment.ID.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update comment: %w", err)
+	}
+	if err := expectOneRow(res, Domain.ErrCommentNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetCommentByID(ctx, comment.ID)
+}
+
+func (r *SQLCommentRepository) DeleteComment(ctx context.Context, id Domain.ID) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM task_comments WHERE id = ?`), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+	return expectOneRow(res, Domain.ErrCommentNotFound)
+}
+
+func scanComment(row rowScanner) (*Domain.Comment, error) {
+	var (
+		comment              Domain.Comment
+		createdMs, updatedMs int64
+	)
+	if err := row.Scan(&comment.ID, &comment.TaskID, &comment.AuthorID, &comment.AuthorUsername, &comment.Body, &createdMs, &updatedMs); err != nil {
+		return nil, err
+	}
+	comment.CreatedAt = time.UnixMilli(createdMs)
+	comment.UpdatedAt = time.UnixMilli(updatedMs)
+	return &comment, nil
+}
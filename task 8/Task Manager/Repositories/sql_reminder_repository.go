@@ -0,0 +1,43 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+)
+
+// SQLReminderRepository implements ReminderRepository on top of database/sql
+type SQLReminderRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLReminderRepository creates a new SQLReminderRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLReminderRepository(db *sql.DB, dialect SQLDialect) *SQLReminderRepository {
+	return &SQLReminderRepository{db: db, dialect: dialect}
+}
+
+// ClaimReminder relies on the primary key to reject a reminder that was already sent
+func (r *SQLReminderRepository) ClaimReminder(ctx context.Context, key Domain.ReminderKey, at time.Time) (bool, error) {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO task_reminders (task_id, kind, due_date, sent_at) VALUES (?, ?, ?, ?)`),
+		key.TaskID.String(), string(key.Kind), key.DueDate.UnixMilli(), at.UnixMilli())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim reminder: %w", err)
+	}
+	return true, nil
+}
+
+func (r *SQLReminderRepository) ReleaseReminder(ctx context.Context, key Domain.ReminderKey) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM task_reminders WHERE task_id = ? AND kind = ? AND due_date = ?`),
+		key.TaskID.String(), string(key.Kind), key.DueDate.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to release reminder: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"taskmanager/Domain"
+	"time"
+)
+
+// SQLTaskRepository implements TaskRepository on top of database/sql.
+// It works with SQLite and PostgreSQL, see SQLDialect.
+type SQLTaskRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLTaskRepository creates a new SQLTaskRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLTaskRepository(db *sql.DB, dialect SQLDialect) *SQLTaskRepository {
+	return &SQLTaskRepository{db: db, dialect: dialect}
+}
+
+const taskColumns = `id, owner_id, team, title, description, due_date, status, priority, labels, assignee_ids, parent_id, blocked_by, recurrence, occurrence, version, deleted_at, deleted_by`
+
+func (r *SQLTaskRepository) GetAllTasks(ctx context.Context) ([]Domain.Task, error) {
+	return r.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE deleted_at IS NULL ORDER BY id`)
+}
+
+func (r *SQLTaskRepository) GetTasksByOwner(ctx context.Context, ownerID Domain.ID) ([]Domain.Task, error) {
+	return r.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE owner_id = ? AND deleted_at IS NULL ORDER BY id`, ownerID.String())
+}
+
+func (r *SQLTaskRepository) GetSubtasks(ctx context.Context, parentID Domain.ID) ([]Domain.Task, error) {
+	return r.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE parent_id = ? AND parent_id <> '' AND deleted_at IS NULL ORDER BY id`, parentID.String())
+}
+
+// ListTasks applies the same filtering, ordering and keyset pagination as MongoTaskRepository
+func (r *SQLTaskRepository) ListTasks(ctx context.Context, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	conds, args, err := r.queryConditions(query)
+	if err != nil {
+		return nil, err
+	}
+	stmt := `SELECT ` + taskColumns + ` FROM tasks WHERE ` + strings.Join(conds, " AND ")
+	stmt += ` ORDER BY ` + r.orderBy(query.Sort) + ` LIMIT ?`
+	args = append(args, query.Limit+1) // one extra to know whether another page exists
+
+	tasks, err := r.queryTasks(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	page := &Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > query.Limit {
+		page.Tasks = tasks[:query.Limit]
+		page.NextCursor = encodeTaskCursor(query.Sort, page.Tasks[query.Limit-1])
+	}
+	return page, nil
+}
+
+// CountLabels selects the labels of the matching tasks and counts them in Go,
+// the JSON label lists are not portable to unnest across SQLite and PostgreSQL
+func (r *SQLTaskRepository) CountLabels(ctx context.Context, query Domain.TaskQuery) ([]Domain.LabelCount, error) {
+	query.Cursor = ""
+	conds, args, err := r.queryConditions(query)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(`SELECT labels FROM tasks WHERE `+strings.Join(conds, " AND ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count labels: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []Domain.Task
+	for rows.Next() {
+		var labels string
+		if err := rows.Scan(&labels); err != nil {
+			return nil, err
+		}
+		var task Domain.Task
+		if task.Labels, err = decodeLabels(labels); err != nil {
+			return nil, fmt.Errorf("failed to decode task labels: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return countTaskLabels(tasks), nil
+}
+
+// queryConditions translates a TaskQuery into WHERE conditions and their arguments
+func (r *SQLTaskRepository) queryConditions(query Domain.TaskQuery) ([]string, []interface{}, error) {
+	var (
+		conds = []string{"deleted_at IS NULL"}
+		args  []interface{}
+	)
+	if query.Deleted {
+		conds[0] = "deleted_at IS NOT NULL"
+	}
+	switch {
+	case !query.OwnerID.IsZero() && query.Team != "":
+		conds = append(conds, "(owner_id = ? OR team = ?)")
+		args = append(args, query.OwnerID.String(), query.Team)
+	case !query.OwnerID.IsZero():
+		conds = append(conds, "owner_id = ?")
+		args = append(args, query.OwnerID.String())
+	case query.Team != "":
+		conds = append(conds, "team = ?")
+		args = append(args, query.Team)
+	}
+	if !query.AssigneeID.IsZero() {
+		// assignee_ids is a JSON array of IDs, which never contain quotes or LIKE wildcards
+		conds = append(conds, "assignee_ids LIKE ?")
+		args = append(args, `%"`+query.AssigneeID.String()+`"%`)
+	}
+	if query.Status != "" {
+		conds = append(conds, "status = ?")
+		args = append(args, string(query.Status))
+	}
+	if query.Priority != "" {
+		conds = append(conds, "priority = ?")
+		args = append(args, string(query.Priority))
+	}
+	if query.Label != "" {
+		// labels is a JSON array of strings, valid labels never contain quotes or backslashes
+		conds = append(conds, `labels LIKE ? ESCAPE '\'`)
+		args = append(args, `%"`+escapeLike(query.Label)+`"%`)
+	}
+	if !query.DueAfter.IsZero() {
+		conds = append(conds, "due_date >= ?")
+		args = append(args, query.DueAfter.UnixMilli())
+	}
+	if !query.DueBefore.IsZero() {
+		conds = append(conds, "due_date <= ?")
+		args = append(args, query.DueBefore.UnixMilli())
+	}
+	if query.Title != "" {
+		conds = append(conds, `LOWER(title) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(strings.ToLower(query.Title))+"%")
+	}
+	if query.Cursor != "" {
+		c, err := decodeTaskCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, nil, err
+		}
+		cond, condArgs := r.cursorCondition(c)
+		conds = append(conds, cond)
+		args = append(args, condArgs...)
+	}
+	return conds, args, nil
+}
+
+func (r *SQLTaskRepository) cursorCondition(c *taskCursor) (string, []interface{}) {
+	id := c.ID.String()
+	switch c.Sort {
+	case Domain.SortByDueDate:
+		return "(due_date > ? OR (due_date = ? AND id > ?))", []interface{}{c.DueMs, c.DueMs, id}
+	case Domain.SortByDueDateDesc:
+		return "(due_date < ? OR (due_date = ? AND id < ?))", []interface{}{c.DueMs, c.DueMs, id}
+	case Domain.SortByTitle:
+		collate := r.dialect.binaryCollation()
+		return "(title " + collate + " > ? OR (title = ? AND id > ?))", []interface{}{c.Title, c.Title, id}
+	default:
+		return "id > ?", []interface{}{id}
+	}
+}
+
+func (r *SQLTaskRepository) orderBy(sort Domain.TaskSort) string {
+	switch sort {
+	case Domain.SortByDueDate:
+		return "due_date, id"
+	case Domain.SortByDueDateDesc:
+		return "due_date DESC, id DESC"
+	case Domain.SortByTitle:
+		return "title " + r.dialect.binaryCollation() + ", id"
+	default:
+		return "id"
+	}
+}
+
+func (r *SQLTaskRepository) GetTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	return r.getTask(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ? AND deleted_at IS NULL`, id)
+}
+
+func (r *SQLTaskRepository) GetDeletedTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	return r.getTask(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ? AND deleted_at IS NOT NULL`, id)
+}
+
+func (r *SQLTaskRepository) getTask(ctx context.Context, query string, id Domain.ID) (*Domain.Task, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(query), id.String())
+	task, err := scanTask(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Domain.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find task: %w", err)
+	}
+	return &task, nil
+}
+
+func (r *SQLTaskRepository) AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	task.ID = Domain.NewID()
+	task.DueDate = roundToMillis(task.DueDate)
+	task.Version = 1
+	assignees, err := encodeIDs(task.AssigneeIDs)
+	if err != nil {
+		return nil, err
+	}
+	blockedBy, err := encodeIDs(task.BlockedBy)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := encodeLabels(task.Labels)
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO tasks (id, owner_id, team, title, description, due_date, status, priority, labels, assignee_ids, parent_id, blocked_by, recurrence, occurrence, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		task.ID.String(), task.OwnerID.String(), task.Team, task.Title, task.Description, task.DueDate.UnixMilli(), string(task.Status), string(task.Priority), labels, assignees, task.ParentID.String(), blockedBy, task.Recurrence, task.Occurrence, task.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert task: %w", err)
+	}
+	return &task, nil
+}
+
+func (r *SQLTaskRepository) UpdateTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	task.DueDate = roundToMillis(task.DueDate)
+	assignees, err := encodeIDs(task.AssigneeIDs)
+	if err != nil {
+		return nil, err
+	}
+	blockedBy, err := encodeIDs(task.BlockedBy)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := encodeLabels(task.Labels)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`UPDATE tasks SET owner_id = ?, team = ?, title = ?, description = ?, due_date = ?, status = ?, priority = ?, labels = ?, assignee_ids = ?, parent_id = ?, blocked_by = ?, recurrence = ?, occurrence = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`),
+		task.OwnerID.String(), task.Team, task.Title, task.Description, task.DueDate.UnixMilli(), string(task.Status), string(task.Priority), labels, assignees, task.ParentID.String(), blockedBy, task.Recurrence, task.Occurrence, task.ID.String(), task.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update task: %w", err)
+	}
+	if err := expectOneRow(res, Domain.ErrVersionConflict); err != nil {
+		// Either the task is gone or its version moved on
+		if _, getErr := r.GetTaskByID(ctx, task.ID); getErr != nil {
+			return nil, getErr
+		}
+		return nil, err
+	}
+	task.Version++
+	return &task, nil
+}
+
+func (r *SQLTaskRepository) DeleteTask(ctx context.Context, id Domain.ID, deletedBy Domain.ID, at time.Time) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`UPDATE tasks SET deleted_at = ?, deleted_by = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL`),
+		at.UnixMilli(), deletedBy.String(), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return expectOneRow(res, Domain.ErrTaskNotFound)
+}
+
+func (r *SQLTaskRepository) RestoreTask(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`UPDATE tasks SET deleted_at = NULL, deleted_by = '', version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL`), id.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+	if err := expectOneRow(res, Domain.ErrTaskNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetTaskByID(ctx, id)
+}
+
+func (r *SQLTaskRepository) PurgeTask(ctx context.Context, id Domain.ID) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM tasks WHERE id = ? AND deleted_at IS NOT NULL`), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+	return expectOneRow(res, Domain.ErrTaskNotFound)
+}
+
+func (r *SQLTaskRepository) queryTasks(ctx context.Context, query string, args ...interface{}) ([]Domain.Task, error) {
+	rows, err := r.db.QueryContext(ctx, r.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []Domain.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return tasks, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(row rowScanner) (Domain.Task, error) {
+	var (
+		dueMs     int64
+		deletedMs sql.NullInt64
+		deletedBy string
+		labels    string
+		assignees string
+		blockedBy string
+		task      Domain.Task
+	)
+	if err := row.Scan(&task.ID, &task.OwnerID, &task.Team, &task.Title, &task.Description, &dueMs, &task.Status, &task.Priority, &labels, &assignees, &task.ParentID, &blockedBy, &task.Recurrence, &task.Occurrence, &task.Version, &deletedMs, &deletedBy); err != nil {
+		return Domain.Task{}, err
+	}
+	var err error
+	if task.Labels, err = decodeLabels(labels); err != nil {
+		return Domain.Task{}, fmt.Errorf("failed to decode task labels: %w", err)
+	}
+	if task.AssigneeIDs, err = decodeIDs(assignees); err != nil {
+		return Domain.Task{}, fmt.Errorf("failed to decode task assignees: %w", err)
+	}
+	if task.BlockedBy, err = decodeIDs(blockedBy); err != nil {
+		return Domain.Task{}, fmt.Errorf("failed to decode task blockers: %w", err)
+	}
+	task.DueDate = time.UnixMilli(dueMs)
+	if deletedMs.Valid {
+		task.DeletedAt = time.UnixMilli(deletedMs.Int64)
+		task.DeletedBy = Domain.ID(deletedBy)
+	}
+	return task, nil
+}
+
+// encodeIDs stores a list of IDs as a JSON array
+func encodeIDs(ids []Domain.ID) (string, error) {
+	if ids == nil {
+		ids = []Domain.ID{}
+	}
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode IDs: %w", err)
+	}
+	return string(b), nil
+}
+
+// decodeIDs reads a JSON array written by encodeIDs, an empty array maps to nil
+func decodeIDs(s string) ([]Domain.ID, error) {
+	var ids []Domain.ID
+	if err := json.Unmarshal([]byte(s), &ids); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	return ids, nil
+}
+
+// encodeLabels stores a list of labels as a JSON array
+func encodeLabels(labels []string) (string, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode labels: %w", err)
+	}
+	return string(b), nil
+}
+
+// decodeLabels reads a JSON array written by encodeLabels, an empty array maps to nil
+func decodeLabels(s string) ([]string, error) {
+	var labels []string
+	if err := json.Unmarshal([]byte(s), &labels); err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
+
+// escapeLike escapes the LIKE wildcards so user input is matched literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,93 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+)
+
+// SQLTokenRepository implements TokenRepository on top of database/sql.
+// Expired rows are deleted whenever a new one is written.
+type SQLTokenRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLTokenRepository creates a new SQLTokenRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLTokenRepository(db *sql.DB, dialect SQLDialect) *SQLTokenRepository {
+	return &SQLTokenRepository{db: db, dialect: dialect}
+}
+
+func (r *SQLTokenRepository) SaveRefreshToken(ctx context.Context, token Domain.RefreshToken) error {
+	if err := r.purgeExpired(ctx, "refresh_tokens"); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO refresh_tokens (token_hash, user_id, expires_at) VALUES (?, ?, ?)`),
+		token.TokenHash, token.UserID.String(), token.ExpiresAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken uses DELETE ... RETURNING so two concurrent refreshes cannot both succeed
+func (r *SQLTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*Domain.RefreshToken, error) {
+	var expiresMs int64
+	token := Domain.RefreshToken{TokenHash: tokenHash}
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`DELETE FROM refresh_tokens WHERE token_hash = ? RETURNING user_id, expires_at`), tokenHash)
+	err := row.Scan(&token.UserID, &expiresMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+	token.ExpiresAt = time.UnixMilli(expiresMs)
+	return &token, nil
+}
+
+func (r *SQLTokenRepository) DeleteRefreshToken(ctx context.Context, tokenHash string, userID Domain.ID) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM refresh_tokens WHERE token_hash = ? AND user_id = ?`),
+		tokenHash, userID.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	if err := r.purgeExpired(ctx, "revoked_tokens"); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?)`),
+		jti, expiresAt.UnixMilli())
+	// Revoking twice is fine
+	if err != nil && !isUniqueViolation(err) {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var found int
+	err := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT 1 FROM revoked_tokens WHERE jti = ?`), jti).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return true, nil
+}
+
+func (r *SQLTokenRepository) purgeExpired(ctx context.Context, table string) error {
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM `+table+` WHERE expires_at <= ?`), time.Now().UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to purge expired tokens: %w", err)
+	}
+	return nil
+}
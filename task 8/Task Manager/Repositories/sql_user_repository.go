@@ -0,0 +1,117 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+)
+
+// SQLUserRepository implements UserRepository on top of database/sql.
+// It works with SQLite and PostgreSQL, see SQLDialect.
+type SQLUserRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLUserRepository creates a new SQLUserRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLUserRepository(db *sql.DB, dialect SQLDialect) *SQLUserRepository {
+	return &SQLUserRepository{db: db, dialect: dialect}
+}
+
+const userColumns = `id, username, password, email, role, team`
+
+// The unique email index as reported by uniqueViolation: its name on PostgreSQL, its column on SQLite
+const (
+	postgresEmailIndex = "idx_users_email"
+	sqliteEmailColumn  = "users.email"
+)
+
+func (r *SQLUserRepository) RegisterUser(ctx context.Context, user Domain.User) error {
+	user.ID = Domain.NewID()
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO users (`+userColumns+`) VALUES (?, ?, ?, ?, ?, ?)`),
+		user.ID.String(), user.Username, user.Password, user.Email, user.Role, user.Team)
+	if err != nil {
+		if constraint, ok := uniqueViolation(err); ok {
+			if constraint == postgresEmailIndex || constraint == sqliteEmailColumn {
+				return Domain.ErrEmailTaken
+			}
+			return Domain.ErrUsernameTaken
+		}
+		return fmt.Errorf("failed to insert user: %w", err)
+	}
+	return nil
+}
+
+func (r *SQLUserRepository) GetUserByUsername(ctx context.Context, username string) (*Domain.User, error) {
+	return r.findUser(ctx, `username = ?`, username)
+}
+
+func (r *SQLUserRepository) GetUserByID(ctx context.Context, id Domain.ID) (*Domain.User, error) {
+	return r.findUser(ctx, `id = ?`, id.String())
+}
+
+// ListUsers returns every user in creation order
+func (r *SQLUserRepository) ListUsers(ctx context.Context) ([]Domain.User, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []Domain.User
+	for rows.Next() {
+		var user Domain.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role, &user.Team); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return users, nil
+}
+
+func (r *SQLUserRepository) UpdateUserRole(ctx context.Context, id Domain.ID, role string) (*Domain.User, error) {
+	return r.setUserColumn(ctx, id, "role", role)
+}
+
+func (r *SQLUserRepository) UpdateUserTeam(ctx context.Context, id Domain.ID, team string) (*Domain.User, error) {
+	return r.setUserColumn(ctx, id, "team", team)
+}
+
+// setUserColumn updates a single column; column is never user input
+func (r *SQLUserRepository) setUserColumn(ctx context.Context, id Domain.ID, column string, value interface{}) (*Domain.User, error) {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`UPDATE users SET `+column+` = ? WHERE id = ?`), value, id.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if err := expectOneRow(res, Domain.ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	return r.GetUserByID(ctx, id)
+}
+
+func (r *SQLUserRepository) DeleteUser(ctx context.Context, id Domain.ID) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM users WHERE id = ?`), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return expectOneRow(res, Domain.ErrUserNotFound)
+}
+
+func (r *SQLUserRepository) findUser(ctx context.Context, where string, arg interface{}) (*Domain.User, error) {
+	var user Domain.User
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+userColumns+` FROM users WHERE `+where), arg)
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role, &user.Team)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
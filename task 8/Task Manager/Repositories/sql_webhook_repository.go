@@ -0,0 +1,169 @@
+package Repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+)
+
+// SQLWebhookRepository implements WebhookRepository on top of database/sql
+type SQLWebhookRepository struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQLWebhookRepository creates a new SQLWebhookRepository.
+// The schema must have been created with MigrateSQL.
+func NewSQLWebhookRepository(db *sql.DB, dialect SQLDialect) *SQLWebhookRepository {
+	return &SQLWebhookRepository{db: db, dialect: dialect}
+}
+
+const (
+	webhookColumns    = `id, url, secret, events, created_by, created_at`
+	deadLetterColumns = `id, subscription_id, url, event_id, event_type, payload, attempts, last_error, failed_at`
+)
+
+func (r *SQLWebhookRepository) AddSubscription(ctx context.Context, sub Domain.WebhookSubscription) (*Domain.WebhookSubscription, error) {
+	sub.ID = Domain.NewID()
+	sub.CreatedAt = roundToMillis(sub.CreatedAt)
+	events, err := encodeLabels(eventTypesToStrings(sub.Events))
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO webhook_subscriptions (`+webhookColumns+`) VALUES (?, ?, ?, ?, ?, ?)`),
+		sub.ID.String(), sub.URL, sub.Secret, events, sub.CreatedBy.String(), sub.CreatedAt.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook subscription: %w", err)
+	}
+	return &sub, nil
+}
+
+func (r *SQLWebhookRepository) GetSubscription(ctx context.Context, id Domain.ID) (*Domain.WebhookSubscription, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+webhookColumns+` FROM webhook_subscriptions WHERE id = ?`), id.String())
+	sub, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscription: %w", err)
+	}
+	return sub, nil
+}
+
+func (r *SQLWebhookRepository) ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+webhookColumns+` FROM webhook_subscriptions ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []Domain.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook subscription: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+func (r *SQLWebhookRepository) DeleteSubscription(ctx context.Context, id Domain.ID) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM webhook_subscriptions WHERE id = ?`), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	return expectOneRow(res, Domain.ErrWebhookNotFound)
+}
+
+func (r *SQLWebhookRepository) AddDeadLetter(ctx context.Context, letter Domain.WebhookDeadLetter) (*Domain.WebhookDeadLetter, error) {
+	letter.ID = Domain.NewID()
+	letter.FailedAt = roundToMillis(letter.FailedAt)
+	_, err := r.db.ExecContext(ctx, r.dialect.rebind(`INSERT INTO webhook_dead_letters (`+deadLetterColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		letter.ID.String(), letter.SubscriptionID.String(), letter.URL, letter.EventID.String(), string(letter.EventType),
+		string(letter.Payload), letter.Attempts, letter.LastError, letter.FailedAt.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+	return &letter, nil
+}
+
+func (r *SQLWebhookRepository) GetDeadLetter(ctx context.Context, id Domain.ID) (*Domain.WebhookDeadLetter, error) {
+	row := r.db.QueryRowContext(ctx, r.dialect.rebind(`SELECT `+deadLetterColumns+` FROM webhook_dead_letters WHERE id = ?`), id.String())
+	letter, err := scanDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, Domain.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead letter: %w", err)
+	}
+	return letter, nil
+}
+
+func (r *SQLWebhookRepository) ListDeadLetters(ctx context.Context) ([]Domain.WebhookDeadLetter, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+deadLetterColumns+` FROM webhook_dead_letters ORDER BY failed_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var letters []Domain.WebhookDeadLetter
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead letter: %w", err)
+		}
+		letters = append(letters, *letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+func (r *SQLWebhookRepository) DeleteDeadLetter(ctx context.Context, id Domain.ID) error {
+	res, err := r.db.ExecContext(ctx, r.dialect.rebind(`DELETE FROM webhook_dead_letters WHERE id = ?`), id.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	return expectOneRow(res, Domain.ErrDeadLetterNotFound)
+}
+
+func scanWebhook(row rowScanner) (*Domain.WebhookSubscription, error) {
+	var (
+		sub       Domain.WebhookSubscription
+		events    string
+		createdMs int64
+	)
+	if err := row.Scan(&sub.ID, &sub.URL, &sub.Secret, &events, &sub.CreatedBy, &createdMs); err != nil {
+		return nil, err
+	}
+	names, err := decodeLabels(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode webhook events: %w", err)
+	}
+	sub.Events = eventTypesFromStrings(names)
+	sub.CreatedAt = time.UnixMilli(createdMs)
+	return &sub, nil
+}
+
+func scanDeadLetter(row rowScanner) (*Domain.WebhookDeadLetter, error) {
+	var (
+		letter   Domain.WebhookDeadLetter
+		payload  string
+		failedMs int64
+	)
+	if err := row.Scan(&letter.ID, &letter.SubscriptionID, &letter.URL, &letter.EventID, &letter.EventType,
+		&payload, &letter.Attempts, &letter.LastError, &failedMs); err != nil {
+		return nil, err
+	}
+	letter.Payload = []byte(payload)
+	letter.FailedAt = time.UnixMilli(failedMs)
+	return &letter, nil
+}
@@ -0,0 +1,42 @@
+package Repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"taskmanager/Domain"
+)
+
+// taskCursor is the position of the last task on a page.
+// It is serialised into the opaque token handed out to clients.
+type taskCursor struct {
+	Sort  Domain.TaskSort `json:"s"`
+	ID    Domain.ID       `json:"id"`
+	DueMs int64           `json:"d,omitempty"` // due date in milliseconds, matching BSON DateTime precision
+	Title string          `json:"t,omitempty"`
+}
+
+// encodeTaskCursor builds the token pointing just after the given task.
+func encodeTaskCursor(sort Domain.TaskSort, task Domain.Task) string {
+	c := taskCursor{Sort: sort, ID: task.ID}
+	switch sort {
+	case Domain.SortByDueDate, Domain.SortByDueDateDesc:
+		c.DueMs = task.DueDate.UnixMilli()
+	case Domain.SortByTitle:
+		c.Title = task.Title
+	}
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTaskCursor parses a token and checks it was issued for the same sort order.
+func decodeTaskCursor(token string, sort Domain.TaskSort) (*taskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, Domain.ErrInvalidCursor
+	}
+	var c taskCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID.IsZero() || c.Sort != sort {
+		return nil, Domain.ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -4,53 +4,128 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"regexp"
 	"taskmanager/Domain"
+	"time"
 
 	// import "go.mongodb.org/mongo-driver/bson/primitive"
 
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	// MongoTaskRepository implements TaskRepository using MongoDB
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// TaskEntity is the persistence model for Task with BSON tags and ObjectID
+// TaskEntity is the persistence model for Task with BSON/JSON tags and ObjectID
 type TaskEntity struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty"`
-	Title       string             `bson:"title"`
-	Description string             `bson:"description"`
-	DueDate     primitive.DateTime `bson:"due_date"`
-	Status      string             `bson:"status"`
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OwnerID     primitive.ObjectID `bson:"owner_id" json:"owner_id"`
+	Team        string             `bson:"team,omitempty" json:"team,omitempty"`
+	Title       string             `bson:"title" json:"title"`
+	Description string             `bson:"description" json:"description"`
+	DueDate     primitive.DateTime `bson:"due_date" json:"due_date"`
+	Status      string             `bson:"status" json:"status"`
+	Priority    string             `bson:"priority,omitempty" json:"priority,omitempty"`
+	// Not omitempty, so removing every label clears the stored list
+	Labels []string `bson:"labels" json:"labels,omitempty"`
+	// Not omitempty, so unassigning everyone clears the stored list
+	AssigneeIDs []primitive.ObjectID `bson:"assignee_ids" json:"assignee_ids,omitempty"`
+	// Not omitempty either, a top level task stores the nil ObjectID
+	ParentID  primitive.ObjectID   `bson:"parent_id" json:"parent_id,omitempty"`
+	BlockedBy []primitive.ObjectID `bson:"blocked_by" json:"blocked_by,omitempty"`
+	// Not omitempty, completing a recurring task clears its rule
+	Recurrence string `bson:"recurrence" json:"recurrence,omitempty"`
+	Occurrence int    `bson:"occurrence,omitempty" json:"occurrence,omitempty"`
+	Version    int64  `bson:"version" json:"version"`
+	// Set while the task is in the trash, see notDeleted
+	DeletedAt *primitive.DateTime `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy primitive.ObjectID  `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
 }
 
+// notDeleted matches the tasks that are not in the trash, documents without deleted_at included
+var notDeleted = bson.M{"deleted_at": nil}
+
+// inTrash matches the soft deleted tasks
+var inTrash = bson.M{"deleted_at": bson.M{"$ne": nil}}
+
 // ToDomain converts TaskEntity to Domain.Task
 func (te *TaskEntity) ToDomain() Domain.Task {
-	return Domain.Task{
-		ID:          te.ID,
+	task := Domain.Task{
+		ID:          DomainIDFromObjectID(te.ID),
+		OwnerID:     DomainIDFromObjectID(te.OwnerID),
+		Team:        te.Team,
 		Title:       te.Title,
 		Description: te.Description,
 		DueDate:     te.DueDate.Time(),
-		Status:      te.Status,
+		Status:      Domain.TaskStatus(te.Status),
+		Priority:    Domain.TaskPriority(te.Priority),
+		Labels:      te.Labels,
+		AssigneeIDs: domainIDsFromObjectIDs(te.AssigneeIDs),
+		ParentID:    DomainIDFromObjectID(te.ParentID),
+		BlockedBy:   domainIDsFromObjectIDs(te.BlockedBy),
+		Recurrence:  te.Recurrence,
+		Occurrence:  te.Occurrence,
+		Version:     te.Version,
+		DeletedBy:   DomainIDFromObjectID(te.DeletedBy),
+	}
+	if te.DeletedAt != nil {
+		task.DeletedAt = te.DeletedAt.Time()
 	}
+	return task
 }
 
 // FromDomain converts Domain.Task to TaskEntity
 func FromDomain(task Domain.Task) TaskEntity {
-	return TaskEntity{
-		ID:          task.ID, // Use domain model ID directly
+	te := TaskEntity{
+		ID:          objectIDOrNil(task.ID),
+		OwnerID:     objectIDOrNil(task.OwnerID),
+		Team:        task.Team,
 		Title:       task.Title,
 		Description: task.Description,
 		DueDate:     primitive.NewDateTimeFromTime(task.DueDate),
-		Status:      task.Status,
+		Status:      string(task.Status),
+		Priority:    string(task.Priority),
+		Labels:      task.Labels,
+		AssigneeIDs: objectIDsOrNil(task.AssigneeIDs),
+		ParentID:    objectIDOrNil(task.ParentID),
+		BlockedBy:   objectIDsOrNil(task.BlockedBy),
+		Recurrence:  task.Recurrence,
+		Occurrence:  task.Occurrence,
+		Version:     task.Version,
+		DeletedBy:   objectIDOrNil(task.DeletedBy),
 	}
+	if task.IsDeleted() {
+		deletedAt := primitive.NewDateTimeFromTime(task.DeletedAt)
+		te.DeletedAt = &deletedAt
+	}
+	return te
 }
 
 type TaskRepository interface {
 	GetAllTasks(ctx context.Context) ([]Domain.Task, error)
-	GetTaskByID(ctx context.Context, id primitive.ObjectID) (*Domain.Task, error)
+	GetTasksByOwner(ctx context.Context, ownerID Domain.ID) ([]Domain.Task, error)
+	// GetSubtasks returns the live tasks whose parent is parentID, in creation order
+	GetSubtasks(ctx context.Context, parentID Domain.ID) ([]Domain.Task, error)
+	ListTasks(ctx context.Context, query Domain.TaskQuery) (*Domain.TaskPage, error)
+	// CountLabels counts the tasks matching the query per label, most used first and ties by label.
+	// Sort, Limit and Cursor are ignored.
+	CountLabels(ctx context.Context, query Domain.TaskQuery) ([]Domain.LabelCount, error)
+	// GetTaskByID returns Domain.ErrTaskNotFound for a task in the trash, like every other read
+	GetTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error)
 	AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error)
+	// UpdateTask replaces the task if its stored version still equals task.Version and stores
+	// it with the next version. It returns Domain.ErrVersionConflict if the version differs.
 	UpdateTask(ctx context.Context, task Domain.Task) (*Domain.Task, error)
-	DeleteTask(ctx context.Context, id primitive.ObjectID) error
+	// DeleteTask moves the task to the trash, recording who deleted it and when
+	DeleteTask(ctx context.Context, id Domain.ID, deletedBy Domain.ID, at time.Time) error
+	// GetDeletedTaskByID returns a task in the trash
+	GetDeletedTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error)
+	// RestoreTask takes a task out of the trash
+	RestoreTask(ctx context.Context, id Domain.ID) (*Domain.Task, error)
+	// PurgeTask removes a task in the trash for good
+	PurgeTask(ctx context.Context, id Domain.ID) error
 }
 
 type MongoTaskRepository struct {
@@ -86,14 +161,209 @@ type Collection interface {
 	InsertOne(context.Context, interface{}, ...interface{}) (*InsertOneResult, error)
 	FindOneAndUpdate(context.Context, interface{}, interface{}, ...interface{}) SingleResult
 	DeleteOne(context.Context, interface{}, ...interface{}) (DeleteResult, error)
+	Aggregate(context.Context, interface{}, ...interface{}) (Cursor, error)
+	CreateIndexes(context.Context, []mongo.IndexModel) error
+	// UpdateMany returns the number of documents it changed
+	UpdateMany(context.Context, interface{}, interface{}) (int64, error)
 }
 
 func NewMongoTaskRepository(collection Collection) *MongoTaskRepository {
 	return &MongoTaskRepository{collection: collection}
 }
 
+// EnsureIndexes creates the indexes used to filter tasks by label and by priority
+func (r *MongoTaskRepository) EnsureIndexes(ctx context.Context) error {
+	err := r.collection.CreateIndexes(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "labels", Value: 1}}},
+		{Keys: bson.D{{Key: "priority", Value: 1}}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create task indexes: %w", err)
+	}
+	return nil
+}
+
+// BackfillVersions gives the tasks stored before versioning version 1, like new tasks get.
+// Until then they count as version 0, which an If-Match header cannot name since 0 means any version.
+func (r *MongoTaskRepository) BackfillVersions(ctx context.Context) error {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"version": 0},
+		bson.M{"version": bson.M{"$exists": false}},
+	}}
+	n, err := r.collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"version": 1}})
+	if err != nil {
+		return fmt.Errorf("failed to backfill task versions: %w", err)
+	}
+	if n > 0 {
+		log.Printf("Backfilled the version of %d tasks", n)
+	}
+	return nil
+}
+
 func (r *MongoTaskRepository) GetAllTasks(ctx context.Context) ([]Domain.Task, error) {
-	cursor, err := r.collection.Find(ctx, bson.D{})
+	return r.findTasks(ctx, notDeleted)
+}
+
+// GetTasksByOwner returns only the tasks created by the given user
+func (r *MongoTaskRepository) GetTasksByOwner(ctx context.Context, ownerID Domain.ID) ([]Domain.Task, error) {
+	return r.findTasks(ctx, bson.M{"owner_id": objectIDOrNil(ownerID), "deleted_at": nil})
+}
+
+func (r *MongoTaskRepository) GetSubtasks(ctx context.Context, parentID Domain.ID) ([]Domain.Task, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	return r.findTasks(ctx, bson.M{"parent_id": objectIDOrNil(parentID), "deleted_at": nil}, opts)
+}
+
+// ListTasks returns one page of tasks matching the query.
+// Pagination is keyset based: the cursor holds the sort key and ID of the last task
+// on the previous page, so pages stay stable while tasks are added or removed.
+func (r *MongoTaskRepository) ListTasks(ctx context.Context, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	filter, err := taskQueryFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	opts := options.Find().
+		SetSort(taskQuerySort(query.Sort)).
+		SetLimit(int64(query.Limit) + 1) // one extra to know whether another page exists
+
+	tasks, err := r.findTasks(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Domain.TaskPage{Tasks: tasks}
+	if len(tasks) > query.Limit {
+		page.Tasks = tasks[:query.Limit]
+		page.NextCursor = encodeTaskCursor(query.Sort, page.Tasks[query.Limit-1])
+	}
+	return page, nil
+}
+
+// CountLabels unwinds the labels of the matching tasks and groups them in an aggregation pipeline
+func (r *MongoTaskRepository) CountLabels(ctx context.Context, query Domain.TaskQuery) ([]Domain.LabelCount, error) {
+	query.Cursor = ""
+	filter, err := taskQueryFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$unwind", Value: "$labels"}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$labels"}, {Key: "count", Value: bson.M{"$sum": 1}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count labels: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var counts []Domain.LabelCount
+	for cursor.Next(ctx) {
+		var row struct {
+			Label string `bson:"_id"`
+			Count int    `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, fmt.Errorf("error decoding label count: %w", err)
+		}
+		counts = append(counts, Domain.LabelCount{Label: row.Label, Count: row.Count})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+	return counts, nil
+}
+
+// taskQueryFilter translates a TaskQuery into a MongoDB filter document
+func taskQueryFilter(query Domain.TaskQuery) (bson.D, error) {
+	var conds bson.A
+	switch {
+	case !query.OwnerID.IsZero() && query.Team != "":
+		conds = append(conds, bson.M{"$or": bson.A{
+			bson.M{"owner_id": objectIDOrNil(query.OwnerID)},
+			bson.M{"team": query.Team},
+		}})
+	case !query.OwnerID.IsZero():
+		conds = append(conds, bson.M{"owner_id": objectIDOrNil(query.OwnerID)})
+	case query.Team != "":
+		conds = append(conds, bson.M{"team": query.Team})
+	}
+	if !query.AssigneeID.IsZero() {
+		conds = append(conds, bson.M{"assignee_ids": objectIDOrNil(query.AssigneeID)})
+	}
+	if query.Status != "" {
+		conds = append(conds, bson.M{"status": string(query.Status)})
+	}
+	if query.Priority != "" {
+		conds = append(conds, bson.M{"priority": string(query.Priority)})
+	}
+	if query.Label != "" {
+		conds = append(conds, bson.M{"labels": query.Label})
+	}
+	if !query.DueAfter.IsZero() {
+		conds = append(conds, bson.M{"due_date": bson.M{"$gte": primitive.NewDateTimeFromTime(query.DueAfter)}})
+	}
+	if !query.DueBefore.IsZero() {
+		conds = append(conds, bson.M{"due_date": bson.M{"$lte": primitive.NewDateTimeFromTime(query.DueBefore)}})
+	}
+	if query.Title != "" {
+		conds = append(conds, bson.M{"title": bson.M{"$regex": regexp.QuoteMeta(query.Title), "$options": "i"}})
+	}
+	if query.Cursor != "" {
+		c, err := decodeTaskCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, taskCursorFilter(c))
+	}
+	if query.Deleted {
+		conds = append(conds, inTrash)
+	} else {
+		conds = append(conds, notDeleted)
+	}
+	return bson.D{{Key: "$and", Value: conds}}, nil
+}
+
+// taskCursorFilter matches the tasks that sort strictly after the cursor position
+func taskCursorFilter(c *taskCursor) bson.M {
+	switch c.Sort {
+	case Domain.SortByDueDate, Domain.SortByDueDateDesc:
+		op := "$gt"
+		if c.Sort == Domain.SortByDueDateDesc {
+			op = "$lt"
+		}
+		due := primitive.DateTime(c.DueMs)
+		return bson.M{"$or": bson.A{
+			bson.M{"due_date": bson.M{op: due}},
+			bson.M{"due_date": due, "_id": bson.M{op: objectIDOrNil(c.ID)}},
+		}}
+	case Domain.SortByTitle:
+		return bson.M{"$or": bson.A{
+			bson.M{"title": bson.M{"$gt": c.Title}},
+			bson.M{"title": c.Title, "_id": bson.M{"$gt": objectIDOrNil(c.ID)}},
+		}}
+	default:
+		return bson.M{"_id": bson.M{"$gt": objectIDOrNil(c.ID)}}
+	}
+}
+
+// taskQuerySort returns the sort document for a TaskSort, using _id as a tie breaker
+func taskQuerySort(sort Domain.TaskSort) bson.D {
+	switch sort {
+	case Domain.SortByDueDate:
+		return bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}
+	case Domain.SortByDueDateDesc:
+		return bson.D{{Key: "due_date", Value: -1}, {Key: "_id", Value: -1}}
+	case Domain.SortByTitle:
+		return bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}
+	default:
+		return bson.D{{Key: "_id", Value: 1}}
+	}
+}
+
+func (r *MongoTaskRepository) findTasks(ctx context.Context, filter interface{}, opts ...interface{}) ([]Domain.Task, error) {
+	cursor, err := r.collection.Find(ctx, filter, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find documents: %w", err)
 	}
@@ -120,11 +390,22 @@ func (r *MongoTaskRepository) GetAllTasks(ctx context.Context) ([]Domain.Task, e
 	return tasks, nil
 }
 
-func (r *MongoTaskRepository) GetTaskByID(ctx context.Context, id primitive.ObjectID) (*Domain.Task, error) {
+func (r *MongoTaskRepository) GetTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	return r.findTask(ctx, bson.M{"_id": objectIDOrNil(id), "deleted_at": nil})
+}
+
+func (r *MongoTaskRepository) GetDeletedTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	return r.findTask(ctx, bson.M{"_id": objectIDOrNil(id), "deleted_at": bson.M{"$ne": nil}})
+}
+
+func (r *MongoTaskRepository) findTask(ctx context.Context, filter bson.M) (*Domain.Task, error) {
 	var taskEntity TaskEntity
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&taskEntity)
+	err := r.collection.FindOne(ctx, filter).Decode(&taskEntity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrTaskNotFound
+	}
 	if err != nil {
-		return nil, errors.New("task not found")
+		return nil, fmt.Errorf("failed to find task: %w", err)
 	}
 	task := taskEntity.ToDomain()
 	return &task, nil
@@ -133,9 +414,10 @@ func (r *MongoTaskRepository) GetTaskByID(ctx context.Context, id primitive.Obje
 func (r *MongoTaskRepository) AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
 	taskEntity := FromDomain(task)
 	taskEntity.ID = primitive.NilObjectID
+	taskEntity.Version = 1
 	res, err := r.collection.InsertOne(ctx, taskEntity)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to insert task: %w", err)
 	}
 	id, ok := res.InsertedID.(primitive.ObjectID)
 	if !ok {
@@ -148,25 +430,76 @@ func (r *MongoTaskRepository) AddTask(ctx context.Context, task Domain.Task) (*D
 
 func (r *MongoTaskRepository) UpdateTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
 	taskEntity := FromDomain(task)
-	filter := bson.M{"_id": taskEntity.ID}
+	filter := bson.M{"_id": taskEntity.ID, "version": taskEntity.Version, "deleted_at": nil}
+	if taskEntity.Version == 0 {
+		// Documents written before versioning have no version field, they count as version 0
+		filter = bson.M{"_id": taskEntity.ID, "deleted_at": nil, "$or": bson.A{
+			bson.M{"version": 0},
+			bson.M{"version": bson.M{"$exists": false}},
+		}}
+	}
+	taskEntity.Version++
 	update := bson.M{"$set": taskEntity}
 	var updatedTaskEntity TaskEntity
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updatedTaskEntity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Either the task is gone or its version moved on
+		if _, err := r.GetTaskByID(ctx, task.ID); err != nil {
+			return nil, err
+		}
+		return nil, Domain.ErrVersionConflict
+	}
 	if err != nil {
-		return nil, errors.New("task not found")
+		return nil, fmt.Errorf("failed to update task: %w", err)
 	}
 	updatedTask := updatedTaskEntity.ToDomain()
 	return &updatedTask, nil
 }
 
-func (r *MongoTaskRepository) DeleteTask(ctx context.Context, id primitive.ObjectID) error {
-	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+func (r *MongoTaskRepository) DeleteTask(ctx context.Context, id Domain.ID, deletedBy Domain.ID, at time.Time) error {
+	filter := bson.M{"_id": objectIDOrNil(id), "deleted_at": nil}
+	update := bson.M{
+		"$set": bson.M{"deleted_at": primitive.NewDateTimeFromTime(at), "deleted_by": objectIDOrNil(deletedBy)},
+		"$inc": bson.M{"version": 1},
+	}
+	var taskEntity TaskEntity
+	err := r.collection.FindOneAndUpdate(ctx, filter, update).Decode(&taskEntity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return Domain.ErrTaskNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoTaskRepository) RestoreTask(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	filter := bson.M{"_id": objectIDOrNil(id), "deleted_at": bson.M{"$ne": nil}}
+	update := bson.M{
+		"$unset": bson.M{"deleted_at": "", "deleted_by": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	var taskEntity TaskEntity
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&taskEntity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore task: %w", err)
+	}
+	task := taskEntity.ToDomain()
+	return &task, nil
+}
+
+func (r *MongoTaskRepository) PurgeTask(ctx context.Context, id Domain.ID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectIDOrNil(id), "deleted_at": bson.M{"$ne": nil}})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to purge task: %w", err)
 	}
 	if res.DeletedCount() == 0 {
-		return errors.New("task not found")
+		return Domain.ErrTaskNotFound
 	}
 	return nil
 }
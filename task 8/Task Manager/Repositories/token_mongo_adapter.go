@@ -0,0 +1,50 @@
+package Repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type TokenMongoCollectionAdapter struct {
+	Coll *mongo.Collection
+}
+
+func (a *TokenMongoCollectionAdapter) InsertOne(ctx context.Context, document interface{}, opts ...interface{}) (interface{}, error) {
+	var mongoOpts []*options.InsertOneOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.InsertOneOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.InsertOne(ctx, document, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &InsertOneResult{InsertedID: res.InsertedID}, nil
+}
+
+func (a *TokenMongoCollectionAdapter) FindOne(ctx context.Context, filter interface{}, opts ...interface{}) SingleResult {
+	var mongoOpts []*options.FindOneOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOneOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	return a.Coll.FindOne(ctx, filter, mongoOpts...)
+}
+
+func (a *TokenMongoCollectionAdapter) FindOneAndDelete(ctx context.Context, filter interface{}) SingleResult {
+	return a.Coll.FindOneAndDelete(ctx, filter)
+}
+
+func (a *TokenMongoCollectionAdapter) DeleteOne(ctx context.Context, filter interface{}) error {
+	_, err := a.Coll.DeleteOne(ctx, filter)
+	return err
+}
+
+func (a *TokenMongoCollectionAdapter) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := a.Coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -0,0 +1,146 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TokenRepository stores refresh tokens and the IDs (jti) of revoked access tokens
+type TokenRepository interface {
+	SaveRefreshToken(ctx context.Context, token Domain.RefreshToken) error
+	// ConsumeRefreshToken atomically removes and returns the refresh token with the given hash,
+	// so a token can be exchanged only once. It returns Domain.ErrInvalidRefreshToken if there is none.
+	ConsumeRefreshToken(ctx context.Context, tokenHash string) (*Domain.RefreshToken, error)
+	// DeleteRefreshToken removes the refresh token if it belongs to the user. Unknown tokens are ignored.
+	DeleteRefreshToken(ctx context.Context, tokenHash string, userID Domain.ID) error
+	// RevokeAccessToken marks the jti as revoked until the access token expires on its own
+	RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// RefreshTokenEntity is the persistence model for Domain.RefreshToken, keyed by the token hash
+type RefreshTokenEntity struct {
+	TokenHash string             `bson:"_id" json:"token_hash"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	ExpiresAt primitive.DateTime `bson:"expires_at" json:"expires_at"`
+}
+
+// ToDomain converts RefreshTokenEntity to Domain.RefreshToken
+func (e *RefreshTokenEntity) ToDomain() Domain.RefreshToken {
+	return Domain.RefreshToken{
+		TokenHash: e.TokenHash,
+		UserID:    DomainIDFromObjectID(e.UserID),
+		ExpiresAt: e.ExpiresAt.Time(),
+	}
+}
+
+// RefreshTokenFromDomain converts Domain.RefreshToken to RefreshTokenEntity
+func RefreshTokenFromDomain(token Domain.RefreshToken) RefreshTokenEntity {
+	return RefreshTokenEntity{
+		TokenHash: token.TokenHash,
+		UserID:    objectIDOrNil(token.UserID),
+		ExpiresAt: primitive.NewDateTimeFromTime(token.ExpiresAt),
+	}
+}
+
+// RevokedTokenEntity records a revoked access token, keyed by its jti
+type RevokedTokenEntity struct {
+	JTI       string             `bson:"_id"`
+	ExpiresAt primitive.DateTime `bson:"expires_at"`
+}
+
+// TokenCollection defines the minimal collection interface for the token repository
+type TokenCollection interface {
+	InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (interface{}, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...interface{}) SingleResult
+	FindOneAndDelete(ctx context.Context, filter interface{}) SingleResult
+	DeleteOne(ctx context.Context, filter interface{}) error
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
+}
+
+// MongoTokenRepository implements TokenRepository using two MongoDB collections,
+// one for refresh tokens and one for revoked access tokens
+type MongoTokenRepository struct {
+	refreshTokens TokenCollection
+	revokedTokens TokenCollection
+}
+
+// NewMongoTokenRepository creates a new MongoTokenRepository
+func NewMongoTokenRepository(refreshTokens, revokedTokens TokenCollection) *MongoTokenRepository {
+	return &MongoTokenRepository{refreshTokens: refreshTokens, revokedTokens: revokedTokens}
+}
+
+// EnsureIndexes creates TTL indexes so MongoDB drops expired tokens by itself
+func (r *MongoTokenRepository) EnsureIndexes(ctx context.Context) error {
+	ttl := []mongo.IndexModel{{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}}
+	if err := r.refreshTokens.CreateIndexes(ctx, ttl); err != nil {
+		return fmt.Errorf("failed to create refresh token indexes: %w", err)
+	}
+	if err := r.revokedTokens.CreateIndexes(ctx, ttl); err != nil {
+		return fmt.Errorf("failed to create revoked token indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoTokenRepository) SaveRefreshToken(ctx context.Context, token Domain.RefreshToken) error {
+	if _, err := r.refreshTokens.InsertOne(ctx, RefreshTokenFromDomain(token)); err != nil {
+		return fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoTokenRepository) ConsumeRefreshToken(ctx context.Context, tokenHash string) (*Domain.RefreshToken, error) {
+	var entity RefreshTokenEntity
+	err := r.refreshTokens.FindOneAndDelete(ctx, bson.M{"_id": tokenHash}).Decode(&entity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+	token := entity.ToDomain()
+	return &token, nil
+}
+
+func (r *MongoTokenRepository) DeleteRefreshToken(ctx context.Context, tokenHash string, userID Domain.ID) error {
+	err := r.refreshTokens.DeleteOne(ctx, bson.M{"_id": tokenHash, "user_id": objectIDOrNil(userID)})
+	if err != nil {
+		return fmt.Errorf("failed to delete refresh token: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoTokenRepository) RevokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := r.revokedTokens.InsertOne(ctx, RevokedTokenEntity{
+		JTI:       jti,
+		ExpiresAt: primitive.NewDateTimeFromTime(expiresAt),
+	})
+	// Revoking twice is fine
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoTokenRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var entity RevokedTokenEntity
+	err := r.revokedTokens.FindOne(ctx, bson.M{"_id": jti}).Decode(&entity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	return true, nil
+}
@@ -34,3 +34,46 @@ func (a *UserMongoCollectionAdapter) FindOne(ctx context.Context, filter interfa
 	}
 	return a.Coll.FindOne(ctx, filter, mongoOpts...)
 }
+
+func (a *UserMongoCollectionAdapter) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error) {
+	var mongoOpts []*options.FindOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	cursor, err := a.Coll.Find(ctx, filter, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func (a *UserMongoCollectionAdapter) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) SingleResult {
+	var mongoOpts []*options.FindOneAndUpdateOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.FindOneAndUpdateOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	return a.Coll.FindOneAndUpdate(ctx, filter, update, mongoOpts...)
+}
+
+func (a *UserMongoCollectionAdapter) DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error) {
+	var mongoOpts []*options.DeleteOptions
+	for _, o := range opts {
+		if opt, ok := o.(*options.DeleteOptions); ok {
+			mongoOpts = append(mongoOpts, opt)
+		}
+	}
+	res, err := a.Coll.DeleteOne(ctx, filter, mongoOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoDeleteResultAdapter{res}, nil
+}
+
+func (a *UserMongoCollectionAdapter) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	_, err := a.Coll.Indexes().CreateMany(ctx, models)
+	return err
+}
@@ -3,24 +3,69 @@ package Repositories
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"taskmanager/Domain"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	// "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// UserEntity is the persistence model for User with BSON/JSON tags and ObjectID
+type UserEntity struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Username string             `bson:"username" json:"username"`
+	Password string             `bson:"password" json:"password"`
+	Email    string             `bson:"email" json:"email"`
+	Role     string             `bson:"role" json:"role"`
+	Team     string             `bson:"team,omitempty" json:"team,omitempty"`
+}
+
+// ToDomain converts UserEntity to Domain.User
+func (ue *UserEntity) ToDomain() Domain.User {
+	return Domain.User{
+		ID:       DomainIDFromObjectID(ue.ID),
+		Username: ue.Username,
+		Password: ue.Password,
+		Email:    ue.Email,
+		Role:     ue.Role,
+		Team:     ue.Team,
+	}
+}
+
+// UserFromDomain converts Domain.User to UserEntity
+func UserFromDomain(user Domain.User) UserEntity {
+	return UserEntity{
+		ID:       objectIDOrNil(user.ID),
+		Username: user.Username,
+		Password: user.Password,
+		Email:    user.Email,
+		Role:     user.Role,
+		Team:     user.Team,
+	}
+}
+
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	RegisterUser(ctx context.Context, user Domain.User) error
-	AuthenticateUser(ctx context.Context, username, password string) (*Domain.User, error)
-	GetUserByID(ctx context.Context, id primitive.ObjectID) (*Domain.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*Domain.User, error)
+	GetUserByID(ctx context.Context, id Domain.ID) (*Domain.User, error)
+	ListUsers(ctx context.Context) ([]Domain.User, error)
+	UpdateUserRole(ctx context.Context, id Domain.ID, role string) (*Domain.User, error)
+	UpdateUserTeam(ctx context.Context, id Domain.ID, team string) (*Domain.User, error)
+	DeleteUser(ctx context.Context, id Domain.ID) error
 }
 
 // UserCollection defines the minimal collection interface for user repository
 type UserCollection interface {
 	InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (interface{}, error)
 	FindOne(ctx context.Context, filter interface{}, opts ...interface{}) SingleResult
+	Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error)
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) SingleResult
+	DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
 }
 
 // MongoUserRepository implements UserRepository using MongoDB
@@ -33,25 +78,137 @@ func NewMongoUserRepository(collection UserCollection) *MongoUserRepository {
 	return &MongoUserRepository{collection: collection}
 }
 
+// Names of the unique user indexes, the MongoDB defaults for their keys.
+// RegisterUser tells the duplicates apart by them.
+const (
+	usernameIndex = "username_1"
+	emailIndex    = "email_1"
+)
+
+// EnsureIndexes creates the unique indexes on username and email.
+// Email is optional, so only non-empty addresses have to be unique.
+func (r *MongoUserRepository) EnsureIndexes(ctx context.Context) error {
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "username", Value: 1}},
+			Options: options.Index().SetName(usernameIndex).SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName(emailIndex).SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$gt": ""}}),
+		},
+	}
+	if err := r.collection.CreateIndexes(ctx, indexes); err != nil {
+		return fmt.Errorf("failed to create user indexes: %w", err)
+	}
+	return nil
+}
+
+// RegisterUser inserts the user, relying on the unique indexes to reject duplicates
 func (r *MongoUserRepository) RegisterUser(ctx context.Context, user Domain.User) error {
-	_, err := r.collection.InsertOne(ctx, user)
+	_, err := r.collection.InsertOne(ctx, UserFromDomain(user))
+	if mongo.IsDuplicateKeyError(err) {
+		if duplicateKeyIndex(err) == emailIndex {
+			return Domain.ErrEmailTaken
+		}
+		return Domain.ErrUsernameTaken
+	}
 	return err
 }
 
-func (r *MongoUserRepository) AuthenticateUser(ctx context.Context, username, password string) (*Domain.User, error) {
-	var user Domain.User
-	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&user)
+// duplicateKeyIndex returns the name of the unique index that rejected a write, or "" if unknown.
+// The server names it in the message, "E11000 duplicate key error collection: db.users index: email_1 dup key: ...",
+// before the duplicated value.
+func duplicateKeyIndex(err error) string {
+	var writeErr mongo.WriteException
+	if !errors.As(err, &writeErr) {
+		return ""
+	}
+	for _, e := range writeErr.WriteErrors {
+		if e.Code != 11000 {
+			continue
+		}
+		if _, rest, ok := strings.Cut(e.Message, " index: "); ok {
+			name, _, _ := strings.Cut(rest, " ")
+			return name
+		}
+	}
+	return ""
+}
+
+func (r *MongoUserRepository) GetUserByUsername(ctx context.Context, username string) (*Domain.User, error) {
+	return r.findUser(ctx, bson.M{"username": username})
+}
+
+func (r *MongoUserRepository) GetUserByID(ctx context.Context, id Domain.ID) (*Domain.User, error) {
+	return r.findUser(ctx, bson.M{"_id": objectIDOrNil(id)})
+}
+
+// ListUsers returns every user in creation order
+func (r *MongoUserRepository) ListUsers(ctx context.Context) ([]Domain.User, error) {
+	cursor, err := r.collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var users []Domain.User
+	for cursor.Next(ctx) {
+		var userEntity UserEntity
+		if err := cursor.Decode(&userEntity); err != nil {
+			return nil, fmt.Errorf("failed to decode user: %w", err)
+		}
+		users = append(users, userEntity.ToDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+	return users, nil
+}
+
+func (r *MongoUserRepository) UpdateUserRole(ctx context.Context, id Domain.ID, role string) (*Domain.User, error) {
+	return r.setUserField(ctx, id, "role", role)
+}
+
+func (r *MongoUserRepository) UpdateUserTeam(ctx context.Context, id Domain.ID, team string) (*Domain.User, error) {
+	return r.setUserField(ctx, id, "team", team)
+}
+
+func (r *MongoUserRepository) setUserField(ctx context.Context, id Domain.ID, field string, value interface{}) (*Domain.User, error) {
+	var userEntity UserEntity
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectIDOrNil(id)}, bson.M{"$set": bson.M{field: value}}, opts).Decode(&userEntity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrUserNotFound
+	}
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
+	user := userEntity.ToDomain()
 	return &user, nil
 }
 
-func (r *MongoUserRepository) GetUserByID(ctx context.Context, id primitive.ObjectID) (*Domain.User, error) {
-	var user Domain.User
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user)
+func (r *MongoUserRepository) DeleteUser(ctx context.Context, id Domain.ID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectIDOrNil(id)})
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if res.DeletedCount() == 0 {
+		return Domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) findUser(ctx context.Context, filter interface{}) (*Domain.User, error) {
+	var userEntity UserEntity
+	err := r.collection.FindOne(ctx, filter).Decode(&userEntity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrUserNotFound
+	}
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, fmt.Errorf("failed to find user: %w", err)
 	}
+	user := userEntity.ToDomain()
 	return &user, nil
 }
@@ -0,0 +1,279 @@
+package Repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookRepository stores the webhook subscriptions and the deliveries that failed for good
+type WebhookRepository interface {
+	AddSubscription(ctx context.Context, sub Domain.WebhookSubscription) (*Domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id Domain.ID) (*Domain.WebhookSubscription, error)
+	// ListSubscriptions returns every subscription, oldest first
+	ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id Domain.ID) error
+	AddDeadLetter(ctx context.Context, letter Domain.WebhookDeadLetter) (*Domain.WebhookDeadLetter, error)
+	GetDeadLetter(ctx context.Context, id Domain.ID) (*Domain.WebhookDeadLetter, error)
+	// ListDeadLetters returns every dead letter, most recent failure first
+	ListDeadLetters(ctx context.Context) ([]Domain.WebhookDeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id Domain.ID) error
+}
+
+// WebhookEntity is the persistence model for Domain.WebhookSubscription
+type WebhookEntity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	URL       string             `bson:"url" json:"url"`
+	Secret    string             `bson:"secret" json:"secret"`
+	Events    []string           `bson:"events" json:"events"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt primitive.DateTime `bson:"created_at" json:"created_at"`
+}
+
+// ToDomain converts WebhookEntity to Domain.WebhookSubscription
+func (e *WebhookEntity) ToDomain() Domain.WebhookSubscription {
+	return Domain.WebhookSubscription{
+		ID:        DomainIDFromObjectID(e.ID),
+		URL:       e.URL,
+		Secret:    e.Secret,
+		Events:    eventTypesFromStrings(e.Events),
+		CreatedBy: DomainIDFromObjectID(e.CreatedBy),
+		CreatedAt: e.CreatedAt.Time(),
+	}
+}
+
+// WebhookFromDomain converts Domain.WebhookSubscription to WebhookEntity
+func WebhookFromDomain(sub Domain.WebhookSubscription) WebhookEntity {
+	return WebhookEntity{
+		ID:        objectIDOrNil(sub.ID),
+		URL:       sub.URL,
+		Secret:    sub.Secret,
+		Events:    eventTypesToStrings(sub.Events),
+		CreatedBy: objectIDOrNil(sub.CreatedBy),
+		CreatedAt: primitive.NewDateTimeFromTime(sub.CreatedAt),
+	}
+}
+
+// DeadLetterEntity is the persistence model for Domain.WebhookDeadLetter
+type DeadLetterEntity struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubscriptionID primitive.ObjectID `bson:"subscription_id" json:"subscription_id"`
+	URL            string             `bson:"url" json:"url"`
+	EventID        primitive.ObjectID `bson:"event_id" json:"event_id"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	Payload        []byte             `bson:"payload" json:"payload"`
+	Attempts       int                `bson:"attempts" json:"attempts"`
+	LastError      string             `bson:"last_error" json:"last_error"`
+	FailedAt       primitive.DateTime `bson:"failed_at" json:"failed_at"`
+}
+
+// ToDomain converts DeadLetterEntity to Domain.WebhookDeadLetter
+func (e *DeadLetterEntity) ToDomain() Domain.WebhookDeadLetter {
+	return Domain.WebhookDeadLetter{
+		ID:             DomainIDFromObjectID(e.ID),
+		SubscriptionID: DomainIDFromObjectID(e.SubscriptionID),
+		URL:            e.URL,
+		EventID:        DomainIDFromObjectID(e.EventID),
+		EventType:      Domain.TaskEventType(e.EventType),
+		Payload:        e.Payload,
+		Attempts:       e.Attempts,
+		LastError:      e.LastError,
+		FailedAt:       e.FailedAt.Time(),
+	}
+}
+
+// DeadLetterFromDomain converts Domain.WebhookDeadLetter to DeadLetterEntity
+func DeadLetterFromDomain(letter Domain.WebhookDeadLetter) DeadLetterEntity {
+	return DeadLetterEntity{
+		ID:             objectIDOrNil(letter.ID),
+		SubscriptionID: objectIDOrNil(letter.SubscriptionID),
+		URL:            letter.URL,
+		EventID:        objectIDOrNil(letter.EventID),
+		EventType:      string(letter.EventType),
+		Payload:        letter.Payload,
+		Attempts:       letter.Attempts,
+		LastError:      letter.LastError,
+		FailedAt:       primitive.NewDateTimeFromTime(letter.FailedAt),
+	}
+}
+
+// WebhookCollection defines the minimal collection interface for the webhook repository,
+// which uses one collection for the subscriptions and one for the dead letters
+type WebhookCollection interface {
+	InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*InsertOneResult, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...interface{}) SingleResult
+	Find(ctx context.Context, filter interface{}, opts ...interface{}) (Cursor, error)
+	DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (DeleteResult, error)
+	CreateIndexes(ctx context.Context, models []mongo.IndexModel) error
+}
+
+// MongoWebhookRepository implements WebhookRepository using MongoDB
+type MongoWebhookRepository struct {
+	subscriptions WebhookCollection
+	deadLetters   WebhookCollection
+}
+
+// NewMongoWebhookRepository creates a new MongoWebhookRepository
+func NewMongoWebhookRepository(subscriptions, deadLetters WebhookCollection) *MongoWebhookRepository {
+	return &MongoWebhookRepository{subscriptions: subscriptions, deadLetters: deadLetters}
+}
+
+// EnsureIndexes creates the index used to list the dead letters
+func (r *MongoWebhookRepository) EnsureIndexes(ctx context.Context) error {
+	err := r.deadLetters.CreateIndexes(ctx, []mongo.IndexModel{{
+		Keys: bson.D{{Key: "failed_at", Value: -1}, {Key: "_id", Value: -1}},
+	}})
+	if err != nil {
+		return fmt.Errorf("failed to create webhook indexes: %w", err)
+	}
+	return nil
+}
+
+func (r *MongoWebhookRepository) AddSubscription(ctx context.Context, sub Domain.WebhookSubscription) (*Domain.WebhookSubscription, error) {
+	entity := WebhookFromDomain(sub)
+	entity.ID = primitive.NilObjectID
+	res, err := r.subscriptions.InsertOne(ctx, entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert webhook subscription: %w", err)
+	}
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+	entity.ID = id
+	created := entity.ToDomain()
+	return &created, nil
+}
+
+func (r *MongoWebhookRepository) GetSubscription(ctx context.Context, id Domain.ID) (*Domain.WebhookSubscription, error) {
+	var entity WebhookEntity
+	err := r.subscriptions.FindOne(ctx, bson.M{"_id": objectIDOrNil(id)}).Decode(&entity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscription: %w", err)
+	}
+	sub := entity.ToDomain()
+	return &sub, nil
+}
+
+func (r *MongoWebhookRepository) ListSubscriptions(ctx context.Context) ([]Domain.WebhookSubscription, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.subscriptions.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find webhook subscriptions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var subs []Domain.WebhookSubscription
+	for cursor.Next(ctx) {
+		var entity WebhookEntity
+		if err := cursor.Decode(&entity); err != nil {
+			return nil, fmt.Errorf("error decoding webhook subscription: %w", err)
+		}
+		subs = append(subs, entity.ToDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+	return subs, nil
+}
+
+func (r *MongoWebhookRepository) DeleteSubscription(ctx context.Context, id Domain.ID) error {
+	res, err := r.subscriptions.DeleteOne(ctx, bson.M{"_id": objectIDOrNil(id)})
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if res.DeletedCount() == 0 {
+		return Domain.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *MongoWebhookRepository) AddDeadLetter(ctx context.Context, letter Domain.WebhookDeadLetter) (*Domain.WebhookDeadLetter, error) {
+	entity := DeadLetterFromDomain(letter)
+	entity.ID = primitive.NilObjectID
+	res, err := r.deadLetters.InsertOne(ctx, entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert dead letter: %w", err)
+	}
+	id, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return nil, errors.New("failed to convert inserted ID to ObjectID")
+	}
+	entity.ID = id
+	created := entity.ToDomain()
+	return &created, nil
+}
+
+func (r *MongoWebhookRepository) GetDeadLetter(ctx context.Context, id Domain.ID) (*Domain.WebhookDeadLetter, error) {
+	var entity DeadLetterEntity
+	err := r.deadLetters.FindOne(ctx, bson.M{"_id": objectIDOrNil(id)}).Decode(&entity)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, Domain.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead letter: %w", err)
+	}
+	letter := entity.ToDomain()
+	return &letter, nil
+}
+
+func (r *MongoWebhookRepository) ListDeadLetters(ctx context.Context) ([]Domain.WebhookDeadLetter, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "failed_at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.deadLetters.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find dead letters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var letters []Domain.WebhookDeadLetter
+	for cursor.Next(ctx) {
+		var entity DeadLetterEntity
+		if err := cursor.Decode(&entity); err != nil {
+			return nil, fmt.Errorf("error decoding dead letter: %w", err)
+		}
+		letters = append(letters, entity.ToDomain())
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor iteration error: %w", err)
+	}
+	return letters, nil
+}
+
+func (r *MongoWebhookRepository) DeleteDeadLetter(ctx context.Context, id Domain.ID) error {
+	res, err := r.deadLetters.DeleteOne(ctx, bson.M{"_id": objectIDOrNil(id)})
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter: %w", err)
+	}
+	if res.DeletedCount() == 0 {
+		return Domain.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func eventTypesToStrings(types []Domain.TaskEventType) []string {
+	s := make([]string, 0, len(types))
+	for _, t := range types {
+		s = append(s, string(t))
+	}
+	return s
+}
+
+func eventTypesFromStrings(s []string) []Domain.TaskEventType {
+	if len(s) == 0 {
+		return nil
+	}
+	types := make([]Domain.TaskEventType, 0, len(s))
+	for _, v := range s {
+		types = append(types, Domain.TaskEventType(v))
+	}
+	return types
+}
@@ -0,0 +1,112 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for a new token pair
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// AuthUsecase issues, rotates and revokes tokens
+type AuthUsecase interface {
+	Login(ctx context.Context, username, password string) (*Domain.TokenPair, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*Domain.TokenPair, error)
+	Logout(ctx context.Context, actor Domain.Actor, accessToken Domain.AccessToken, refreshToken string) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// TokenService creates access tokens and refresh tokens
+type TokenService interface {
+	GenerateAccessToken(user Domain.User) (Domain.AccessToken, error)
+	GenerateRefreshToken() (string, error)
+	HashRefreshToken(token string) string
+}
+
+// authUsecase implements AuthUsecase interface
+type authUsecase struct {
+	userUsecase  UserUsecase
+	tokenRepo    Repositories.TokenRepository
+	tokenService TokenService
+}
+
+// NewAuthUsecase creates a new AuthUsecase
+func NewAuthUsecase(userUsecase UserUsecase, tokenRepo Repositories.TokenRepository, tokenService TokenService) AuthUsecase {
+	return &authUsecase{userUsecase: userUsecase, tokenRepo: tokenRepo, tokenService: tokenService}
+}
+
+// Login verifies the credentials and issues a new token pair.
+// It returns Domain.ErrInvalidCredentials like UserUsecase.AuthenticateUser.
+func (u *authUsecase) Login(ctx context.Context, username, password string) (*Domain.TokenPair, error) {
+	user, err := u.userUsecase.AuthenticateUser(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return u.issueTokens(ctx, *user)
+}
+
+// RefreshToken exchanges a refresh token for a new token pair.
+// The refresh token is rotated: it is consumed and cannot be used again.
+func (u *authUsecase) RefreshToken(ctx context.Context, refreshToken string) (*Domain.TokenPair, error) {
+	stored, err := u.tokenRepo.ConsumeRefreshToken(ctx, u.tokenService.HashRefreshToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if stored.IsExpired(time.Now()) {
+		return nil, Domain.ErrInvalidRefreshToken
+	}
+	// Reload the user so a changed role is picked up, and a deleted user cannot refresh
+	user, err := u.userUsecase.GetUserByID(ctx, stored.UserID.String())
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		return nil, Domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.issueTokens(ctx, *user)
+}
+
+// Logout revokes the access token and, when given, deletes the caller's refresh token
+func (u *authUsecase) Logout(ctx context.Context, actor Domain.Actor, accessToken Domain.AccessToken, refreshToken string) error {
+	if err := u.tokenRepo.RevokeAccessToken(ctx, accessToken.JTI, accessToken.ExpiresAt); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	return u.tokenRepo.DeleteRefreshToken(ctx, u.tokenService.HashRefreshToken(refreshToken), actor.UserID)
+}
+
+// IsTokenRevoked lets the authentication middleware reject logged out access tokens
+func (u *authUsecase) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return u.tokenRepo.IsAccessTokenRevoked(ctx, jti)
+}
+
+func (u *authUsecase) issueTokens(ctx context.Context, user Domain.User) (*Domain.TokenPair, error) {
+	accessToken, err := u.tokenService.GenerateAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := u.tokenService.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(RefreshTokenTTL)
+	err = u.tokenRepo.SaveRefreshToken(ctx, Domain.RefreshToken{
+		TokenHash: u.tokenService.HashRefreshToken(refreshToken),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return &Domain.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: expiresAt,
+	}, nil
+}
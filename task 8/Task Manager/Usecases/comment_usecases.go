@@ -0,0 +1,122 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"time"
+)
+
+// CommentUsecase defines the use case interface for task comments
+type CommentUsecase interface {
+	ListComments(ctx context.Context, actor Domain.Actor, taskID string) ([]Domain.Comment, error)
+	AddComment(ctx context.Context, actor Domain.Actor, taskID string, comment Domain.Comment) (*Domain.Comment, error)
+	UpdateComment(ctx context.Context, actor Domain.Actor, id string, body string) (*Domain.Comment, error)
+	DeleteComment(ctx context.Context, actor Domain.Actor, id string) error
+}
+
+// commentUsecase implements CommentUsecase interface.
+// Whoever may read a task may read and post its comments; a comment is changed by its author or a moderator.
+type commentUsecase struct {
+	commentRepo Repositories.CommentRepository
+	taskRepo    Repositories.TaskRepository
+	policy      *Domain.Policy
+	now         func() time.Time
+}
+
+// NewCommentUsecase creates a new CommentUsecase
+func NewCommentUsecase(commentRepo Repositories.CommentRepository, taskRepo Repositories.TaskRepository, policy *Domain.Policy) CommentUsecase {
+	return &commentUsecase{commentRepo: commentRepo, taskRepo: taskRepo, policy: policy, now: time.Now}
+}
+
+// ListComments returns the comments of a task, oldest first
+func (u *commentUsecase) ListComments(ctx context.Context, actor Domain.Actor, taskID string) ([]Domain.Comment, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.getReadableTask(ctx, actor, id); err != nil {
+		return nil, err
+	}
+	return u.commentRepo.ListTaskComments(ctx, id)
+}
+
+// AddComment posts a comment by the actor on a task
+func (u *commentUsecase) AddComment(ctx context.Context, actor Domain.Actor, taskID string, comment Domain.Comment) (*Domain.Comment, error) {
+	id, err := parseTaskID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.getReadableTask(ctx, actor, id); err != nil {
+		return nil, err
+	}
+	if err := comment.Validate(); err != nil {
+		return nil, err
+	}
+	now := u.now()
+	comment.TaskID = id
+	comment.AuthorID = actor.UserID
+	comment.AuthorUsername = actor.Username
+	comment.CreatedAt = now
+	comment.UpdatedAt = now
+	return u.commentRepo.AddComment(ctx, comment)
+}
+
+// UpdateComment replaces the body of a comment
+func (u *commentUsecase) UpdateComment(ctx context.Context, actor Domain.Actor, id string, body string) (*Domain.Comment, error) {
+	comment, err := u.getChangeableComment(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	comment.Body = body
+	if err := comment.Validate(); err != nil {
+		return nil, err
+	}
+	comment.UpdatedAt = u.now()
+	return u.commentRepo.UpdateComment(ctx, *comment)
+}
+
+func (u *commentUsecase) DeleteComment(ctx context.Context, actor Domain.Actor, id string) error {
+	comment, err := u.getChangeableComment(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	return u.commentRepo.DeleteComment(ctx, comment.ID)
+}
+
+// getChangeableComment loads a comment the actor may edit or delete.
+// A comment on a task the actor cannot read is reported as not found,
+// one the actor can see but not change returns Domain.ErrForbidden.
+func (u *commentUsecase) getChangeableComment(ctx context.Context, actor Domain.Actor, id string) (*Domain.Comment, error) {
+	commentID, err := Domain.ParseID(id)
+	if err != nil {
+		return nil, Domain.ErrCommentNotFound
+	}
+	comment, err := u.commentRepo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := u.getReadableTask(ctx, actor, comment.TaskID); err != nil {
+		if errors.Is(err, Domain.ErrTaskNotFound) {
+			return nil, Domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	if !u.policy.CanChangeComment(actor, comment) {
+		return nil, Domain.ErrForbidden
+	}
+	return comment, nil
+}
+
+// getReadableTask loads a live task and hides it from callers who may not see it
+func (u *commentUsecase) getReadableTask(ctx context.Context, actor Domain.Actor, id Domain.ID) (*Domain.Task, error) {
+	task, err := u.taskRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.policy.CanReadTask(actor, task) {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return task, nil
+}
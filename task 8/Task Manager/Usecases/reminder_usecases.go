@@ -0,0 +1,122 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"time"
+)
+
+// Notifier delivers reminders to the people working on a task.
+// Infrastructure provides notifiers that log, send emails and call webhooks.
+type Notifier interface {
+	Notify(ctx context.Context, reminder Domain.Reminder) error
+}
+
+// ReminderUsecase finds the tasks that need a reminder and hands them to the notifier
+type ReminderUsecase interface {
+	// SendReminders makes one pass over the open tasks due within the window or overdue
+	// and returns how many reminders were sent. A failed delivery is retried on the next pass.
+	SendReminders(ctx context.Context) (int, error)
+}
+
+// reminderUsecase implements ReminderUsecase.
+// Reminders are claimed in the reminder repository before they are sent, so each is sent once.
+type reminderUsecase struct {
+	taskRepo     Repositories.TaskRepository
+	userRepo     Repositories.UserRepository
+	reminderRepo Repositories.ReminderRepository
+	notifier     Notifier
+	window       time.Duration // how long before the due date tasks are reminded
+	now          func() time.Time
+}
+
+// ReminderUsecaseOption configures an optional part of the reminder usecase, see NewReminderUsecase
+type ReminderUsecaseOption func(*reminderUsecase)
+
+// WithReminderClock replaces time.Now, so passes can be tested at a fixed time
+func WithReminderClock(now func() time.Time) ReminderUsecaseOption {
+	return func(u *reminderUsecase) { u.now = now }
+}
+
+// NewReminderUsecase creates a new ReminderUsecase reminding tasks due within window
+func NewReminderUsecase(taskRepo Repositories.TaskRepository, userRepo Repositories.UserRepository, reminderRepo Repositories.ReminderRepository, notifier Notifier, window time.Duration, opts ...ReminderUsecaseOption) ReminderUsecase {
+	u := &reminderUsecase{taskRepo: taskRepo, userRepo: userRepo, reminderRepo: reminderRepo, notifier: notifier, window: window, now: time.Now}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func (u *reminderUsecase) SendReminders(ctx context.Context) (int, error) {
+	now := u.now()
+	sent := 0
+	var errs []error
+	// Completed tasks need no reminder, the two open statuses are listed one after the other
+	for _, status := range []Domain.TaskStatus{Domain.StatusPending, Domain.StatusInProgress} {
+		query := Domain.TaskQuery{Status: status, DueBefore: now.Add(u.window), Limit: Domain.MaxTaskPageSize}
+		for {
+			page, err := u.taskRepo.ListTasks(ctx, query)
+			if err != nil {
+				return sent, err
+			}
+			for _, task := range page.Tasks {
+				ok, err := u.remind(ctx, task, now)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("task %s: %w", task.ID, err))
+				}
+				if ok {
+					sent++
+				}
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// remind sends the reminder the task needs at now, unless it was sent already
+func (u *reminderUsecase) remind(ctx context.Context, task Domain.Task, now time.Time) (bool, error) {
+	reminder := Domain.Reminder{Kind: Domain.ReminderDueSoon, Task: task}
+	if task.IsOverdueAt(now) {
+		reminder.Kind = Domain.ReminderOverdue
+	}
+	claimed, err := u.reminderRepo.ClaimReminder(ctx, reminder.Key(), now)
+	if err != nil || !claimed {
+		return false, err
+	}
+	if reminder.Recipients, err = u.recipients(ctx, task); err == nil {
+		err = u.notifier.Notify(ctx, reminder)
+	}
+	if err != nil {
+		if releaseErr := u.reminderRepo.ReleaseReminder(ctx, reminder.Key()); releaseErr != nil {
+			return false, errors.Join(err, releaseErr)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// recipients looks up the owner and the assignees of a task. Users deleted since are left out.
+func (u *reminderUsecase) recipients(ctx context.Context, task Domain.Task) ([]Domain.Recipient, error) {
+	var recipients []Domain.Recipient
+	for _, id := range append([]Domain.ID{task.OwnerID}, task.AssigneeIDs...) {
+		user, err := u.userRepo.GetUserByID(ctx, id)
+		if errors.Is(err, Domain.ErrUserNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(recipients, func(r Domain.Recipient) bool { return r.UserID == user.ID }) {
+			recipients = append(recipients, Domain.Recipient{UserID: user.ID, Username: user.Username, Email: user.Email})
+		}
+	}
+	return recipients, nil
+}
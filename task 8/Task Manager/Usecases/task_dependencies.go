@@ -0,0 +1,185 @@
+package Usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"taskmanager/Domain"
+)
+
+// GetTaskTree returns a task with its subtasks, recursively.
+// Subtasks the actor may not read are left out together with their own subtasks.
+func (u *taskUsecase) GetTaskTree(ctx context.Context, actor Domain.Actor, id string) (*Domain.TaskNode, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	root, err := u.getReadableTask(ctx, actor, taskID)
+	if err != nil {
+		return nil, err
+	}
+	node, err := u.buildTree(ctx, actor, *root, map[Domain.ID]bool{})
+	if err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+func (u *taskUsecase) buildTree(ctx context.Context, actor Domain.Actor, task Domain.Task, seen map[Domain.ID]bool) (Domain.TaskNode, error) {
+	seen[task.ID] = true
+	node := Domain.TaskNode{Task: task}
+	subtasks, err := u.taskRepo.GetSubtasks(ctx, task.ID)
+	if err != nil {
+		return Domain.TaskNode{}, err
+	}
+	for _, subtask := range subtasks {
+		// seen guards against a corrupted hierarchy, checkRelations keeps it acyclic
+		if seen[subtask.ID] || !u.policy.CanReadTask(actor, &subtask) {
+			continue
+		}
+		child, err := u.buildTree(ctx, actor, subtask, seen)
+		if err != nil {
+			return Domain.TaskNode{}, err
+		}
+		node.Subtasks = append(node.Subtasks, child)
+	}
+	return node, nil
+}
+
+// checkRelations validates the parent and blockers of a task about to be written, existing being
+// nil for a new task. Newly referenced tasks must exist and be readable by the actor, and the
+// change must not create a cycle: a task waits for its blockers and for its subtasks, and may not
+// end up waiting for itself.
+func (u *taskUsecase) checkRelations(ctx context.Context, actor Domain.Actor, existing *Domain.Task, task Domain.Task) error {
+	var before Domain.Task
+	if existing != nil {
+		before = *existing
+	}
+	if task.ParentID == before.ParentID && slices.Equal(task.BlockedBy, before.BlockedBy) {
+		return nil
+	}
+	verr := &Domain.ValidationError{}
+	if !task.ParentID.IsZero() && task.ParentID != before.ParentID {
+		if err := u.checkRelatedTask(ctx, actor, task.ParentID, "parent_id", verr); err != nil {
+			return err
+		}
+	}
+	for _, id := range task.BlockedBy {
+		if slices.Contains(before.BlockedBy, id) {
+			continue
+		}
+		if err := u.checkRelatedTask(ctx, actor, id, "blocked_by", verr); err != nil {
+			return err
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		return err
+	}
+	cycle, err := u.createsCycle(ctx, task)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return Domain.ErrDependencyCycle
+	}
+	return nil
+}
+
+// checkRelatedTask reports a parent or blocker that does not exist or that the actor cannot see
+// as a validation error of field, without telling the two apart
+func (u *taskUsecase) checkRelatedTask(ctx context.Context, actor Domain.Actor, id Domain.ID, field string, verr *Domain.ValidationError) error {
+	_, err := u.getReadableTask(ctx, actor, id)
+	if errors.Is(err, Domain.ErrTaskNotFound) {
+		verr.Add(field, fmt.Sprintf("unknown task %q", id))
+		return nil
+	}
+	return err
+}
+
+// createsCycle checks if task, in its new state, would wait for itself. The stored tasks are
+// acyclic, so any new cycle goes through task; it is found by walking the tasks it waits for.
+// For a new task the walk looks for its zero ID, which only its parent can lead to.
+func (u *taskUsecase) createsCycle(ctx context.Context, task Domain.Task) (bool, error) {
+	pending, err := u.waitsFor(ctx, task, task)
+	if err != nil {
+		return false, err
+	}
+	visited := make(map[Domain.ID]bool)
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if id == task.ID {
+			return true, nil
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		next, err := u.taskRepo.GetTaskByID(ctx, id)
+		if errors.Is(err, Domain.ErrTaskNotFound) {
+			// Tasks in the trash no longer block anything
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		ids, err := u.waitsFor(ctx, *next, task)
+		if err != nil {
+			return false, err
+		}
+		pending = append(pending, ids...)
+	}
+	return false, nil
+}
+
+// waitsFor lists the tasks t waits for: its blockers and subtasks.
+// changed is the task being written, whose new parent replaces the stored one.
+func (u *taskUsecase) waitsFor(ctx context.Context, t Domain.Task, changed Domain.Task) ([]Domain.ID, error) {
+	ids := slices.Clone(t.BlockedBy)
+	if !t.ID.IsZero() {
+		subtasks, err := u.taskRepo.GetSubtasks(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, subtask := range subtasks {
+			if subtask.ID != changed.ID {
+				ids = append(ids, subtask.ID)
+			}
+		}
+	}
+	if !t.ID.IsZero() && changed.ParentID == t.ID {
+		ids = append(ids, changed.ID)
+	}
+	return ids, nil
+}
+
+// checkCompletion refuses to complete a task while one of its blockers or subtasks is not completed.
+// Blockers in the trash are ignored.
+func (u *taskUsecase) checkCompletion(ctx context.Context, existing *Domain.Task, task Domain.Task) error {
+	if task.Status != Domain.StatusCompleted || existing.Status == Domain.StatusCompleted {
+		return nil
+	}
+	for _, id := range task.BlockedBy {
+		blocker, err := u.taskRepo.GetTaskByID(ctx, id)
+		if errors.Is(err, Domain.ErrTaskNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if blocker.Status != Domain.StatusCompleted {
+			return Domain.ErrTaskBlocked
+		}
+	}
+	subtasks, err := u.taskRepo.GetSubtasks(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	for _, subtask := range subtasks {
+		if subtask.Status != Domain.StatusCompleted {
+			return Domain.ErrTaskBlocked
+		}
+	}
+	return nil
+}
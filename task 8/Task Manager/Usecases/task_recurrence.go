@@ -0,0 +1,47 @@
+package Usecases
+
+import (
+	"context"
+	"log"
+	"taskmanager/Domain"
+)
+
+// normalizeRecurrence rewrites a valid rule in its canonical form.
+// Invalid rules are returned unchanged for Task.Validate to report.
+func normalizeRecurrence(rule string) string {
+	if rule == "" {
+		return ""
+	}
+	parsed, err := Domain.ParseRecurrence(rule)
+	if err != nil {
+		return rule
+	}
+	return parsed.String()
+}
+
+// completeOccurrence prepares the next occurrence when task, a new state of existing, completes a recurring task.
+// The rule moves on to the next occurrence and is cleared on task, so reopening and completing
+// the task again does not create a second one.
+func (u *taskUsecase) completeOccurrence(existing *Domain.Task, task *Domain.Task) (Domain.Task, bool, error) {
+	if existing.Status == Domain.StatusCompleted || task.Status != Domain.StatusCompleted || task.Recurrence == "" {
+		return Domain.Task{}, false, nil
+	}
+	next, ok, err := task.NextOccurrence(u.now())
+	if err != nil {
+		return Domain.Task{}, false, err
+	}
+	task.Recurrence = ""
+	return next, ok, nil
+}
+
+// discardOccurrence removes an occurrence stored for a completion that could not be written.
+// Nothing was recorded or published for it yet, so it is purged rather than left in the trash.
+func (u *taskUsecase) discardOccurrence(ctx context.Context, actor Domain.Actor, id Domain.ID) {
+	err := u.taskRepo.DeleteTask(ctx, id, actor.UserID, u.now())
+	if err == nil {
+		err = u.taskRepo.PurgeTask(ctx, id)
+	}
+	if err != nil {
+		log.Printf("Failed to discard occurrence %s of a task that was not completed: %v", id, err)
+	}
+}
@@ -0,0 +1,89 @@
+package Usecases
+
+import (
+	"strconv"
+	"sync"
+	"taskmanager/Domain"
+)
+
+// TaskEventSource hands out subscriptions to the published task events.
+// Infrastructure.EventBus implements it.
+type TaskEventSource interface {
+	// Subscribe returns the events published from now on, after the buffered events following
+	// the one numbered after if resume is set
+	Subscribe(after uint64, resume bool) *Domain.TaskEventFeed
+}
+
+// TaskStreamUsecase defines the use case interface for following task changes as they happen
+type TaskStreamUsecase interface {
+	// StreamTasks subscribes the actor to the task events. lastEventID is the Seq of the last event
+	// the client received, empty for a new stream. Events of tasks the actor cannot read only carry
+	// their Seq, so the client's position still advances. The caller must close the feed.
+	StreamTasks(actor Domain.Actor, lastEventID string) (*Domain.TaskEventFeed, error)
+}
+
+// taskStreamUsecase implements TaskStreamUsecase interface
+type taskStreamUsecase struct {
+	source TaskEventSource
+	policy *Domain.Policy
+}
+
+// NewTaskStreamUsecase creates a new TaskStreamUsecase
+func NewTaskStreamUsecase(source TaskEventSource, policy *Domain.Policy) TaskStreamUsecase {
+	return &taskStreamUsecase{source: source, policy: policy}
+}
+
+func (u *taskStreamUsecase) StreamTasks(actor Domain.Actor, lastEventID string) (*Domain.TaskEventFeed, error) {
+	if !u.policy.Allows(actor, Domain.PermTasksRead) {
+		return nil, Domain.ErrForbidden
+	}
+	var after uint64
+	resume := lastEventID != ""
+	if resume {
+		var err error
+		if after, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			return nil, Domain.NewValidationError("Last-Event-ID", "must be the id of an event of this stream")
+		}
+	}
+
+	feed := u.source.Subscribe(after, resume)
+	backlog := make([]Domain.TaskEvent, len(feed.Backlog))
+	for i, event := range feed.Backlog {
+		backlog[i] = u.visible(actor, event)
+	}
+
+	// The filtered events are handed over one by one, so closing stops the goroutine right away
+	events := make(chan Domain.TaskEvent)
+	done := make(chan struct{})
+	go func() {
+		defer close(events)
+		for event := range feed.Events {
+			select {
+			case events <- u.visible(actor, event):
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return &Domain.TaskEventFeed{
+		Position: feed.Position,
+		Backlog:  backlog,
+		Gap:      feed.Gap,
+		Events:   events,
+		Close: func() {
+			once.Do(func() {
+				close(done)
+				feed.Close()
+			})
+		},
+	}, nil
+}
+
+// visible returns the event, or only its Seq if the actor may not read the task
+func (u *taskStreamUsecase) visible(actor Domain.Actor, event Domain.TaskEvent) Domain.TaskEvent {
+	if u.policy.CanReadTask(actor, &event.Task) {
+		return event
+	}
+	return Domain.TaskEvent{Seq: event.Seq}
+}
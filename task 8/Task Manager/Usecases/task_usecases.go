@@ -3,68 +3,513 @@ package Usecases
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"taskmanager/Domain"
 	"taskmanager/Repositories"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
 )
 
 // TaskUsecase defines the use case interface for task operations
 type TaskUsecase interface {
-	GetAllTasks(ctx context.Context) ([]Domain.Task, error)
-	GetTaskByID(ctx context.Context, id string) (*Domain.Task, error)
-	AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error)
-	UpdateTask(ctx context.Context, id string, task Domain.Task) (*Domain.Task, error)
-	DeleteTask(ctx context.Context, id string) error
+	GetAllTasks(ctx context.Context, actor Domain.Actor) ([]Domain.Task, error)
+	ListTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error)
+	GetTaskByID(ctx context.Context, actor Domain.Actor, id string) (*Domain.Task, error)
+	AddTask(ctx context.Context, actor Domain.Actor, task Domain.Task) (*Domain.Task, error)
+	UpdateTask(ctx context.Context, actor Domain.Actor, id string, task Domain.Task) (*Domain.Task, error)
+	PatchTask(ctx context.Context, actor Domain.Actor, id string, patch Domain.TaskPatch) (*Domain.Task, error)
+	DeleteTask(ctx context.Context, actor Domain.Actor, id string) error
+	ListDeletedTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error)
+	RestoreTask(ctx context.Context, actor Domain.Actor, id string) (*Domain.Task, error)
+	PurgeTask(ctx context.Context, actor Domain.Actor, id string) error
+	GetTaskHistory(ctx context.Context, actor Domain.Actor, id string) ([]Domain.AuditEvent, error)
+	AssignTask(ctx context.Context, actor Domain.Actor, id string, userIDs []string, version int64) (*Domain.Task, error)
+	UnassignTask(ctx context.Context, actor Domain.Actor, id string, userIDs []string, version int64) (*Domain.Task, error)
+	ListAssignedTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error)
+	GetTaskTree(ctx context.Context, actor Domain.Actor, id string) (*Domain.TaskNode, error)
+	ListLabels(ctx context.Context, actor Domain.Actor) ([]Domain.LabelCount, error)
+}
+
+// TaskEventPublisher receives the events of the changes made through TaskUsecase.
+// PublishTaskEvent is called after the change is stored and must not block, deliveries happen elsewhere.
+type TaskEventPublisher interface {
+	PublishTaskEvent(event Domain.TaskEvent)
 }
 
-// taskUsecase implements TaskUsecase interface
+// taskUsecase implements TaskUsecase interface.
+// Every operation is checked against the policy, so the rules hold for callers other than the HTTP API too.
+// Every change is recorded in the audit repository and published as task events.
 type taskUsecase struct {
-	taskRepo Repositories.TaskRepository
+	taskRepo  Repositories.TaskRepository
+	auditRepo Repositories.AuditRepository
+	userRepo  Repositories.UserRepository // looks up assignees
+	policy    *Domain.Policy
+	now       func() time.Time
+	publisher TaskEventPublisher // nil when nobody listens
+}
+
+// TaskUsecaseOption configures an optional part of the task usecase, see NewTaskUsecase
+type TaskUsecaseOption func(*taskUsecase)
+
+// WithClock replaces time.Now, so due dates and recurring tasks can be tested at a fixed time
+func WithClock(now func() time.Time) TaskUsecaseOption {
+	return func(u *taskUsecase) { u.now = now }
+}
+
+// WithEventPublisher publishes the task events of every change to publisher
+func WithEventPublisher(publisher TaskEventPublisher) TaskUsecaseOption {
+	return func(u *taskUsecase) { u.publisher = publisher }
 }
 
 // NewTaskUsecase creates a new TaskUsecase
-func NewTaskUsecase(taskRepo Repositories.TaskRepository) TaskUsecase {
-	return &taskUsecase{taskRepo: taskRepo}
+func NewTaskUsecase(taskRepo Repositories.TaskRepository, auditRepo Repositories.AuditRepository, userRepo Repositories.UserRepository, policy *Domain.Policy, opts ...TaskUsecaseOption) TaskUsecase {
+	u := &taskUsecase{taskRepo: taskRepo, auditRepo: auditRepo, userRepo: userRepo, policy: policy, now: time.Now}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+// GetAllTasks returns every task the actor may read
+func (u *taskUsecase) GetAllTasks(ctx context.Context, actor Domain.Actor) ([]Domain.Task, error) {
+	switch {
+	case u.policy.Allows(actor, Domain.PermTasksReadAny):
+		return u.taskRepo.GetAllTasks(ctx)
+	case u.policy.Allows(actor, Domain.PermTasksReadTeam) && actor.Team != "":
+		all, err := u.taskRepo.GetAllTasks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var tasks []Domain.Task
+		for _, task := range all {
+			if u.policy.CanReadTask(actor, &task) {
+				tasks = append(tasks, task)
+			}
+		}
+		return tasks, nil
+	case u.policy.Allows(actor, Domain.PermTasksRead):
+		return u.taskRepo.GetTasksByOwner(ctx, actor.UserID)
+	default:
+		return nil, Domain.ErrForbidden
+	}
+}
+
+// ListTasks returns one page of tasks matching the query.
+// The owner and team of the query are replaced by what the actor may read, see Policy.ScopeTaskQuery.
+func (u *taskUsecase) ListTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	query.Deleted = false
+	return u.listTasks(ctx, actor, query)
 }
 
-func (u *taskUsecase) GetAllTasks(ctx context.Context) ([]Domain.Task, error) {
-	tasks, err := u.taskRepo.GetAllTasks(ctx)
+// ListDeletedTasks returns one page of the trash, scoped like ListTasks
+func (u *taskUsecase) ListDeletedTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	query.Deleted = true
+	return u.listTasks(ctx, actor, query)
+}
+
+// ListAssignedTasks returns one page of the live tasks assigned to the actor.
+// Assignees may always read their tasks, so only the base tasks:read permission is needed.
+func (u *taskUsecase) ListAssignedTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	if !u.policy.Allows(actor, Domain.PermTasksRead) || actor.UserID.IsZero() {
+		return nil, Domain.ErrForbidden
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	query.OwnerID = ""
+	query.Team = ""
+	query.AssigneeID = actor.UserID
+	query.Deleted = false
+	return u.taskRepo.ListTasks(ctx, query)
+}
+
+func (u *taskUsecase) listTasks(ctx context.Context, actor Domain.Actor, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+	query, err := u.policy.ScopeTaskQuery(actor, query)
 	if err != nil {
 		return nil, err
 	}
-	return tasks, nil
+	return u.taskRepo.ListTasks(ctx, query)
 }
 
-func (u *taskUsecase) GetTaskByID(ctx context.Context, id string) (*Domain.Task, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+// ListLabels counts the labels of the live tasks the actor may read, most used first
+func (u *taskUsecase) ListLabels(ctx context.Context, actor Domain.Actor) ([]Domain.LabelCount, error) {
+	query, err := u.policy.ScopeTaskQuery(actor, Domain.TaskQuery{})
 	if err != nil {
-		return nil, errors.New("invalid task ID")
+		return nil, err
 	}
-	return u.taskRepo.GetTaskByID(ctx, objID)
+	return u.taskRepo.CountLabels(ctx, query)
 }
 
-func (u *taskUsecase) AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+func (u *taskUsecase) GetTaskByID(ctx context.Context, actor Domain.Actor, id string) (*Domain.Task, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	return u.getReadableTask(ctx, actor, taskID)
+}
+
+// AddTask creates a task owned by the actor, in the actor's team.
+// A task without a status starts as Pending; it may not be created as Completed.
+// A task without a priority gets medium priority.
+func (u *taskUsecase) AddTask(ctx context.Context, actor Domain.Actor, task Domain.Task) (*Domain.Task, error) {
+	if !u.policy.Allows(actor, Domain.PermTasksWrite) {
+		return nil, Domain.ErrForbidden
+	}
+	if task.Status == "" {
+		task.Status = Domain.StatusPending
+	}
+	if task.Priority == "" {
+		task.Priority = Domain.PriorityMedium
+	}
+	task.Labels = Domain.NormalizeLabels(task.Labels)
+	task.Recurrence = normalizeRecurrence(task.Recurrence)
+	task.Occurrence = 0
+	if task.Recurrence != "" {
+		task.Occurrence = 1
+	}
+	if err := task.ValidateNew(u.now()); err != nil {
+		return nil, err
+	}
+	if err := u.policy.CheckStatusChange(actor, "", task.Status); err != nil {
+		return nil, err
+	}
+	if err := u.checkRelations(ctx, actor, nil, task); err != nil {
+		return nil, err
+	}
+	task.OwnerID = actor.UserID
+	task.Team = actor.Team
 	createdTask, err := u.taskRepo.AddTask(ctx, task)
 	if err != nil {
 		return nil, err
 	}
+	u.record(ctx, Domain.AuditCreated, actor, Domain.Task{}, *createdTask)
 	return createdTask, nil
 }
 
-func (u *taskUsecase) UpdateTask(ctx context.Context, id string, task Domain.Task) (*Domain.Task, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+// UpdateTask replaces the fields of a task. task.Version is the version the caller
+// last read, Domain.ErrVersionConflict is returned if it changed since; 0 skips the check.
+func (u *taskUsecase) UpdateTask(ctx context.Context, actor Domain.Actor, id string, task Domain.Task) (*Domain.Task, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := u.getUpdatableTask(ctx, actor, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing, task.Version); err != nil {
+		return nil, err
+	}
+	return u.saveTask(ctx, actor, existing, task)
+}
+
+// PatchTask changes only the fields set in the patch, with the same version check as UpdateTask
+func (u *taskUsecase) PatchTask(ctx context.Context, actor Domain.Actor, id string, patch Domain.TaskPatch) (*Domain.Task, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := u.getUpdatableTask(ctx, actor, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing, patch.Version); err != nil {
+		return nil, err
+	}
+	return u.saveTask(ctx, actor, existing, patch.Apply(*existing))
+}
+
+// saveTask validates the new state of an existing task and writes it.
+// The write is conditional on the version that was loaded, so a concurrent
+// change between the read and the write is reported as a conflict too.
+func (u *taskUsecase) saveTask(ctx context.Context, actor Domain.Actor, existing *Domain.Task, task Domain.Task) (*Domain.Task, error) {
+	// A priority cannot be removed, a replacement without one keeps the current priority
+	if task.Priority == "" {
+		task.Priority = existing.Priority
+	}
+	task.Labels = Domain.NormalizeLabels(task.Labels)
+	task.Recurrence = normalizeRecurrence(task.Recurrence)
+	if err := task.Validate(); err != nil {
+		return nil, err
+	}
+	if err := u.policy.CheckStatusChange(actor, existing.Status, task.Status); err != nil {
+		return nil, err
+	}
+	task.ID = existing.ID
+	// Ownership never changes through an update, assignees only through AssignTask and UnassignTask
+	task.OwnerID = existing.OwnerID
+	task.Team = existing.Team
+	task.AssigneeIDs = existing.AssigneeIDs
+	task.Occurrence = existing.Occurrence
+	if task.Recurrence != "" && task.Occurrence == 0 {
+		task.Occurrence = 1
+	}
+	task.Version = existing.Version
+	if err := u.checkRelations(ctx, actor, existing, task); err != nil {
+		return nil, err
+	}
+	if err := u.checkCompletion(ctx, existing, task); err != nil {
+		return nil, err
+	}
+	next, recurs, err := u.completeOccurrence(existing, &task)
+	if err != nil {
+		return nil, err
+	}
+	// The next occurrence is stored before the rule is cleared on the completed task,
+	// so a failure leaves the series as it was and the client can retry
+	var occurrence *Domain.Task
+	if recurs {
+		if occurrence, err = u.taskRepo.AddTask(ctx, next); err != nil {
+			return nil, err
+		}
+	}
+	updated, err := u.taskRepo.UpdateTask(ctx, task)
+	if err != nil {
+		if occurrence != nil {
+			u.discardOccurrence(ctx, actor, occurrence.ID)
+		}
+		return nil, err
+	}
+	u.record(ctx, Domain.AuditUpdated, actor, *existing, *updated)
+	if occurrence != nil {
+		u.record(ctx, Domain.AuditCreated, actor, Domain.Task{}, *occurrence)
+	}
+	return updated, nil
+}
+
+// AssignTask adds registered users to the assignees of a task, users already assigned are kept once.
+// Whoever may delete the task may assign it, its assignees may not. version is checked like for UpdateTask.
+func (u *taskUsecase) AssignTask(ctx context.Context, actor Domain.Actor, id string, userIDs []string, version int64) (*Domain.Task, error) {
+	ids, err := u.parseAssignees(ctx, userIDs, true)
+	if err != nil {
+		return nil, err
+	}
+	return u.changeAssignees(ctx, actor, id, version, Domain.AuditAssigned, func(t *Domain.Task) { t.Assign(ids...) })
+}
+
+// UnassignTask removes users from the assignees of a task, users that are not assigned are ignored
+func (u *taskUsecase) UnassignTask(ctx context.Context, actor Domain.Actor, id string, userIDs []string, version int64) (*Domain.Task, error) {
+	ids, err := u.parseAssignees(ctx, userIDs, false)
+	if err != nil {
+		return nil, err
+	}
+	return u.changeAssignees(ctx, actor, id, version, Domain.AuditUnassigned, func(t *Domain.Task) { t.Unassign(ids...) })
+}
+
+func (u *taskUsecase) changeAssignees(ctx context.Context, actor Domain.Actor, id string, version int64, action Domain.AuditAction, change func(*Domain.Task)) (*Domain.Task, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := u.getWritableTask(ctx, actor, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing, version); err != nil {
+		return nil, err
+	}
+	task := *existing
+	change(&task)
+	if err := task.Validate(); err != nil {
+		return nil, err
+	}
+	updated, err := u.taskRepo.UpdateTask(ctx, task)
 	if err != nil {
-		return nil, errors.New("invalid task ID")
+		return nil, err
+	}
+	u.record(ctx, action, actor, *existing, *updated)
+	return updated, nil
+}
+
+// parseAssignees validates the user IDs of an assign or unassign request.
+// With mustExist, every user is looked up so tasks cannot be assigned to unknown users.
+func (u *taskUsecase) parseAssignees(ctx context.Context, userIDs []string, mustExist bool) ([]Domain.ID, error) {
+	if len(userIDs) == 0 {
+		return nil, Domain.NewValidationError("user_ids", "at least one user ID is required")
+	}
+	verr := &Domain.ValidationError{}
+	ids := make([]Domain.ID, 0, len(userIDs))
+	for _, raw := range userIDs {
+		id, err := Domain.ParseID(raw)
+		if err != nil {
+			verr.Add("user_ids", fmt.Sprintf("unknown user %q", raw))
+			continue
+		}
+		if mustExist {
+			_, err := u.userRepo.GetUserByID(ctx, id)
+			if errors.Is(err, Domain.ErrUserNotFound) {
+				verr.Add("user_ids", fmt.Sprintf("unknown user %q", raw))
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, id)
+	}
+	if err := verr.OrNil(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DeleteTask moves the task to the trash, from where it can be restored until an admin purges it
+func (u *taskUsecase) DeleteTask(ctx context.Context, actor Domain.Actor, id string) error {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return err
+	}
+	task, err := u.getWritableTask(ctx, actor, taskID)
+	if err != nil {
+		return err
+	}
+	if err := u.taskRepo.DeleteTask(ctx, taskID, actor.UserID, u.now()); err != nil {
+		return err
+	}
+	u.record(ctx, Domain.AuditDeleted, actor, *task, *task)
+	return nil
+}
+
+// RestoreTask takes a task out of the trash. Whoever may change the task may restore it.
+func (u *taskUsecase) RestoreTask(ctx context.Context, actor Domain.Actor, id string) (*Domain.Task, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := u.taskRepo.GetDeletedTaskByID(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.policy.CanReadTask(actor, task) {
+		return nil, Domain.ErrTaskNotFound
+	}
+	if !u.policy.CanWriteTask(actor, task) {
+		return nil, Domain.ErrForbidden
+	}
+	// The tasks it relates to may have changed while it was in the trash, where it blocked nothing
+	cycle, err := u.createsCycle(ctx, *task)
+	if err != nil {
+		return nil, err
+	}
+	if cycle {
+		return nil, Domain.ErrDependencyCycle
+	}
+	restored, err := u.taskRepo.RestoreTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	u.record(ctx, Domain.AuditRestored, actor, *task, *restored)
+	return restored, nil
+}
+
+// PurgeTask deletes a task in the trash for good, it needs the tasks:purge permission
+func (u *taskUsecase) PurgeTask(ctx context.Context, actor Domain.Actor, id string) error {
+	if !u.policy.Allows(actor, Domain.PermTasksPurge) {
+		return Domain.ErrForbidden
+	}
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return err
+	}
+	if err := u.taskRepo.PurgeTask(ctx, taskID); err != nil {
+		return err
+	}
+	// The history outlives the task
+	u.record(ctx, Domain.AuditPurged, actor, Domain.Task{ID: taskID}, Domain.Task{ID: taskID})
+	return nil
+}
+
+// GetTaskHistory returns the audit events of a task the actor may read, oldest first.
+// The history of a task in the trash stays available.
+func (u *taskUsecase) GetTaskHistory(ctx context.Context, actor Domain.Actor, id string) ([]Domain.AuditEvent, error) {
+	taskID, err := parseTaskID(id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = u.getReadableTask(ctx, actor, taskID)
+	if errors.Is(err, Domain.ErrTaskNotFound) {
+		var task *Domain.Task
+		task, err = u.taskRepo.GetDeletedTaskByID(ctx, taskID)
+		if err == nil && !u.policy.CanReadTask(actor, task) {
+			err = Domain.ErrTaskNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.auditRepo.ListTaskEvents(ctx, taskID)
+}
+
+// record writes the audit event of a change and publishes its task events. The change itself
+// has already been stored, so failing to write the audit event is only logged: reporting an error
+// would make the client retry a change that succeeded.
+func (u *taskUsecase) record(ctx context.Context, action Domain.AuditAction, actor Domain.Actor, before, after Domain.Task) {
+	now := u.now()
+	if u.publisher != nil {
+		for _, event := range Domain.NewTaskEvents(action, actor, now, before, after) {
+			u.publisher.PublishTaskEvent(event)
+		}
+	}
+	if _, err := u.auditRepo.AddEvent(ctx, Domain.NewAuditEvent(action, actor, now, before, after)); err != nil {
+		log.Printf("Failed to record audit event %s of task %s: %v", action, after.ID, err)
+	}
+}
+
+// getReadableTask loads a task and hides it from callers who may not see it.
+// Tasks the actor may not read are reported as not found so their existence is not leaked.
+func (u *taskUsecase) getReadableTask(ctx context.Context, actor Domain.Actor, id Domain.ID) (*Domain.Task, error) {
+	task, err := u.taskRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.policy.CanReadTask(actor, task) {
+		return nil, Domain.ErrTaskNotFound
+	}
+	return task, nil
+}
+
+// getUpdatableTask loads a task the actor may change, as its owner or one of its assignees.
+// A task the actor can see but not change returns Domain.ErrForbidden.
+func (u *taskUsecase) getUpdatableTask(ctx context.Context, actor Domain.Actor, id Domain.ID) (*Domain.Task, error) {
+	task, err := u.getReadableTask(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.policy.CanUpdateTask(actor, task) {
+		return nil, Domain.ErrForbidden
+	}
+	return task, nil
+}
+
+// getWritableTask loads a task the actor may delete or assign, which its assignees may not.
+// A task the actor can see but not write returns Domain.ErrForbidden.
+func (u *taskUsecase) getWritableTask(ctx context.Context, actor Domain.Actor, id Domain.ID) (*Domain.Task, error) {
+	task, err := u.getReadableTask(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.policy.CanWriteTask(actor, task) {
+		return nil, Domain.ErrForbidden
+	}
+	return task, nil
+}
+
+// checkVersion compares the version a client expects with the stored one, 0 meaning any version
+func checkVersion(task *Domain.Task, expected int64) error {
+	if expected != 0 && expected != task.Version {
+		return Domain.ErrVersionConflict
 	}
-	task.ID = objID
-	return u.taskRepo.UpdateTask(ctx, task)
+	return nil
 }
 
-func (u *taskUsecase) DeleteTask(ctx context.Context, id string) error {
-	objID, err := primitive.ObjectIDFromHex(id)
+// parseTaskID treats a malformed ID like an unknown task
+func parseTaskID(id string) (Domain.ID, error) {
+	taskID, err := Domain.ParseID(id)
 	if err != nil {
-		return errors.New("invalid task ID")
+		return "", Domain.ErrTaskNotFound
 	}
-	return u.taskRepo.DeleteTask(ctx, objID)
+	return taskID, nil
 }
@@ -3,10 +3,10 @@ package Usecases
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"taskmanager/Domain"
 	"taskmanager/Repositories"
-
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // UserUsecase defines the use case interface for user operations
@@ -14,30 +14,167 @@ type UserUsecase interface {
 	RegisterUser(ctx context.Context, user Domain.User) error
 	AuthenticateUser(ctx context.Context, username, password string) (*Domain.User, error)
 	GetUserByID(ctx context.Context, id string) (*Domain.User, error)
+	ListUsers(ctx context.Context, actor Domain.Actor) ([]Domain.User, error)
+	UpdateUserRole(ctx context.Context, actor Domain.Actor, id, role string) (*Domain.User, error)
+	UpdateUserTeam(ctx context.Context, actor Domain.Actor, id, team string) (*Domain.User, error)
+	DeleteUser(ctx context.Context, actor Domain.Actor, id string) error
+	BootstrapAdmin(ctx context.Context, admin Domain.User) (bool, error)
+}
+
+// PasswordService hashes and verifies user passwords
+type PasswordService interface {
+	HashPassword(password string) (string, error)
+	CheckPasswordHash(hashedPassword, password string) bool
 }
 
 // userUsecase implements UserUsecase interface
 type userUsecase struct {
-	userRepo Repositories.UserRepository
+	userRepo        Repositories.UserRepository
+	passwordService PasswordService
+	policy          *Domain.Policy
+
+	// dummyHash is checked against for unknown usernames, so they take as long as wrong passwords
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // NewUserUsecase creates a new UserUsecase
-func NewUserUsecase(userRepo Repositories.UserRepository) UserUsecase {
-	return &userUsecase{userRepo: userRepo}
+func NewUserUsecase(userRepo Repositories.UserRepository, passwordService PasswordService, policy *Domain.Policy) UserUsecase {
+	return &userUsecase{userRepo: userRepo, passwordService: passwordService, policy: policy}
 }
 
+// RegisterUser stores the user with a hashed password and the "user" role, whatever role was asked for.
+// It returns a *Domain.ValidationError for a missing username, a too short password or an invalid email,
+// and Domain.ErrUsernameTaken or Domain.ErrEmailTaken for duplicates.
 func (u *userUsecase) RegisterUser(ctx context.Context, user Domain.User) error {
+	user.Role = Domain.RoleUser
+	return u.createUser(ctx, user)
+}
+
+// BootstrapAdmin creates the given user as an admin unless the username already exists,
+// so the first admin can be configured without anyone registering as admin.
+// It reports whether the user was created.
+func (u *userUsecase) BootstrapAdmin(ctx context.Context, admin Domain.User) (bool, error) {
+	if !u.policy.HasRole(Domain.RoleAdmin) {
+		return false, fmt.Errorf("%w: %q is not configured", Domain.ErrInvalidRole, Domain.RoleAdmin)
+	}
+	admin.Role = Domain.RoleAdmin
+	err := u.createUser(ctx, admin)
+	if errors.Is(err, Domain.ErrUsernameTaken) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (u *userUsecase) createUser(ctx context.Context, user Domain.User) error {
+	if err := user.ValidateNew(); err != nil {
+		return err
+	}
+	hashedPassword, err := u.passwordService.HashPassword(user.Password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.Password = hashedPassword
 	return u.userRepo.RegisterUser(ctx, user)
 }
 
+// AuthenticateUser verifies the credentials and returns the matching user.
+// Unknown usernames and wrong passwords both return Domain.ErrInvalidCredentials.
 func (u *userUsecase) AuthenticateUser(ctx context.Context, username, password string) (*Domain.User, error) {
-	return u.userRepo.AuthenticateUser(ctx, username, password)
+	user, err := u.userRepo.GetUserByUsername(ctx, username)
+	if errors.Is(err, Domain.ErrUserNotFound) {
+		u.passwordService.CheckPasswordHash(u.getDummyHash(), password)
+		return nil, Domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !u.passwordService.CheckPasswordHash(user.Password, password) {
+		return nil, Domain.ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// getDummyHash hashes a fixed password the first time a login names an unknown user
+func (u *userUsecase) getDummyHash() string {
+	u.dummyHashOnce.Do(func() {
+		u.dummyHash, _ = u.passwordService.HashPassword("not-a-real-password")
+	})
+	return u.dummyHash
 }
 
 func (u *userUsecase) GetUserByID(ctx context.Context, id string) (*Domain.User, error) {
-	objID, err := primitive.ObjectIDFromHex(id)
+	userID, err := parseUserID(id)
+	if err != nil {
+		return nil, err
+	}
+	return u.userRepo.GetUserByID(ctx, userID)
+}
+
+// ListUsers returns every user, for actors with the users:admin permission
+func (u *userUsecase) ListUsers(ctx context.Context, actor Domain.Actor) ([]Domain.User, error) {
+	if !u.policy.Allows(actor, Domain.PermUsersAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	return u.userRepo.ListUsers(ctx)
+}
+
+// UpdateUserRole changes the role of another user to one of the configured roles.
+// Tokens already issued keep the old role until they are refreshed.
+func (u *userUsecase) UpdateUserRole(ctx context.Context, actor Domain.Actor, id, role string) (*Domain.User, error) {
+	if !u.policy.Allows(actor, Domain.PermUsersAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	if !u.policy.HasRole(role) {
+		return nil, Domain.ErrInvalidRole
+	}
+	userID, err := parseUserID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Keeps an admin from locking everyone out by demoting themselves
+	if userID == actor.UserID {
+		return nil, Domain.ErrCannotModifySelf
+	}
+	return u.userRepo.UpdateUserRole(ctx, userID, role)
+}
+
+// UpdateUserTeam moves a user to another team, or out of any team with an empty name.
+// Existing tasks stay in the team they were created in.
+func (u *userUsecase) UpdateUserTeam(ctx context.Context, actor Domain.Actor, id, team string) (*Domain.User, error) {
+	if !u.policy.Allows(actor, Domain.PermUsersAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	userID, err := parseUserID(id)
+	if err != nil {
+		return nil, err
+	}
+	return u.userRepo.UpdateUserTeam(ctx, userID, team)
+}
+
+// DeleteUser removes another user. Their refresh tokens stop working since the user no longer exists.
+func (u *userUsecase) DeleteUser(ctx context.Context, actor Domain.Actor, id string) error {
+	if !u.policy.Allows(actor, Domain.PermUsersAdmin) {
+		return Domain.ErrForbidden
+	}
+	userID, err := parseUserID(id)
+	if err != nil {
+		return err
+	}
+	if userID == actor.UserID {
+		return Domain.ErrCannotModifySelf
+	}
+	return u.userRepo.DeleteUser(ctx, userID)
+}
+
+// parseUserID treats a malformed ID like an unknown user
+func parseUserID(id string) (Domain.ID, error) {
+	userID, err := Domain.ParseID(id)
 	if err != nil {
-		return nil, errors.New("invalid user ID")
+		return "", Domain.ErrUserNotFound
 	}
-	return u.userRepo.GetUserByID(ctx, objID)
+	return userID, nil
 }
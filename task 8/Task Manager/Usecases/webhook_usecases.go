@@ -0,0 +1,148 @@
+package Usecases
+
+import (
+	"context"
+	"log"
+	"slices"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"time"
+)
+
+// WebhookRedeliverer sends a dead letter again, with the same retries as a new delivery.
+// Infrastructure.WebhookDispatcher implements it.
+type WebhookRedeliverer interface {
+	// Redeliver returns an error if the delivery was not accepted
+	Redeliver(sub Domain.WebhookSubscription, letter Domain.WebhookDeadLetter) error
+}
+
+// WebhookUsecase defines the use case interface for managing webhook subscriptions
+// and the deliveries that failed for good. Every operation needs the webhooks:admin permission.
+type WebhookUsecase interface {
+	CreateSubscription(ctx context.Context, actor Domain.Actor, sub Domain.WebhookSubscription) (*Domain.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, actor Domain.Actor) ([]Domain.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, actor Domain.Actor, id string) (*Domain.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, actor Domain.Actor, id string) error
+	ListDeadLetters(ctx context.Context, actor Domain.Actor) ([]Domain.WebhookDeadLetter, error)
+	// RetryDeadLetter removes a dead letter and delivers its payload again to its subscription.
+	// It becomes a new dead letter if every attempt fails again. While the server shuts down the letter
+	// is kept, under a new ID, and Domain.ErrWebhooksStopped is returned.
+	RetryDeadLetter(ctx context.Context, actor Domain.Actor, id string) error
+	DeleteDeadLetter(ctx context.Context, actor Domain.Actor, id string) error
+}
+
+// webhookUsecase implements WebhookUsecase interface
+type webhookUsecase struct {
+	webhookRepo Repositories.WebhookRepository
+	redeliverer WebhookRedeliverer
+	policy      *Domain.Policy
+	now         func() time.Time
+}
+
+// NewWebhookUsecase creates a new WebhookUsecase
+func NewWebhookUsecase(webhookRepo Repositories.WebhookRepository, redeliverer WebhookRedeliverer, policy *Domain.Policy) WebhookUsecase {
+	return &webhookUsecase{webhookRepo: webhookRepo, redeliverer: redeliverer, policy: policy, now: time.Now}
+}
+
+// CreateSubscription subscribes a URL to task events, to every event when sub.Events is empty
+func (u *webhookUsecase) CreateSubscription(ctx context.Context, actor Domain.Actor, sub Domain.WebhookSubscription) (*Domain.WebhookSubscription, error) {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	sub.Events = slices.Compact(slices.Sorted(slices.Values(sub.Events)))
+	if err := sub.Validate(); err != nil {
+		return nil, err
+	}
+	sub.CreatedBy = actor.UserID
+	sub.CreatedAt = u.now()
+	return u.webhookRepo.AddSubscription(ctx, sub)
+}
+
+func (u *webhookUsecase) ListSubscriptions(ctx context.Context, actor Domain.Actor) ([]Domain.WebhookSubscription, error) {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	return u.webhookRepo.ListSubscriptions(ctx)
+}
+
+func (u *webhookUsecase) GetSubscription(ctx context.Context, actor Domain.Actor, id string) (*Domain.WebhookSubscription, error) {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	subID, err := parseIDOr(id, Domain.ErrWebhookNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return u.webhookRepo.GetSubscription(ctx, subID)
+}
+
+// DeleteSubscription stops the deliveries to a subscription. Its dead letters are kept until deleted.
+func (u *webhookUsecase) DeleteSubscription(ctx context.Context, actor Domain.Actor, id string) error {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return Domain.ErrForbidden
+	}
+	subID, err := parseIDOr(id, Domain.ErrWebhookNotFound)
+	if err != nil {
+		return err
+	}
+	return u.webhookRepo.DeleteSubscription(ctx, subID)
+}
+
+func (u *webhookUsecase) ListDeadLetters(ctx context.Context, actor Domain.Actor) ([]Domain.WebhookDeadLetter, error) {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return nil, Domain.ErrForbidden
+	}
+	return u.webhookRepo.ListDeadLetters(ctx)
+}
+
+// RetryDeadLetter redelivers to the subscription's current URL and secret, so a fixed
+// endpoint can be set up with a new subscription. Letters of deleted subscriptions cannot be retried.
+func (u *webhookUsecase) RetryDeadLetter(ctx context.Context, actor Domain.Actor, id string) error {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return Domain.ErrForbidden
+	}
+	letterID, err := parseIDOr(id, Domain.ErrDeadLetterNotFound)
+	if err != nil {
+		return err
+	}
+	letter, err := u.webhookRepo.GetDeadLetter(ctx, letterID)
+	if err != nil {
+		return err
+	}
+	sub, err := u.webhookRepo.GetSubscription(ctx, letter.SubscriptionID)
+	if err != nil {
+		return err
+	}
+	// Deleting first means two concurrent retries cannot both redeliver
+	if err := u.webhookRepo.DeleteDeadLetter(ctx, letterID); err != nil {
+		return err
+	}
+	if err := u.redeliverer.Redeliver(*sub, *letter); err != nil {
+		// The letter is put back, under a new ID, to be retried later
+		if _, addErr := u.webhookRepo.AddDeadLetter(ctx, *letter); addErr != nil {
+			log.Printf("Failed to put back dead letter %s of event %s: %v", letter.ID, letter.EventID, addErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (u *webhookUsecase) DeleteDeadLetter(ctx context.Context, actor Domain.Actor, id string) error {
+	if !u.policy.Allows(actor, Domain.PermWebhooksAdmin) {
+		return Domain.ErrForbidden
+	}
+	letterID, err := parseIDOr(id, Domain.ErrDeadLetterNotFound)
+	if err != nil {
+		return err
+	}
+	return u.webhookRepo.DeleteDeadLetter(ctx, letterID)
+}
+
+// parseIDOr treats a malformed ID like an unknown one, reported as notFound
+func parseIDOr(id string, notFound error) (Domain.ID, error) {
+	parsed, err := Domain.ParseID(id)
+	if err != nil {
+		return "", notFound
+	}
+	return parsed, nil
+}
@@ -1,21 +1,58 @@
 package main
 
-// package main
-
 import (
-	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"strings"
+	"taskmanager/Domain"
 	"taskmanager/Infrastructure"
 )
 
-// ...existing code...
-func Init() {
-	err := godotenv.Load()
+// loadConfig reads the configuration from the environment, .env and the optional CONFIG_FILE,
+// and logs it with the secrets hidden. An invalid configuration, such as a missing or weak
+// JWT_SECRET, stops the process before anything is served.
+func loadConfig() *Infrastructure.Config {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	config, err := Infrastructure.LoadConfig(env, ".env")
+	if err != nil {
+		log.Fatal("Invalid configuration: ", err)
+	}
+	log.Printf("Configuration:\n%s", config.Redacted())
+	return config
+}
+
+// loadJWTKeys signs with the RS256/EdDSA key in JWT_SIGNING_KEY_FILE when it is set,
+// and with the HS256 JWT_SECRET otherwise. JWT_VERIFICATION_KEY_FILES lists the previous
+// keys whose tokens are still accepted during a rotation.
+func loadJWTKeys(settings Infrastructure.JWTSettings) {
+	if settings.SigningKeyFile == "" {
+		Infrastructure.SetJWTSecret(settings.Secret)
+		return
+	}
+
+	keySet, err := Infrastructure.LoadKeySetFromFiles(settings.SigningKeyFile, settings.VerificationKeyFiles)
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	Infrastructure.SetKeySet(keySet)
+	log.Printf("Signing tokens with %s, %d verification key(s) published", settings.SigningKeyFile, len(keySet.JWKS().Keys))
+}
+
+// loadPolicy reads the role to permissions mapping from ROLES_CONFIG_FILE,
+// e.g. config/roles.json, and uses Domain.DefaultRolePermissions when it is unset
+func loadPolicy(path string) *Domain.Policy {
+	if path == "" {
+		return Domain.DefaultPolicy()
+	}
+	policy, err := Infrastructure.LoadPolicyFile(path)
 	if err != nil {
-		log.Println("Warning: .env file not loaded, environment variables may be missing.")
+		log.Fatal("Failed to load roles config:", err)
 	}
-	// *** CRITICAL DEBUGGING LINE ADDED HERE ***
-	loadedSecret := os.Getenv("JWT_SECRET")
-	Infrastructure.SetJWTSecret(loadedSecret)
+	log.Printf("Loaded roles %v from %s", policy.Roles(), path)
+	return policy
 }
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"taskmanager/Infrastructure"
+)
+
+// newEventBus creates the bus the task usecase publishes to. It passes every event on to the listeners,
+// such as the webhook dispatcher, and keeps the last EVENT_REPLAY_SIZE (default 1000) events so
+// GET /tasks/stream clients can resume after a reconnect.
+func newEventBus(settings Infrastructure.EventSettings, listeners ...Infrastructure.TaskEventListener) *Infrastructure.EventBus {
+	return Infrastructure.NewEventBus(settings.ReplaySize, listeners...)
+}
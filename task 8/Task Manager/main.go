@@ -1,55 +1,119 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"taskmanager/Delivery/controllers"
 	"taskmanager/Delivery/routers"
-	Infrasturcture "taskmanager/Infrastructure"
-	"taskmanager/Repositories"
+	"taskmanager/Domain"
+	"taskmanager/Infrastructure"
 	"taskmanager/Usecases"
 )
 
 func main() {
 	log.Println("Starting Task Manager...")
-	// Initialize MongoDB client using Infrastructure package
-	Init()
-	mongoURI := os.Getenv("MONGODB_URI")
-	dbName := os.Getenv("DATABASE_NAME")
-	// log.Println("MongoDB URI:", mongoURI)
-	tasksCollection := os.Getenv("TASKS_COLLECTION")
-	usersCollection := os.Getenv("USERS_COLLECTION")
-	mongoClient, err := Infrasturcture.NewMongoDBClient(mongoURI)
-	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
+	config := loadConfig()
+	loadJWTKeys(config.JWT)
+	if err := run(config); err != nil {
+		log.Fatal(err)
 	}
-	defer func() {
-		if err := mongoClient.Disconnect(); err != nil {
-			log.Fatal("Failed to disconnect MongoDB:", err)
-		}
-	}()
-
-	// Initialize repositories with collections from Infrastructure
-	taskCollection := mongoClient.GetCollection(dbName, tasksCollection)
-	userCollection := mongoClient.GetCollection(dbName, usersCollection)
+	log.Println("Task Manager stopped")
+}
 
-	taskRepo := Repositories.NewMongoTaskRepository(&Repositories.MongoCollectionAdapter{Coll: taskCollection})
-	userRepo := Repositories.NewMongoUserRepository(&Repositories.UserMongoCollectionAdapter{Coll: userCollection})
+// run serves the API until SIGINT or SIGTERM. It then lets the requests in flight finish
+// within SHUTDOWN_TIMEOUT (default 30s), stops the background work and closes the storage.
+func run(config *Infrastructure.Config) error {
+	// Initialize repositories for the configured storage backend
+	store := newStorage(config)
+	defer store.close()
 
 	// Initialize usecases
-	taskUsecase := Usecases.NewTaskUsecase(taskRepo)
-	userUsecase := Usecases.NewUserUsecase(userRepo)
+	policy := loadPolicy(config.RolesFile)
+	webhooks := newWebhookDispatcher(config.Webhooks, store)
+	events := newEventBus(config.Events, webhooks)
+	taskUsecase := Usecases.NewTaskUsecase(store.taskRepo, store.auditRepo, store.userRepo, policy, Usecases.WithEventPublisher(events))
+	userUsecase := Usecases.NewUserUsecase(store.userRepo, Infrastructure.NewBcryptPasswordService(), policy)
+	authUsecase := Usecases.NewAuthUsecase(userUsecase, store.tokenRepo, Infrastructure.NewJWTTokenService())
+	commentUsecase := Usecases.NewCommentUsecase(store.commentRepo, store.taskRepo, policy)
+	webhookUsecase := Usecases.NewWebhookUsecase(store.webhookRepo, webhooks, policy)
+	streamUsecase := Usecases.NewTaskStreamUsecase(events, policy)
+	bootstrapAdmin(config.Admin, userUsecase)
+	reminders := newReminderScheduler(config.Reminders, store)
 
 	// Initialize controllers
-	ctrl := controllers.NewController(userUsecase, taskUsecase)
+	ctrl := controllers.NewController(userUsecase, taskUsecase, authUsecase, commentUsecase, webhookUsecase, streamUsecase, policy)
 
 	// Setup router
-	r := routers.SetupRouter(ctrl)
+	server := newHTTPServer(config.HTTP, routers.SetupRouter(ctrl, store.readiness))
 
-	// Start server
-	if err := http.ListenAndServe(":8080", r); err != nil {
-		log.Fatal("Failed to start server:", err)
+	// Start server and background work
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Println("Listening on", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+	webhooks.Start()
+	if reminders != nil {
+		reminders.Start()
+	}
+
+	var err error
+	select {
+	case err = <-serverErr:
+		err = fmt.Errorf("failed to start server: %w", err)
+	case <-ctx.Done():
+	}
+	// A second signal kills the process right away
+	stop()
+	log.Println("Shutting down Task Manager...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	// Task streams never finish on their own, end them so the server can drain
+	events.Close()
+	if err == nil {
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			log.Println("HTTP requests did not finish in time:", shutdownErr)
+		}
+	}
+	// Stopped after the server, so the events of the drained requests are still delivered
+	if reminders != nil {
+		if stopErr := reminders.Stop(shutdownCtx); stopErr != nil {
+			log.Println("Reminder scheduler did not stop in time:", stopErr)
+		}
+	}
+	if stopErr := webhooks.Stop(shutdownCtx); stopErr != nil {
+		log.Println("Webhook deliveries did not finish in time:", stopErr)
+	}
+	return err
+}
+
+// bootstrapAdmin creates the first admin from ADMIN_USERNAME and ADMIN_PASSWORD (and optional ADMIN_EMAIL).
+// Nothing happens if ADMIN_USERNAME is unset or that user already exists.
+func bootstrapAdmin(settings Infrastructure.AdminSettings, userUsecase Usecases.UserUsecase) {
+	if settings.Username == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	created, err := userUsecase.BootstrapAdmin(ctx, Domain.User{
+		Username: settings.Username,
+		Password: settings.Password,
+		Email:    settings.Email,
+	})
+	if err != nil {
+		log.Fatal("Failed to create admin user:", err)
+	}
+	if created {
+		log.Printf("Created admin user %q", settings.Username)
 	}
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"taskmanager/Infrastructure"
+	"taskmanager/Usecases"
+)
+
+// newReminderScheduler sets up the background pass that reminds the owners and assignees of tasks
+// due within REMINDER_WINDOW (24h by default) or overdue, every REMINDER_INTERVAL (1m by default).
+// It returns nil when REMINDER_INTERVAL is 0, which disables reminders.
+func newReminderScheduler(settings Infrastructure.ReminderSettings, store *storage) *Infrastructure.Scheduler {
+	if settings.Interval == 0 {
+		log.Println("Reminders are disabled")
+		return nil
+	}
+	reminderUsecase := Usecases.NewReminderUsecase(store.taskRepo, store.userRepo, store.reminderRepo, newNotifier(settings), settings.Window)
+
+	return Infrastructure.NewScheduler("Reminder pass", settings.Interval, func(ctx context.Context) error {
+		sent, err := reminderUsecase.SendReminders(ctx)
+		if sent > 0 {
+			log.Printf("Sent %d reminder(s)", sent)
+		}
+		return err
+	})
+}
+
+// newNotifier builds the notifier chosen by NOTIFIER: "log" (default), "smtp" or "webhook"
+func newNotifier(settings Infrastructure.ReminderSettings) Usecases.Notifier {
+	switch settings.Notifier {
+	case "smtp":
+		return Infrastructure.NewSMTPNotifier(settings.SMTP)
+	case "webhook":
+		return Infrastructure.NewWebhookNotifier(settings.WebhookURL, nil)
+	default:
+		return Infrastructure.NewLogNotifier(nil)
+	}
+}
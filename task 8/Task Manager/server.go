@@ -0,0 +1,20 @@
+package main
+
+import (
+	"net/http"
+
+	"taskmanager/Infrastructure"
+)
+
+// newHTTPServer serves the handler on HTTP_ADDR (default :8080). HTTP_READ_TIMEOUT (default 15s),
+// HTTP_WRITE_TIMEOUT (default 30s) and HTTP_IDLE_TIMEOUT (default 2m) keep slow or idle clients
+// from holding connections; GET /tasks/stream lifts the write timeout for itself.
+func newHTTPServer(settings Infrastructure.HTTPSettings, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         settings.Addr,
+		Handler:      handler,
+		ReadTimeout:  settings.ReadTimeout,
+		WriteTimeout: settings.WriteTimeout,
+		IdleTimeout:  settings.IdleTimeout,
+	}
+}
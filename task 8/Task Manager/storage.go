@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	Infrasturcture "taskmanager/Infrastructure"
+	"taskmanager/Repositories"
+
+	// SQL drivers available to STORAGE_BACKEND=sql
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// storage bundles the repositories selected by STORAGE_BACKEND
+// together with the function that releases them on shutdown.
+type storage struct {
+	taskRepo     Repositories.TaskRepository
+	userRepo     Repositories.UserRepository
+	tokenRepo    Repositories.TokenRepository
+	auditRepo    Repositories.AuditRepository
+	commentRepo  Repositories.CommentRepository
+	reminderRepo Repositories.ReminderRepository
+	webhookRepo  Repositories.WebhookRepository
+	readiness    map[string]Infrasturcture.ReadinessCheck // checked by GET /readyz
+	close        func()
+}
+
+// newStorage builds the repositories for the configured backend ("mongo" by default, "memory" or "sql")
+func newStorage(config *Infrasturcture.Config) *storage {
+	switch config.Storage.Backend {
+	case "memory":
+		return newMemoryStorage(config.Storage)
+	case "sql":
+		return newSQLStorage(config.Storage)
+	default:
+		return newMongoStorage(config.Mongo)
+	}
+}
+
+func newMongoStorage(settings Infrasturcture.MongoSettings) *storage {
+	dbName := settings.Database
+	mongoClient, err := Infrasturcture.NewMongoDBClient(settings.URI)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+
+	// Initialize repositories with collections from Infrastructure
+	taskCollection := mongoClient.GetCollection(dbName, settings.TasksCollection)
+	userCollection := mongoClient.GetCollection(dbName, settings.UsersCollection)
+
+	refreshTokenCollection := mongoClient.GetCollection(dbName, settings.RefreshTokensCollection)
+	revokedTokenCollection := mongoClient.GetCollection(dbName, settings.RevokedTokensCollection)
+	auditCollection := mongoClient.GetCollection(dbName, settings.AuditCollection)
+	commentCollection := mongoClient.GetCollection(dbName, settings.CommentsCollection)
+	reminderCollection := mongoClient.GetCollection(dbName, settings.RemindersCollection)
+	webhookCollection := mongoClient.GetCollection(dbName, settings.WebhooksCollection)
+	deadLetterCollection := mongoClient.GetCollection(dbName, settings.WebhookDeadLettersCollection)
+
+	userRepo := Repositories.NewMongoUserRepository(&Repositories.UserMongoCollectionAdapter{Coll: userCollection})
+	tokenRepo := Repositories.NewMongoTokenRepository(
+		&Repositories.TokenMongoCollectionAdapter{Coll: refreshTokenCollection},
+		&Repositories.TokenMongoCollectionAdapter{Coll: revokedTokenCollection},
+	)
+	auditRepo := Repositories.NewMongoAuditRepository(&Repositories.AuditMongoCollectionAdapter{Coll: auditCollection})
+	commentRepo := Repositories.NewMongoCommentRepository(&Repositories.CommentMongoCollectionAdapter{Coll: commentCollection})
+	reminderRepo := Repositories.NewMongoReminderRepository(&Repositories.ReminderMongoCollectionAdapter{Coll: reminderCollection})
+	webhookRepo := Repositories.NewMongoWebhookRepository(
+		&Repositories.WebhookMongoCollectionAdapter{Coll: webhookCollection},
+		&Repositories.WebhookMongoCollectionAdapter{Coll: deadLetterCollection},
+	)
+	taskRepo := Repositories.NewMongoTaskRepository(&Repositories.MongoCollectionAdapter{Coll: taskCollection})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := userRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := tokenRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := auditRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := commentRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := taskRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := taskRepo.BackfillVersions(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := reminderRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+	if err := webhookRepo.EnsureIndexes(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	return &storage{
+		taskRepo:     taskRepo,
+		userRepo:     userRepo,
+		tokenRepo:    tokenRepo,
+		auditRepo:    auditRepo,
+		commentRepo:  commentRepo,
+		reminderRepo: reminderRepo,
+		webhookRepo:  webhookRepo,
+		readiness:    map[string]Infrasturcture.ReadinessCheck{"mongo": mongoClient.Ping},
+		close: func() {
+			if err := mongoClient.Disconnect(); err != nil {
+				log.Println("Failed to disconnect MongoDB:", err)
+			}
+		},
+	}
+}
+
+// newMemoryStorage keeps everything in process memory.
+// When MEMORY_SNAPSHOT_FILE is set the data is loaded from it at startup and written back on shutdown.
+// Tokens are not part of the snapshot, so users have to log in again after a restart.
+func newMemoryStorage(settings Infrasturcture.StorageSettings) *storage {
+	stores := Repositories.MemoryStores{
+		Tasks:     Repositories.NewMemoryTaskRepository(),
+		Users:     Repositories.NewMemoryUserRepository(),
+		Audit:     Repositories.NewMemoryAuditRepository(),
+		Reminders: Repositories.NewMemoryReminderRepository(),
+		Comments:  Repositories.NewMemoryCommentRepository(),
+		Webhooks:  Repositories.NewMemoryWebhookRepository(),
+	}
+	snapshotFile := settings.MemorySnapshotFile
+	if snapshotFile != "" {
+		if err := Repositories.LoadMemorySnapshot(snapshotFile, stores); err != nil {
+			log.Fatal("Failed to load memory snapshot:", err)
+		}
+	}
+	log.Println("Using in-memory storage, data is not shared between instances")
+
+	return &storage{
+		taskRepo:     stores.Tasks,
+		userRepo:     stores.Users,
+		tokenRepo:    Repositories.NewMemoryTokenRepository(),
+		auditRepo:    stores.Audit,
+		commentRepo:  stores.Comments,
+		reminderRepo: stores.Reminders,
+		webhookRepo:  stores.Webhooks,
+		close: func() {
+			if snapshotFile == "" {
+				return
+			}
+			if err := Repositories.SaveMemorySnapshot(snapshotFile, stores); err != nil {
+				log.Println("Failed to save memory snapshot:", err)
+				return
+			}
+			log.Println("Saved memory snapshot to", snapshotFile)
+		},
+	}
+}
+
+// newSQLStorage connects to SQL_DSN with SQL_DRIVER ("sqlite3" by default, or "postgres")
+// and applies the embedded schema migrations before serving.
+func newSQLStorage(settings Infrasturcture.StorageSettings) *storage {
+	driver, dsn := settings.SQLDriver, settings.SQLDSN
+	dialect, err := Repositories.DialectForDriver(driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		log.Fatal("Failed to open SQL database:", err)
+	}
+	if dialect == Repositories.DialectSQLite {
+		// SQLite only allows a single writer at a time
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := Repositories.MigrateSQL(ctx, db, dialect); err != nil {
+		log.Fatal("Failed to migrate SQL database:", err)
+	}
+
+	return &storage{
+		taskRepo:     Repositories.NewSQLTaskRepository(db, dialect),
+		userRepo:     Repositories.NewSQLUserRepository(db, dialect),
+		tokenRepo:    Repositories.NewSQLTokenRepository(db, dialect),
+		auditRepo:    Repositories.NewSQLAuditRepository(db, dialect),
+		commentRepo:  Repositories.NewSQLCommentRepository(db, dialect),
+		reminderRepo: Repositories.NewSQLReminderRepository(db, dialect),
+		webhookRepo:  Repositories.NewSQLWebhookRepository(db, dialect),
+		readiness:    map[string]Infrasturcture.ReadinessCheck{"sql": db.PingContext},
+		close: func() {
+			if err := db.Close(); err != nil {
+				log.Println("Failed to close SQL database:", err)
+			}
+		},
+	}
+}
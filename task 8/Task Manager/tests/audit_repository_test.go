@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"context"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditMockCollection is a mock for the AuditCollection interface
+type AuditMockCollection struct{ mock.Mock }
+
+func (m *AuditMockCollection) InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*Repositories.InsertOneResult, error) {
+	args := m.Called(ctx, doc)
+	return args.Get(0).(*Repositories.InsertOneResult), args.Error(1)
+}
+
+func (m *AuditMockCollection) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.Cursor, error) {
+	args := m.Called(ctx, filter, opts)
+	return args.Get(0).(Repositories.Cursor), args.Error(1)
+}
+
+func (m *AuditMockCollection) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	return m.Called(ctx, models).Error(0)
+}
+
+// AuditMockCursor iterates over a fixed slice of audit entities
+type AuditMockCursor struct {
+	entities []Repositories.AuditEventEntity
+	pos      int
+}
+
+func (c *AuditMockCursor) Next(context.Context) bool {
+	c.pos++
+	return c.pos <= len(c.entities)
+}
+
+func (c *AuditMockCursor) Decode(val interface{}) error {
+	*(val.(*Repositories.AuditEventEntity)) = c.entities[c.pos-1]
+	return nil
+}
+
+func (c *AuditMockCursor) Close(context.Context) error { return nil }
+
+func (c *AuditMockCursor) Err() error { return nil }
+
+func TestMongoAuditRepository_AddEvent(t *testing.T) {
+	mockColl := new(AuditMockCollection)
+	repo := Repositories.NewMongoAuditRepository(mockColl)
+	var inserted Repositories.AuditEventEntity
+	mockColl.On("InsertOne", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { inserted = args.Get(1).(Repositories.AuditEventEntity) }).
+		Return(&Repositories.InsertOneResult{}, nil)
+
+	taskID := Domain.NewID()
+	event, err := repo.AddEvent(context.Background(), Domain.AuditEvent{
+		TaskID:  taskID,
+		Action:  Domain.AuditUpdated,
+		ActorID: Domain.NewID(),
+		At:      time.Now(),
+		Changes: []Domain.FieldChange{{Field: "status", Before: "Pending", After: "Completed"}},
+	})
+
+	require.NoError(t, err)
+	assert.False(t, event.ID.IsZero())
+	assert.Equal(t, taskID, event.TaskID)
+	assert.Equal(t, []Repositories.FieldChangeEntity{{Field: "status", Before: "Pending", After: "Completed"}}, inserted.Changes)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoAuditRepository_ListTaskEvents(t *testing.T) {
+	mockColl := new(AuditMockCollection)
+	repo := Repositories.NewMongoAuditRepository(mockColl)
+	taskID := primitive.NewObjectID()
+	entities := []Repositories.AuditEventEntity{
+		{ID: primitive.NewObjectID(), TaskID: taskID, Action: "created"},
+		{ID: primitive.NewObjectID(), TaskID: taskID, Action: "updated"},
+	}
+	var gotOpts *options.FindOptions
+	mockColl.On("Find", mock.Anything, bson.M{"task_id": taskID}, mock.Anything).
+		Run(func(args mock.Arguments) { gotOpts = args.Get(2).([]interface{})[0].(*options.FindOptions) }).
+		Return(&AuditMockCursor{entities: entities}, nil)
+
+	events, err := repo.ListTaskEvents(context.Background(), Repositories.DomainIDFromObjectID(taskID))
+
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, Domain.AuditCreated, events[0].Action)
+	assert.Equal(t, Domain.AuditUpdated, events[1].Action)
+	assert.Equal(t, bson.D{{Key: "at", Value: 1}, {Key: "_id", Value: 1}}, gotOpts.Sort)
+	mockColl.AssertExpectations(t)
+}
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"taskmanager/Delivery/middleware"
+	"taskmanager/Domain"
+	"taskmanager/Infrastructure"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// revokedSet implements Infrastructure.RevocationChecker
+type revokedSet map[string]bool
+
+func (s revokedSet) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s[jti], nil
+}
+
+func newAuthTestRouter(revoked revokedSet) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/protected", Infrastructure.AuthenticateJWT(revoked), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetString("user_id"), "jti": c.GetString("jti")})
+	})
+	return r
+}
+
+func doAuthRequest(r *gin.Engine, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthenticateJWT_AcceptsValidToken(t *testing.T) {
+	Infrastructure.SetJWTSecret("test-secret")
+	token, err := Infrastructure.GenerateToken("507f1f77bcf86cd799439011", "alice", "user", "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, token.JTI)
+
+	w := doAuthRequest(newAuthTestRouter(revokedSet{}), token.Token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), token.JTI)
+}
+
+func TestAuthenticateJWT_RejectsRevokedToken(t *testing.T) {
+	Infrastructure.SetJWTSecret("test-secret")
+	token, err := Infrastructure.GenerateToken("507f1f77bcf86cd799439011", "alice", "user", "")
+	require.NoError(t, err)
+
+	w := doAuthRequest(newAuthTestRouter(revokedSet{token.JTI: true}), token.Token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticateJWT_RejectsTokenWithoutJTI(t *testing.T) {
+	Infrastructure.SetJWTSecret("test-secret")
+	// Shaped like the tokens issued before jti was introduced
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":  "507f1f77bcf86cd799439011",
+		"username": "alice",
+		"role":     "user",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test-secret"))
+	require.NoError(t, err)
+
+	w := doAuthRequest(newAuthTestRouter(revokedSet{}), token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthorizeRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/users", func(c *gin.Context) {
+		c.Set("role", c.GetHeader("X-Test-Role"))
+	}, Infrastructure.AuthorizeRole("admin"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for role, expected := range map[string]int{"admin": http.StatusOK, "user": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-Test-Role", role)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, expected, w.Code, role)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/users", func(c *gin.Context) {
+		c.Set("role", c.GetHeader("X-Test-Role"))
+	}, Infrastructure.RequirePermission(Domain.DefaultPolicy(), Domain.PermUsersAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	cases := map[string]int{"admin": http.StatusOK, "manager": http.StatusForbidden, "user": http.StatusForbidden, "": http.StatusForbidden}
+	for role, expected := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("X-Test-Role", role)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, expected, w.Code, role)
+	}
+}
+
+func TestAuthenticateJWT_SetsTeam(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/protected", Infrastructure.AuthenticateJWT(revokedSet{}), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("team"))
+	})
+	token, err := Infrastructure.GenerateToken("507f1f77bcf86cd799439011", "alice", "manager", "platform")
+	require.NoError(t, err)
+
+	w := doAuthRequest(r, token.Token)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "platform", w.Body.String())
+}
+
+func TestLoadPolicyFile(t *testing.T) {
+	// The sample configuration matches the built-in defaults
+	policy, err := Infrastructure.LoadPolicyFile("../config/roles.json")
+	require.NoError(t, err)
+	assert.Equal(t, Domain.DefaultPolicy(), policy)
+
+	_, err = Infrastructure.LoadPolicyFile("../config/missing.json")
+	assert.Error(t, err)
+}
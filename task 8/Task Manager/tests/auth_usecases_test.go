@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"taskmanager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenService issues predictable tokens and "hashes" by prefixing
+type fakeTokenService struct{ issued int }
+
+func (s *fakeTokenService) GenerateAccessToken(user Domain.User) (Domain.AccessToken, error) {
+	s.issued++
+	return Domain.AccessToken{
+		Token:     fmt.Sprintf("access-%d", s.issued),
+		JTI:       fmt.Sprintf("jti-%d", s.issued),
+		ExpiresAt: time.Now().Add(15 * time.Minute),
+	}, nil
+}
+
+func (s *fakeTokenService) GenerateRefreshToken() (string, error) {
+	return fmt.Sprintf("refresh-%d", s.issued), nil
+}
+
+func (s *fakeTokenService) HashRefreshToken(token string) string {
+	return "hashed:" + token
+}
+
+func newTestAuthUsecase(t *testing.T) (Usecases.AuthUsecase, *MockUserRepository, *Repositories.MemoryTokenRepository, Domain.User) {
+	t.Helper()
+	mockRepo := new(MockUserRepository)
+	tokenRepo := Repositories.NewMemoryTokenRepository()
+	user := Domain.User{ID: Domain.NewID(), Username: "testuser", Password: "hashed:pass", Role: "user"}
+	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&user, nil).Maybe()
+	mockRepo.On("GetUserByID", mock.Anything, user.ID).Return(&user, nil).Maybe()
+	userUsecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	return Usecases.NewAuthUsecase(userUsecase, tokenRepo, &fakeTokenService{}), mockRepo, tokenRepo, user
+}
+
+func TestLogin_IssuesTokenPair(t *testing.T) {
+	usecase, _, _, _ := newTestAuthUsecase(t)
+	pair, err := usecase.Login(context.Background(), "testuser", "pass")
+	require.NoError(t, err)
+	assert.Equal(t, "access-1", pair.AccessToken.Token)
+	assert.Equal(t, "refresh-1", pair.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(Usecases.RefreshTokenTTL), pair.RefreshExpiresAt, time.Minute)
+}
+
+func TestLogin_WrongPassword(t *testing.T) {
+	usecase, _, _, _ := newTestAuthUsecase(t)
+	pair, err := usecase.Login(context.Background(), "testuser", "wrong")
+	assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
+	assert.Nil(t, pair)
+}
+
+func TestRefreshToken_RotatesToken(t *testing.T) {
+	usecase, _, _, _ := newTestAuthUsecase(t)
+	ctx := context.Background()
+	pair, err := usecase.Login(ctx, "testuser", "pass")
+	require.NoError(t, err)
+
+	refreshed, err := usecase.RefreshToken(ctx, pair.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, "access-2", refreshed.AccessToken.Token)
+	assert.Equal(t, "refresh-2", refreshed.RefreshToken)
+
+	// The old refresh token was consumed
+	_, err = usecase.RefreshToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefreshToken)
+}
+
+func TestRefreshToken_Expired(t *testing.T) {
+	usecase, _, tokenRepo, user := newTestAuthUsecase(t)
+	ctx := context.Background()
+	require.NoError(t, tokenRepo.SaveRefreshToken(ctx, Domain.RefreshToken{
+		TokenHash: "hashed:old",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}))
+	_, err := usecase.RefreshToken(ctx, "old")
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefreshToken)
+}
+
+func TestRefreshToken_DeletedUser(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	tokenRepo := Repositories.NewMemoryTokenRepository()
+	userID := Domain.NewID()
+	mockRepo.On("GetUserByID", mock.Anything, userID).Return(nil, Domain.ErrUserNotFound)
+	usecase := Usecases.NewAuthUsecase(Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy()), tokenRepo, &fakeTokenService{})
+	ctx := context.Background()
+	require.NoError(t, tokenRepo.SaveRefreshToken(ctx, Domain.RefreshToken{
+		TokenHash: "hashed:token",
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+	_, err := usecase.RefreshToken(ctx, "token")
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefreshToken)
+}
+
+func TestLogout_RevokesTokens(t *testing.T) {
+	usecase, _, _, user := newTestAuthUsecase(t)
+	ctx := context.Background()
+	pair, err := usecase.Login(ctx, "testuser", "pass")
+	require.NoError(t, err)
+
+	actor := Domain.Actor{UserID: user.ID, Username: user.Username, Role: user.Role}
+	require.NoError(t, usecase.Logout(ctx, actor, pair.AccessToken, pair.RefreshToken))
+
+	revoked, err := usecase.IsTokenRevoked(ctx, pair.AccessToken.JTI)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = usecase.RefreshToken(ctx, pair.RefreshToken)
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefreshToken)
+}
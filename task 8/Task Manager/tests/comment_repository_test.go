@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CommentMockCollection is a mock for the CommentCollection interface
+type CommentMockCollection struct{ mock.Mock }
+
+func (m *CommentMockCollection) InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*Repositories.InsertOneResult, error) {
+	args := m.Called(ctx, doc)
+	return args.Get(0).(*Repositories.InsertOneResult), args.Error(1)
+}
+
+func (m *CommentMockCollection) FindOne(ctx context.Context, filter interface{}, opts ...interface{}) Repositories.SingleResult {
+	return m.Called(ctx, filter).Get(0).(Repositories.SingleResult)
+}
+
+func (m *CommentMockCollection) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.Cursor, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(Repositories.Cursor), args.Error(1)
+}
+
+func (m *CommentMockCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) Repositories.SingleResult {
+	return m.Called(ctx, filter, update).Get(0).(Repositories.SingleResult)
+}
+
+func (m *CommentMockCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.DeleteResult, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(Repositories.DeleteResult), args.Error(1)
+}
+
+func (m *CommentMockCollection) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	return m.Called(ctx, models).Error(0)
+}
+
+type CommentMockSingleResult struct {
+	entity Repositories.CommentEntity
+	err    error
+}
+
+func (m *CommentMockSingleResult) Decode(val interface{}) error {
+	if m.err != nil {
+		return m.err
+	}
+	*(val.(*Repositories.CommentEntity)) = m.entity
+	return nil
+}
+
+func TestMongoCommentRepository_AddComment(t *testing.T) {
+	mockColl := new(CommentMockCollection)
+	repo := Repositories.NewMongoCommentRepository(mockColl)
+	insertedID := primitive.NewObjectID()
+	mockColl.On("InsertOne", mock.Anything, mock.Anything).Return(&Repositories.InsertOneResult{InsertedID: insertedID}, nil)
+
+	taskID := Domain.NewID()
+	comment, err := repo.AddComment(context.Background(), Domain.Comment{TaskID: taskID, AuthorID: Domain.NewID(), Body: "Hello", CreatedAt: time.Now()})
+
+	require.NoError(t, err)
+	assert.Equal(t, Repositories.DomainIDFromObjectID(insertedID), comment.ID)
+	assert.Equal(t, taskID, comment.TaskID)
+	assert.Equal(t, "Hello", comment.Body)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoCommentRepository_UpdateComment_NotFound(t *testing.T) {
+	mockColl := new(CommentMockCollection)
+	repo := Repositories.NewMongoCommentRepository(mockColl)
+	id := primitive.NewObjectID()
+	mockColl.On("FindOneAndUpdate", mock.Anything, bson.M{"_id": id}, mock.Anything).Return(&CommentMockSingleResult{err: mongo.ErrNoDocuments})
+
+	_, err := repo.UpdateComment(context.Background(), Domain.Comment{ID: Repositories.DomainIDFromObjectID(id), Body: "Edited"})
+
+	assert.ErrorIs(t, err, Domain.ErrCommentNotFound)
+}
+
+func TestMongoCommentRepository_DeleteComment_NotFound(t *testing.T) {
+	mockColl := new(CommentMockCollection)
+	repo := Repositories.NewMongoCommentRepository(mockColl)
+	mockColl.On("DeleteOne", mock.Anything, mock.Anything).Return(&MockDeleteResult{deleted: 0}, nil)
+
+	err := repo.DeleteComment(context.Background(), Domain.NewID())
+
+	assert.ErrorIs(t, err, Domain.ErrCommentNotFound)
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"context"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"taskmanager/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCommentFixture(t *testing.T) (Usecases.CommentUsecase, Domain.Actor, *Domain.Task) {
+	taskRepo := Repositories.NewMemoryTaskRepository()
+	owner := Domain.Actor{UserID: Domain.NewID(), Username: "alice", Role: "user"}
+	task, err := taskRepo.AddTask(context.Background(), Domain.Task{Title: "Discussed", OwnerID: owner.UserID, DueDate: futureDue, Status: Domain.StatusPending})
+	require.NoError(t, err)
+	return Usecases.NewCommentUsecase(Repositories.NewMemoryCommentRepository(), taskRepo, Domain.DefaultPolicy()), owner, task
+}
+
+func TestCommentUsecase_AuthorEditsOwnComment(t *testing.T) {
+	usecase, owner, task := newCommentFixture(t)
+	ctx := context.Background()
+
+	comment, err := usecase.AddComment(ctx, owner, task.ID.String(), Domain.Comment{Body: "First"})
+	require.NoError(t, err)
+	assert.Equal(t, owner.UserID, comment.AuthorID)
+	assert.Equal(t, "alice", comment.AuthorUsername)
+	assert.False(t, comment.IsEdited())
+
+	edited, err := usecase.UpdateComment(ctx, owner, comment.ID.String(), "First, edited")
+	require.NoError(t, err)
+	assert.Equal(t, "First, edited", edited.Body)
+
+	comments, err := usecase.ListComments(ctx, owner, task.ID.String())
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "First, edited", comments[0].Body)
+
+	_, err = usecase.UpdateComment(ctx, owner, comment.ID.String(), "")
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	require.NoError(t, usecase.DeleteComment(ctx, owner, comment.ID.String()))
+}
+
+func TestCommentUsecase_OnlyAuthorOrModeratorChangesComment(t *testing.T) {
+	taskRepo := Repositories.NewMemoryTaskRepository()
+	usecase := Usecases.NewCommentUsecase(Repositories.NewMemoryCommentRepository(), taskRepo, Domain.DefaultPolicy())
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Role: "user", Team: "platform"}
+	task, err := taskRepo.AddTask(ctx, Domain.Task{Title: "Team task", OwnerID: owner.UserID, Team: "platform", DueDate: futureDue, Status: Domain.StatusPending})
+	require.NoError(t, err)
+	comment, err := usecase.AddComment(ctx, owner, task.ID.String(), Domain.Comment{Body: "Mine"})
+	require.NoError(t, err)
+
+	// The manager reads and changes every task of the team, but not the comments of others
+	_, err = usecase.ListComments(ctx, managerActor, task.ID.String())
+	require.NoError(t, err)
+	_, err = usecase.UpdateComment(ctx, managerActor, comment.ID.String(), "Hijacked")
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	assert.ErrorIs(t, usecase.DeleteComment(ctx, managerActor, comment.ID.String()), Domain.ErrForbidden)
+
+	// Admins moderate
+	moderated, err := usecase.UpdateComment(ctx, adminActor, comment.ID.String(), "Removed by a moderator")
+	require.NoError(t, err)
+	assert.Equal(t, owner.UserID, moderated.AuthorID)
+	require.NoError(t, usecase.DeleteComment(ctx, adminActor, comment.ID.String()))
+
+	// Users outside the team do not learn the comment exists
+	outsider := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	comment, err = usecase.AddComment(ctx, owner, task.ID.String(), Domain.Comment{Body: "Again"})
+	require.NoError(t, err)
+	_, err = usecase.UpdateComment(ctx, outsider, comment.ID.String(), "Hijacked")
+	assert.ErrorIs(t, err, Domain.ErrCommentNotFound)
+}
+
+func TestCommentUsecase_HidesUnreadableTasks(t *testing.T) {
+	usecase, _, task := newCommentFixture(t)
+	ctx := context.Background()
+	other := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	_, err := usecase.AddComment(ctx, other, task.ID.String(), Domain.Comment{Body: "Hi"})
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = usecase.ListComments(ctx, other, task.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = usecase.ListComments(ctx, other, "not-an-id")
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, usecase.DeleteComment(ctx, other, "not-an-id"), Domain.ErrCommentNotFound)
+}
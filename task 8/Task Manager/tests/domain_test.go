@@ -1,11 +1,13 @@
 package tests
 
 import (
+	"strings"
 	"taskmanager/Domain"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Test basic Task creation
@@ -16,7 +18,7 @@ func TestTaskCreation(t *testing.T) {
 		DueDate: time.Now().Add(24 * time.Hour),
 	}
 	assert.Equal(t, "Test Task", task.Title)
-	assert.Equal(t, "pending", task.Status)
+	assert.Equal(t, Domain.TaskStatus("pending"), task.Status)
 	assert.NotZero(t, task.DueDate)
 }
 
@@ -36,14 +38,14 @@ func TestUserCreation(t *testing.T) {
 func TestTaskStatusValidation(t *testing.T) {
 	validStatuses := []string{"pending", "completed", "in-progress"}
 	for _, status := range validStatuses {
-		task := Domain.Task{Status: status}
-		assert.Contains(t, validStatuses, task.Status)
+		task := Domain.Task{Status: Domain.TaskStatus(status)}
+		assert.Contains(t, validStatuses, string(task.Status))
 	}
 
 	invalidStatuses := []string{"unknown", "", "done"}
 	for _, status := range invalidStatuses {
-		task := Domain.Task{Status: status}
-		assert.NotContains(t, validStatuses, task.Status)
+		task := Domain.Task{Status: Domain.TaskStatus(status)}
+		assert.NotContains(t, validStatuses, string(task.Status))
 	}
 }
 
@@ -94,3 +96,254 @@ func TestTaskIsOverdueV2(t *testing.T) {
 		})
 	}
 }
+
+func TestParseID(t *testing.T) {
+	id := Domain.NewID()
+	parsed, err := Domain.ParseID(strings.ToUpper(id.String()))
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	for _, invalid := range []string{"", "123", "zzzzzzzzzzzzzzzzzzzzzzzz", id.String() + "00"} {
+		_, err := Domain.ParseID(invalid)
+		assert.ErrorIs(t, err, Domain.ErrInvalidID, invalid)
+	}
+}
+
+func TestNewIDIsOrderedAndUnique(t *testing.T) {
+	seen := make(map[Domain.ID]bool)
+	previous := Domain.NewID()
+	for i := 0; i < 1000; i++ {
+		id := Domain.NewID()
+		assert.False(t, seen[id])
+		assert.Greater(t, id.String(), previous.String())
+		seen[id] = true
+		previous = id
+	}
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := Domain.DefaultPolicy()
+	owner := Domain.NewID()
+	user := Domain.Actor{UserID: owner, Role: Domain.RoleUser, Team: "platform"}
+	manager := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleManager, Team: "platform"}
+	admin := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleAdmin}
+	task := &Domain.Task{OwnerID: owner, Team: "platform"}
+	otherTeam := &Domain.Task{OwnerID: Domain.NewID(), Team: "sales"}
+
+	assert.True(t, policy.CanWriteTask(user, task))
+	assert.False(t, policy.CanReadTask(user, otherTeam))
+	// Managers act on every task of their team, but not on other teams
+	assert.True(t, policy.CanWriteTask(manager, task))
+	assert.False(t, policy.CanReadTask(manager, otherTeam))
+	assert.True(t, policy.CanWriteTask(admin, otherTeam))
+
+	assert.False(t, policy.Allows(manager, Domain.PermUsersAdmin))
+	assert.True(t, policy.Allows(admin, Domain.PermUsersAdmin))
+	assert.False(t, policy.Allows(Domain.Actor{Role: "unknown"}, Domain.PermTasksRead))
+}
+
+func TestPolicy_Assignee(t *testing.T) {
+	policy := Domain.DefaultPolicy()
+	assignee := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleUser}
+	task := &Domain.Task{OwnerID: Domain.NewID(), AssigneeIDs: []Domain.ID{assignee.UserID}}
+
+	// Assignees work on the task, but deleting and assigning it stay with the owner
+	assert.True(t, policy.CanReadTask(assignee, task))
+	assert.True(t, policy.CanUpdateTask(assignee, task))
+	assert.False(t, policy.CanWriteTask(assignee, task))
+	assert.False(t, policy.CanUpdateTask(assignee, &Domain.Task{OwnerID: task.OwnerID}))
+}
+
+func TestPolicy_ManagerWithoutTeam(t *testing.T) {
+	policy := Domain.DefaultPolicy()
+	manager := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleManager}
+
+	// An empty team never matches the tasks of users outside any team
+	assert.False(t, policy.CanReadTask(manager, &Domain.Task{OwnerID: Domain.NewID()}))
+	query, err := policy.ScopeTaskQuery(manager, Domain.TaskQuery{Team: "sales"})
+	assert.NoError(t, err)
+	assert.Equal(t, Domain.TaskQuery{OwnerID: manager.UserID}, query)
+}
+
+func TestNewPolicy_Validation(t *testing.T) {
+	_, err := Domain.NewPolicy(map[string][]Domain.Permission{Domain.RoleAdmin: {Domain.PermUsersAdmin}})
+	assert.Error(t, err, "the user role is required")
+
+	_, err = Domain.NewPolicy(map[string][]Domain.Permission{Domain.RoleUser: {"tasks:delete"}})
+	assert.Error(t, err, "unknown permission")
+
+	policy, err := Domain.NewPolicy(map[string][]Domain.Permission{Domain.RoleUser: {Domain.PermTasksRead}, "auditor": {Domain.PermTasksReadAny}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"auditor", "user"}, policy.Roles())
+	_, err = policy.ScopeTaskQuery(Domain.Actor{Role: "nobody"}, Domain.TaskQuery{})
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+}
+
+func TestDomainErrorKinds(t *testing.T) {
+	assert.ErrorIs(t, Domain.ErrTaskNotFound, Domain.ErrNotFound)
+	assert.ErrorIs(t, Domain.ErrEmailTaken, Domain.ErrConflict)
+	assert.ErrorIs(t, Domain.ErrInvalidID, Domain.ErrValidation)
+	assert.NotErrorIs(t, Domain.ErrUserNotFound, Domain.ErrConflict)
+
+	verr := &Domain.ValidationError{}
+	assert.NoError(t, verr.OrNil())
+	verr.Add("title", "is required")
+	err := verr.OrNil()
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	assert.Equal(t, "validation failed: title: is required", err.Error())
+}
+
+func TestTaskQueryValidate_ReportsEveryField(t *testing.T) {
+	query := Domain.TaskQuery{Sort: "priority", Limit: 500}
+	err := query.Validate()
+
+	var verr *Domain.ValidationError
+	require.ErrorAs(t, err, &verr)
+	require.Len(t, verr.Fields, 2)
+	assert.Equal(t, "sort", verr.Fields[0].Field)
+	assert.Equal(t, "limit", verr.Fields[1].Field)
+}
+
+func TestTaskValidate(t *testing.T) {
+	due := time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		task   Domain.Task
+		fields []string
+	}{
+		{"Valid", Domain.Task{Title: "Write docs", DueDate: due, Status: Domain.StatusPending}, nil},
+		{"Blank title", Domain.Task{Title: "   ", DueDate: due, Status: Domain.StatusPending}, []string{"title"}},
+		{"Long title", Domain.Task{Title: strings.Repeat("é", Domain.MaxTaskTitleLength+1), DueDate: due, Status: Domain.StatusPending}, []string{"title"}},
+		{"Max title", Domain.Task{Title: strings.Repeat("é", Domain.MaxTaskTitleLength), DueDate: due, Status: Domain.StatusPending}, nil},
+		{"Long description", Domain.Task{Title: "x", Description: strings.Repeat("a", Domain.MaxTaskDescriptionLength+1), DueDate: due, Status: Domain.StatusCompleted}, []string{"description"}},
+		{"Priority and labels", Domain.Task{Title: "x", DueDate: due, Status: Domain.StatusPending, Priority: Domain.PriorityUrgent, Labels: []string{"backend", "team/api", "v1.2"}}, nil},
+		{"Bad priority and label", Domain.Task{Title: "x", DueDate: due, Status: Domain.StatusPending, Priority: "asap", Labels: []string{"two words"}}, []string{"priority", "labels"}},
+		{"Everything wrong", Domain.Task{Status: "done"}, []string{"title", "due_date", "status"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.task.Validate()
+			if tc.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var verr *Domain.ValidationError
+			require.ErrorAs(t, err, &verr)
+			var fields []string
+			for _, f := range verr.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tc.fields, fields)
+		})
+	}
+}
+
+func TestTaskValidateNew_DueDate(t *testing.T) {
+	now := time.Date(2030, 5, 1, 15, 30, 0, 0, time.UTC)
+	task := Domain.Task{Title: "Write docs", Status: Domain.StatusPending}
+
+	// Due dates are whole days, so today is still accepted in the afternoon
+	task.DueDate = time.Date(2030, 5, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, task.ValidateNew(now))
+
+	task.DueDate = time.Date(2030, 4, 30, 0, 0, 0, 0, time.UTC)
+	assert.ErrorIs(t, task.ValidateNew(now), Domain.ErrValidation)
+	// An existing task may be overdue
+	assert.NoError(t, task.Validate())
+}
+
+func TestTaskStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from, to Domain.TaskStatus
+		allowed  bool
+	}{
+		{"", Domain.StatusPending, true},
+		{"", Domain.StatusInProgress, true},
+		{"", Domain.StatusCompleted, false},
+		{Domain.StatusPending, Domain.StatusInProgress, true},
+		{Domain.StatusPending, Domain.StatusCompleted, true},
+		{Domain.StatusInProgress, Domain.StatusPending, true},
+		{Domain.StatusCompleted, Domain.StatusPending, true},
+		{Domain.StatusCompleted, Domain.StatusCompleted, true},
+		{Domain.StatusPending, "Blocked", false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.allowed, tc.from.CanTransitionTo(tc.to), "%q -> %q", tc.from, tc.to)
+	}
+}
+
+func TestPolicy_CheckStatusChange(t *testing.T) {
+	policy := Domain.DefaultPolicy()
+	user := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleUser}
+	manager := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleManager, Team: "platform"}
+	admin := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleAdmin}
+
+	assert.NoError(t, policy.CheckStatusChange(user, Domain.StatusPending, Domain.StatusCompleted))
+	assert.ErrorIs(t, policy.CheckStatusChange(user, "", Domain.StatusCompleted), Domain.ErrInvalidStatusTransition)
+
+	// Reopening a completed task is reserved to roles with tasks:reopen, admins by default
+	assert.ErrorIs(t, policy.CheckStatusChange(user, Domain.StatusCompleted, Domain.StatusPending), Domain.ErrForbidden)
+	assert.ErrorIs(t, policy.CheckStatusChange(manager, Domain.StatusCompleted, Domain.StatusInProgress), Domain.ErrForbidden)
+	assert.NoError(t, policy.CheckStatusChange(admin, Domain.StatusCompleted, Domain.StatusPending))
+}
+
+func TestDiffTasks(t *testing.T) {
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := Domain.Task{Title: "Title", Description: "Same", DueDate: due, Status: Domain.StatusPending}
+	after := before
+	after.Status = Domain.StatusCompleted
+	after.DueDate = due.AddDate(0, 0, 1)
+
+	assert.Equal(t, []Domain.FieldChange{
+		{Field: "due_date", Before: "2030-01-01T00:00:00Z", After: "2030-01-02T00:00:00Z"},
+		{Field: "status", Before: "Pending", After: "Completed"},
+	}, Domain.DiffTasks(before, after))
+	assert.Empty(t, Domain.DiffTasks(before, before))
+
+	// A created task has nothing before
+	created := Domain.NewAuditEvent(Domain.AuditCreated, Domain.Actor{UserID: Domain.NewID(), Username: "alice"}, due, Domain.Task{}, before)
+	assert.Len(t, created.Changes, 4)
+	assert.Equal(t, "", created.Changes[0].Before)
+	assert.Equal(t, "alice", created.ActorUsername)
+}
+
+func TestCommentValidate(t *testing.T) {
+	assert.NoError(t, (&Domain.Comment{Body: "Looks good"}).Validate())
+	assert.ErrorIs(t, (&Domain.Comment{Body: "  "}).Validate(), Domain.ErrValidation)
+	assert.ErrorIs(t, (&Domain.Comment{Body: strings.Repeat("é", Domain.MaxCommentLength+1)}).Validate(), Domain.ErrValidation)
+	assert.NoError(t, (&Domain.Comment{Body: strings.Repeat("é", Domain.MaxCommentLength)}).Validate())
+}
+
+func TestPolicy_CanChangeComment(t *testing.T) {
+	policy := Domain.DefaultPolicy()
+	author := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleUser}
+	other := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleManager}
+	admin := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleAdmin}
+	comment := &Domain.Comment{AuthorID: author.UserID}
+
+	assert.True(t, policy.CanChangeComment(author, comment))
+	assert.False(t, policy.CanChangeComment(other, comment))
+	assert.True(t, policy.CanChangeComment(admin, comment))
+	assert.False(t, policy.CanChangeComment(Domain.Actor{Role: Domain.RoleUser}, &Domain.Comment{}))
+}
+
+func TestNormalizeLabels(t *testing.T) {
+	assert.Equal(t, []string{"backend", "bug"}, Domain.NormalizeLabels([]string{" Backend", "bug", "", "BACKEND "}))
+	assert.Nil(t, Domain.NormalizeLabels([]string{"  "}))
+	assert.False(t, Domain.IsValidLabel(`say "hi"`))
+	assert.False(t, Domain.IsValidLabel(strings.Repeat("a", Domain.MaxLabelLength+1)))
+}
+
+func TestTaskAssignUnassign(t *testing.T) {
+	alice, bob := Domain.NewID(), Domain.NewID()
+	task := Domain.Task{}
+
+	task.Assign(alice, bob, alice)
+	assert.Equal(t, []Domain.ID{alice, bob}, task.AssigneeIDs)
+	assert.True(t, task.IsAssignedTo(bob))
+
+	task.Unassign(alice, Domain.NewID())
+	assert.Equal(t, []Domain.ID{bob}, task.AssigneeIDs)
+	assert.False(t, task.IsAssignedTo(alice))
+	assert.False(t, task.IsAssignedTo(""))
+}
@@ -0,0 +1,292 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"taskmanager/Delivery/controllers"
+	"taskmanager/Delivery/middleware"
+	"taskmanager/Domain"
+	"taskmanager/Infrastructure"
+	"taskmanager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishEvents(bus *Infrastructure.EventBus, n int) {
+	for i := 0; i < n; i++ {
+		bus.PublishTaskEvent(Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskCreated})
+	}
+}
+
+func seqs(events []Domain.TaskEvent) []uint64 {
+	var out []uint64
+	for _, e := range events {
+		out = append(out, e.Seq)
+	}
+	return out
+}
+
+func TestEventBus_NumbersEventsAndPassesThemOn(t *testing.T) {
+	listener := &recordingPublisher{}
+	bus := Infrastructure.NewEventBus(10, listener)
+	feed := bus.Subscribe(0, false)
+	defer feed.Close()
+
+	publishEvents(bus, 2)
+
+	assert.Equal(t, []uint64{1, 2}, seqs(listener.events))
+	assert.Equal(t, uint64(1), (<-feed.Events).Seq)
+	assert.Equal(t, uint64(2), (<-feed.Events).Seq)
+	assert.Equal(t, uint64(0), feed.Position)
+	assert.Empty(t, feed.Backlog)
+}
+
+func TestEventBus_Resume(t *testing.T) {
+	bus := Infrastructure.NewEventBus(3)
+	publishEvents(bus, 5) // 3, 4 and 5 are buffered
+
+	tests := []struct {
+		name    string
+		after   uint64
+		resume  bool
+		backlog []uint64
+		gap     bool
+	}{
+		{"New stream", 0, false, nil, false},
+		{"Up to date", 5, true, nil, false},
+		{"Buffered", 3, true, []uint64{4, 5}, false},
+		{"Oldest buffered is next", 2, true, []uint64{3, 4, 5}, false},
+		{"Evicted", 1, true, []uint64{3, 4, 5}, true},
+		{"Before a restart", 9, true, []uint64{3, 4, 5}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			feed := bus.Subscribe(tc.after, tc.resume)
+			defer feed.Close()
+			assert.Equal(t, uint64(5), feed.Position)
+			assert.Equal(t, tc.backlog, seqs(feed.Backlog))
+			assert.Equal(t, tc.gap, feed.Gap)
+		})
+	}
+}
+
+func TestEventBus_DropsSlowSubscribers(t *testing.T) {
+	bus := Infrastructure.NewEventBus(10)
+	slow := bus.Subscribe(0, false)
+	publishEvents(bus, 100)
+
+	received := 0
+	for range slow.Events {
+		received++
+	}
+	assert.Less(t, received, 100)
+
+	// The dropped subscriber resumes from its last event and learns it missed some
+	resumed := bus.Subscribe(uint64(received), true)
+	defer resumed.Close()
+	assert.True(t, resumed.Gap)
+}
+
+func TestEventBus_CloseEndsSubscriptions(t *testing.T) {
+	listener := &recordingPublisher{}
+	bus := Infrastructure.NewEventBus(10, listener)
+	feed := bus.Subscribe(0, false)
+
+	bus.Close()
+	_, open := <-feed.Events
+	assert.False(t, open)
+	feed.Close() // closing again is harmless
+
+	_, open = <-bus.Subscribe(0, false).Events
+	assert.False(t, open)
+	publishEvents(bus, 1)
+	assert.Len(t, listener.events, 1)
+}
+
+func TestTaskStreamUsecase_HidesUnreadableTasks(t *testing.T) {
+	bus := Infrastructure.NewEventBus(10)
+	usecase := Usecases.NewTaskStreamUsecase(bus, Domain.DefaultPolicy())
+	alice := Domain.Actor{UserID: Domain.NewID(), Username: "alice", Role: "user"}
+	own := Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskCreated, Task: Domain.Task{Title: "Mine", OwnerID: alice.UserID}}
+	other := Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskCreated, Task: Domain.Task{Title: "Bob's", OwnerID: Domain.NewID()}}
+	bus.PublishTaskEvent(other)
+
+	feed, err := usecase.StreamTasks(alice, "0")
+	require.NoError(t, err)
+	defer feed.Close()
+	assert.Equal(t, []Domain.TaskEvent{{Seq: 1}}, feed.Backlog)
+
+	bus.PublishTaskEvent(own)
+	bus.PublishTaskEvent(other)
+	event := <-feed.Events
+	assert.Equal(t, "Mine", event.Task.Title)
+	assert.Equal(t, uint64(2), event.Seq)
+	assert.Equal(t, Domain.TaskEvent{Seq: 3}, <-feed.Events)
+
+	feed.Close()
+	_, open := <-feed.Events
+	assert.False(t, open)
+}
+
+func TestTaskStreamUsecase_RejectsInvalidLastEventID(t *testing.T) {
+	usecase := Usecases.NewTaskStreamUsecase(Infrastructure.NewEventBus(10), Domain.DefaultPolicy())
+	_, err := usecase.StreamTasks(Domain.Actor{UserID: Domain.NewID(), Role: "user"}, "abc")
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+}
+
+// newStreamTestServer serves GET /tasks/stream for the given user, standing in for AuthenticateJWT
+func newStreamTestServer(bus *Infrastructure.EventBus, userID Domain.ID) *httptest.Server {
+	server := httptest.NewUnstartedServer(newStreamTestRouter(bus, userID))
+	server.Start()
+	return server
+}
+
+func newStreamTestRouter(bus *Infrastructure.EventBus, userID Domain.ID) *gin.Engine {
+	return newExpiringStreamTestRouter(bus, userID, time.Time{})
+}
+
+// newExpiringStreamTestRouter is newStreamTestRouter for a token expiring at tokenExpiresAt, zero for none
+func newExpiringStreamTestRouter(bus *Infrastructure.EventBus, userID Domain.ID, tokenExpiresAt time.Time) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	policy := Domain.DefaultPolicy()
+	ctrl := controllers.NewController(nil, nil, nil, nil, nil, Usecases.NewTaskStreamUsecase(bus, policy), policy)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/tasks/stream", func(c *gin.Context) {
+		c.Set("user_id", userID.String())
+		c.Set("username", "alice")
+		c.Set("role", "user")
+		if !tokenExpiresAt.IsZero() {
+			c.Set("token_expires_at", tokenExpiresAt)
+		}
+	}, ctrl.StreamTasks)
+	return r
+}
+
+// readStreamEvent reads the lines of the next event up to the blank line ending it
+func readStreamEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStreamTasks_SendsServerSentEvents(t *testing.T) {
+	bus := Infrastructure.NewEventBus(10)
+	alice := Domain.NewID()
+	server := newStreamTestServer(bus, alice)
+	defer server.Close()
+	publishEvents(bus, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tasks/stream", nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	body := bufio.NewReader(res.Body)
+	assert.Equal(t, []string{"retry: 3000"}, readStreamEvent(t, body))
+	assert.Equal(t, []string{"id: 1"}, readStreamEvent(t, body))
+
+	bus.PublishTaskEvent(Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskCreated, Task: Domain.Task{ID: Domain.NewID(), Title: "Report", OwnerID: alice}})
+	bus.PublishTaskEvent(Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskDeleted, Task: Domain.Task{ID: Domain.NewID(), Title: "Secret", OwnerID: Domain.NewID()}})
+
+	lines := readStreamEvent(t, body)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id: 2", lines[0])
+	assert.Equal(t, "event: task.created", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "data: {"))
+	assert.Contains(t, lines[2], `"title":"Report"`)
+	assert.Equal(t, []string{"id: 3"}, readStreamEvent(t, body))
+}
+
+func TestStreamTasks_ResumesFromLastEventID(t *testing.T) {
+	bus := Infrastructure.NewEventBus(2)
+	alice := Domain.NewID()
+	server := newStreamTestServer(bus, alice)
+	defer server.Close()
+	for i := 0; i < 3; i++ {
+		bus.PublishTaskEvent(Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskUpdated, Task: Domain.Task{OwnerID: alice}})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tasks/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "0")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	body := bufio.NewReader(res.Body)
+	readStreamEvent(t, body) // retry
+	assert.Equal(t, []string{"event: reset", "data: {}"}, readStreamEvent(t, body))
+	assert.Equal(t, "id: 2", readStreamEvent(t, body)[0])
+	assert.Equal(t, "id: 3", readStreamEvent(t, body)[0])
+}
+
+func TestStreamTasks_OutlivesTheWriteTimeout(t *testing.T) {
+	bus := Infrastructure.NewEventBus(10)
+	alice := Domain.NewID()
+	server := httptest.NewUnstartedServer(newStreamTestRouter(bus, alice))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tasks/stream", nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body := bufio.NewReader(res.Body)
+	readStreamEvent(t, body) // retry
+	readStreamEvent(t, body) // id
+
+	time.Sleep(300 * time.Millisecond)
+	bus.PublishTaskEvent(Domain.TaskEvent{ID: Domain.NewID(), Type: Domain.EventTaskCreated, Task: Domain.Task{OwnerID: alice}})
+	assert.Equal(t, "id: 1", readStreamEvent(t, body)[0])
+}
+
+func TestStreamTasks_EndsWhenTheTokenExpires(t *testing.T) {
+	bus := Infrastructure.NewEventBus(10)
+	server := httptest.NewServer(newExpiringStreamTestRouter(bus, Domain.NewID(), time.Now().Add(200*time.Millisecond)))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/tasks/stream", nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body := bufio.NewReader(res.Body)
+	readStreamEvent(t, body) // retry
+	readStreamEvent(t, body) // id
+
+	// The client reconnects with a fresh token, long before the heartbeat
+	started := time.Now()
+	_, err = body.ReadString('\n')
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Less(t, time.Since(started), 5*time.Second)
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"taskmanager/Infrastructure"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHealthTestRouter(checks map[string]Infrastructure.ReadinessCheck) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/healthz", Infrastructure.HealthzHandler())
+	r.GET("/readyz", Infrastructure.ReadyzHandler(checks))
+	return r
+}
+
+func doHealthRequest(r *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestHealthz(t *testing.T) {
+	w := doHealthRequest(newHealthTestRouter(nil), "/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("dial tcp 10.0.0.5:27017: connection refused") }
+	hanging := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name   string
+		checks map[string]Infrastructure.ReadinessCheck
+		code   int
+		body   string
+	}{
+		{"No checks", nil, http.StatusOK, `{"status":"ok","checks":{}}`},
+		{"Ready", map[string]Infrastructure.ReadinessCheck{"mongo": up}, http.StatusOK, `{"status":"ok","checks":{"mongo":"ok"}}`},
+		{"Database down", map[string]Infrastructure.ReadinessCheck{"mongo": down, "cache": up}, http.StatusServiceUnavailable, `{"status":"unavailable","checks":{"mongo":"unavailable","cache":"ok"}}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doHealthRequest(newHealthTestRouter(tc.checks), "/readyz")
+			assert.Equal(t, tc.code, w.Code)
+			assert.JSONEq(t, tc.body, w.Body.String())
+		})
+	}
+
+	t.Run("Hanging database", func(t *testing.T) {
+		start := time.Now()
+		w := doHealthRequest(newHealthTestRouter(map[string]Infrastructure.ReadinessCheck{"mongo": hanging}), "/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+}
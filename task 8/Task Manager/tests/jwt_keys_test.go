@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"taskmanager/Infrastructure"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsaKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func ed25519KeyPEM(t *testing.T) []byte {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// publicKeyPEM extracts the PKIX public key from a private key PEM
+func publicKeyPEM(t *testing.T, privatePEM []byte) []byte {
+	t.Helper()
+	signing, err := Infrastructure.ParseSigningKeyPEM(privatePEM)
+	require.NoError(t, err)
+	block, _ := pem.Decode(privatePEM)
+	var pub interface{}
+	if signing.Method.Alg() == "RS256" {
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		require.NoError(t, err)
+		pub = &key.PublicKey
+	} else {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		require.NoError(t, err)
+		pub = key.(ed25519.PrivateKey).Public()
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func useKeySet(t *testing.T, signingPEM []byte, verificationPEMs ...[]byte) *Infrastructure.KeySet {
+	t.Helper()
+	signing, err := Infrastructure.ParseSigningKeyPEM(signingPEM)
+	require.NoError(t, err)
+	var verification []*Infrastructure.VerificationKey
+	for _, p := range verificationPEMs {
+		vk, err := Infrastructure.ParseVerificationKeyPEM(p)
+		require.NoError(t, err)
+		verification = append(verification, vk)
+	}
+	ks, err := Infrastructure.NewKeySet(signing, verification...)
+	require.NoError(t, err)
+	Infrastructure.SetKeySet(ks)
+	t.Cleanup(func() { Infrastructure.SetJWTSecret("test-secret") })
+	return ks
+}
+
+func TestKeySet_SignAndVerify(t *testing.T) {
+	cases := []struct {
+		name string
+		key  func(*testing.T) []byte
+		alg  string
+	}{
+		{"RS256", rsaKeyPEM, "RS256"},
+		{"EdDSA", ed25519KeyPEM, "EdDSA"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			useKeySet(t, tc.key(t))
+			token, err := Infrastructure.GenerateToken("507f1f77bcf86cd799439011", "alice", "user", "")
+			require.NoError(t, err)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token.Token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.alg, parsed.Header["alg"])
+			assert.NotEmpty(t, parsed.Header["kid"])
+
+			claims, err := Infrastructure.ValidateToken(token.Token)
+			require.NoError(t, err)
+			assert.Equal(t, "alice", claims["username"])
+		})
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, newKey := rsaKeyPEM(t), ed25519KeyPEM(t)
+
+	useKeySet(t, oldKey)
+	oldToken, err := Infrastructure.GenerateToken("507f1f77bcf86cd799439011", "alice", "user", "")
+	require.NoError(t, err)
+
+	// After the rotation the old public key only verifies
+	useKeySet(t, newKey, publicKeyPEM(t, oldKey))
+	_, err = Infrastructure.ValidateToken(oldToken.Token)
+	assert.NoError(t, err)
+
+	// Once the old key is dropped its tokens are rejected
+	useKeySet(t, newKey)
+	_, err = Infrastructure.ValidateToken(oldToken.Token)
+	assert.Error(t, err)
+}
+
+func TestKeySet_RejectsHMACTokenSignedWithPublicKey(t *testing.T) {
+	key := rsaKeyPEM(t)
+	ks := useKeySet(t, key)
+	kid := ks.JWKS().Keys[0].KID
+
+	// The classic algorithm confusion attack: HS256 keyed with the published public key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":  "507f1f77bcf86cd799439011",
+		"username": "mallory",
+		"role":     "admin",
+		"jti":      "forged",
+		"exp":      time.Now().Add(time.Hour).Unix(),
+	})
+	forged.Header["kid"] = kid
+	signed, err := forged.SignedString(publicKeyPEM(t, key))
+	require.NoError(t, err)
+
+	_, err = Infrastructure.ValidateToken(signed)
+	assert.Error(t, err)
+}
+
+func TestParseSigningKeyPEM_RejectsSmallRSAKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = Infrastructure.ParseSigningKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	assert.Error(t, err)
+}
+
+func TestLoadKeySetFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	signingFile := filepath.Join(dir, "signing.pem")
+	oldFile := filepath.Join(dir, "old.pem")
+	require.NoError(t, os.WriteFile(signingFile, ed25519KeyPEM(t), 0o600))
+	require.NoError(t, os.WriteFile(oldFile, rsaKeyPEM(t), 0o600))
+
+	ks, err := Infrastructure.LoadKeySetFromFiles(signingFile, []string{oldFile})
+	require.NoError(t, err)
+	assert.Len(t, ks.JWKS().Keys, 2)
+
+	_, err = Infrastructure.LoadKeySetFromFiles(filepath.Join(dir, "missing.pem"), nil)
+	assert.Error(t, err)
+}
+
+func TestJWKSHandler(t *testing.T) {
+	oldKey, newKey := rsaKeyPEM(t), ed25519KeyPEM(t)
+	useKeySet(t, newKey, publicKeyPEM(t, oldKey))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", Infrastructure.JWKSHandler())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var body struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Keys, 2)
+	// The current signing key comes first
+	assert.Equal(t, "OKP", body.Keys[0]["kty"])
+	assert.Equal(t, "EdDSA", body.Keys[0]["alg"])
+	assert.Equal(t, "RSA", body.Keys[1]["kty"])
+	assert.NotEmpty(t, body.Keys[1]["n"])
+	for _, key := range body.Keys {
+		assert.NotContains(t, key, "d", "private key material must not be published")
+	}
+}
+
+func TestJWKSHandler_HMACPublishesNoKeys(t *testing.T) {
+	Infrastructure.SetJWTSecret("test-secret")
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/.well-known/jwks.json", Infrastructure.JWKSHandler())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"keys":[]}`, w.Body.String())
+}
@@ -0,0 +1,804 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTaskRepository_CRUD(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	ctx := context.Background()
+
+	created, err := repo.AddTask(ctx, Domain.Task{Title: "Write docs", Status: "Pending"})
+	require.NoError(t, err)
+	assert.False(t, created.ID.IsZero())
+
+	found, err := repo.GetTaskByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write docs", found.Title)
+
+	found.Status = "Completed"
+	updated, err := repo.UpdateTask(ctx, *found)
+	require.NoError(t, err)
+	assert.Equal(t, Domain.StatusCompleted, updated.Status)
+
+	require.NoError(t, repo.DeleteTask(ctx, created.ID, Domain.NewID(), time.Now()))
+	_, err = repo.GetTaskByID(ctx, created.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, repo.DeleteTask(ctx, created.ID, Domain.NewID(), time.Now()), Domain.ErrNotFound)
+	_, err = repo.UpdateTask(ctx, Domain.Task{ID: Domain.NewID()})
+	assert.ErrorIs(t, err, Domain.ErrNotFound)
+}
+
+func TestMemoryTaskRepository_GetTasksByOwner(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	ctx := context.Background()
+	alice, bob := Domain.NewID(), Domain.NewID()
+
+	_, _ = repo.AddTask(ctx, Domain.Task{Title: "Alice 1", OwnerID: alice})
+	_, _ = repo.AddTask(ctx, Domain.Task{Title: "Bob 1", OwnerID: bob})
+	_, _ = repo.AddTask(ctx, Domain.Task{Title: "Alice 2", OwnerID: alice})
+
+	tasks, err := repo.GetTasksByOwner(ctx, alice)
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+	assert.Equal(t, "Alice 1", tasks[0].Title)
+	assert.Equal(t, "Alice 2", tasks[1].Title)
+
+	all, err := repo.GetAllTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestMemoryTaskRepository_ListTasks(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	ctx := context.Background()
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Delta", "alpha", "Charlie", "Bravo"} {
+		_, err := repo.AddTask(ctx, Domain.Task{Title: title, Status: "Pending", DueDate: day.AddDate(0, 0, 3-i)})
+		require.NoError(t, err)
+	}
+	_, _ = repo.AddTask(ctx, Domain.Task{Title: "Echo", Status: "Completed", DueDate: day})
+
+	page, err := repo.ListTasks(ctx, Domain.TaskQuery{Status: "Pending", Sort: Domain.SortByDueDate, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bravo", "Charlie", "alpha"}, taskTitles(page.Tasks))
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{Status: "Pending", Sort: Domain.SortByDueDate, Limit: 3, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Delta"}, taskTitles(page.Tasks))
+	assert.Empty(t, page.NextCursor)
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{Title: "ALP", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha"}, taskTitles(page.Tasks))
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{DueAfter: day.AddDate(0, 0, 1), DueBefore: day.AddDate(0, 0, 2), Sort: Domain.SortByDueDateDesc, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "Charlie"}, taskTitles(page.Tasks))
+
+	_, err = repo.ListTasks(ctx, Domain.TaskQuery{Cursor: "not-a-cursor", Limit: 10})
+	assert.ErrorIs(t, err, Domain.ErrInvalidCursor)
+}
+
+func TestMemoryTaskRepository_ConcurrentAccess(t *testing.T) {
+	repo := Repositories.NewMemoryTaskRepository()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			task, err := repo.AddTask(ctx, Domain.Task{Title: "Concurrent"})
+			if assert.NoError(t, err) {
+				_, _ = repo.GetAllTasks(ctx)
+				_, _ = repo.UpdateTask(ctx, *task)
+			}
+		}()
+	}
+	wg.Wait()
+	all, err := repo.GetAllTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 50)
+}
+
+func TestMemoryUserRepository_RegisterAndFind(t *testing.T) {
+	repo := Repositories.NewMemoryUserRepository()
+	ctx := context.Background()
+
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "alice", Email: "alice@example.com", Role: "user"}))
+	assert.ErrorIs(t, repo.RegisterUser(ctx, Domain.User{Username: "alice"}), Domain.ErrUsernameTaken)
+	assert.ErrorIs(t, repo.RegisterUser(ctx, Domain.User{Username: "bob", Email: "alice@example.com"}), Domain.ErrEmailTaken)
+	// Email is optional and an empty one is never a duplicate
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "carol"}))
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "dave"}))
+
+	user, err := repo.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.False(t, user.ID.IsZero())
+
+	byID, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", byID.Username)
+
+	_, err = repo.GetUserByID(ctx, Domain.NewID())
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
+
+func TestMemoryTaskRepository_ListTasks_Team(t *testing.T) {
+	testTaskTeamListing(t, Repositories.NewMemoryTaskRepository())
+}
+
+// testTaskTeamListing checks that a query with an owner and a team lists the tasks matching either
+func testTaskTeamListing(t *testing.T, repo Repositories.TaskRepository) {
+	ctx := context.Background()
+	manager, member, outsider := Domain.NewID(), Domain.NewID(), Domain.NewID()
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := repo.AddTask(ctx, Domain.Task{Title: "Manager, no team", OwnerID: manager, DueDate: due})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Member", OwnerID: member, Team: "platform", DueDate: due.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Outsider", OwnerID: outsider, Team: "sales", DueDate: due.AddDate(0, 0, 2)})
+	require.NoError(t, err)
+
+	page, err := repo.ListTasks(ctx, Domain.TaskQuery{OwnerID: manager, Team: "platform", Sort: Domain.SortByDueDate, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 2)
+	assert.Equal(t, "Manager, no team", page.Tasks[0].Title)
+	assert.Equal(t, "Member", page.Tasks[1].Title)
+	assert.Equal(t, "platform", page.Tasks[1].Team)
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{Team: "sales", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, "Outsider", page.Tasks[0].Title)
+}
+
+func TestMemoryTaskRepository_Assignees(t *testing.T) {
+	testTaskAssignees(t, Repositories.NewMemoryTaskRepository())
+}
+
+// testTaskAssignees checks that assignees are stored, can be cleared and filter listings
+func testTaskAssignees(t *testing.T, repo Repositories.TaskRepository) {
+	ctx := context.Background()
+	alice, bob := Domain.NewID(), Domain.NewID()
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	both, err := repo.AddTask(ctx, Domain.Task{Title: "Both", OwnerID: Domain.NewID(), DueDate: due, AssigneeIDs: []Domain.ID{alice, bob}})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Bob only", OwnerID: Domain.NewID(), DueDate: due, AssigneeIDs: []Domain.ID{bob}})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Nobody", OwnerID: Domain.NewID(), DueDate: due})
+	require.NoError(t, err)
+
+	got, err := repo.GetTaskByID(ctx, both.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.ID{alice, bob}, got.AssigneeIDs)
+
+	page, err := repo.ListTasks(ctx, Domain.TaskQuery{AssigneeID: bob, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Both", "Bob only"}, taskTitles(page.Tasks))
+
+	got.AssigneeIDs = nil
+	_, err = repo.UpdateTask(ctx, *got)
+	require.NoError(t, err)
+	got, err = repo.GetTaskByID(ctx, both.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.AssigneeIDs)
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{AssigneeID: alice, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Tasks)
+}
+
+func TestMemoryTaskRepository_LabelsAndPriority(t *testing.T) {
+	testTaskLabels(t, Repositories.NewMemoryTaskRepository())
+}
+
+// testTaskLabels checks that labels and priority are stored, filter listings and are counted per label.
+// It also checks that recurrence rules are stored.
+func testTaskLabels(t *testing.T, repo Repositories.TaskRepository) {
+	ctx := context.Background()
+	owner := Domain.NewID()
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	api, err := repo.AddTask(ctx, Domain.Task{Title: "API", OwnerID: owner, DueDate: due, Priority: Domain.PriorityHigh, Labels: []string{"backend", "bug"}})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Schema", OwnerID: owner, DueDate: due, Priority: Domain.PriorityLow, Labels: []string{"backend"}})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Lookalike", OwnerID: owner, DueDate: due, Priority: Domain.PriorityHigh, Labels: []string{"back-end", "backendx"}})
+	require.NoError(t, err)
+	_, err = repo.AddTask(ctx, Domain.Task{Title: "Someone else's", OwnerID: Domain.NewID(), DueDate: due, Labels: []string{"ops"}})
+	require.NoError(t, err)
+
+	got, err := repo.GetTaskByID(ctx, api.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Domain.PriorityHigh, got.Priority)
+	assert.Equal(t, []string{"backend", "bug"}, got.Labels)
+
+	page, err := repo.ListTasks(ctx, Domain.TaskQuery{Label: "backend", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API", "Schema"}, taskTitles(page.Tasks))
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{Label: "backend", Priority: Domain.PriorityHigh, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"API"}, taskTitles(page.Tasks))
+
+	counts, err := repo.CountLabels(ctx, Domain.TaskQuery{OwnerID: owner})
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.LabelCount{{Label: "backend", Count: 2}, {Label: "back-end", Count: 1}, {Label: "backendx", Count: 1}, {Label: "bug", Count: 1}}, counts)
+
+	weekly, err := repo.AddTask(ctx, Domain.Task{Title: "Weekly", OwnerID: owner, DueDate: due, Recurrence: "FREQ=WEEKLY", Occurrence: 3})
+	require.NoError(t, err)
+	got, err = repo.GetTaskByID(ctx, weekly.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY", got.Recurrence)
+	assert.Equal(t, 3, got.Occurrence)
+
+	got, err = repo.GetTaskByID(ctx, api.ID)
+	require.NoError(t, err)
+	got.Labels = nil
+	_, err = repo.UpdateTask(ctx, *got)
+	require.NoError(t, err)
+	got, err = repo.GetTaskByID(ctx, api.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got.Labels)
+}
+
+func TestMemoryTaskRepository_Hierarchy(t *testing.T) {
+	testTaskHierarchy(t, Repositories.NewMemoryTaskRepository())
+}
+
+// testTaskHierarchy checks that parents and blockers are stored and subtasks are listed without the trash
+func testTaskHierarchy(t *testing.T, repo Repositories.TaskRepository) {
+	ctx := context.Background()
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent, err := repo.AddTask(ctx, Domain.Task{Title: "Parent", DueDate: due})
+	require.NoError(t, err)
+	blocker, err := repo.AddTask(ctx, Domain.Task{Title: "Blocker", DueDate: due})
+	require.NoError(t, err)
+	first, err := repo.AddTask(ctx, Domain.Task{Title: "First", ParentID: parent.ID, BlockedBy: []Domain.ID{blocker.ID}, DueDate: due})
+	require.NoError(t, err)
+	second, err := repo.AddTask(ctx, Domain.Task{Title: "Second", ParentID: parent.ID, DueDate: due})
+	require.NoError(t, err)
+
+	got, err := repo.GetTaskByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, parent.ID, got.ParentID)
+	assert.Equal(t, []Domain.ID{blocker.ID}, got.BlockedBy)
+
+	subtasks, err := repo.GetSubtasks(ctx, parent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"First", "Second"}, taskTitles(subtasks))
+	subtasks, err = repo.GetSubtasks(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, subtasks, "top level tasks are nobody's subtasks")
+
+	got.ParentID = ""
+	got.BlockedBy = nil
+	_, err = repo.UpdateTask(ctx, *got)
+	require.NoError(t, err)
+	got, err = repo.GetTaskByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.True(t, got.ParentID.IsZero())
+	assert.Empty(t, got.BlockedBy)
+
+	require.NoError(t, repo.DeleteTask(ctx, second.ID, Domain.NewID(), due))
+	subtasks, err = repo.GetSubtasks(ctx, parent.ID)
+	require.NoError(t, err)
+	assert.Empty(t, subtasks)
+}
+
+func TestMemoryTaskRepository_Versioning(t *testing.T) {
+	testTaskVersioning(t, Repositories.NewMemoryTaskRepository())
+}
+
+// testTaskVersioning checks that every write increments the version and a stale version is rejected
+func testTaskVersioning(t *testing.T, repo Repositories.TaskRepository) {
+	ctx := context.Background()
+	created, err := repo.AddTask(ctx, Domain.Task{Title: "Versioned", Status: Domain.StatusPending})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Version)
+
+	first := *created
+	first.Title = "First writer"
+	updated, err := repo.UpdateTask(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	// The second writer still holds version 1
+	second := *created
+	second.Title = "Second writer"
+	_, err = repo.UpdateTask(ctx, second)
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	assert.ErrorIs(t, err, Domain.ErrPreconditionFailed)
+
+	stored, err := repo.GetTaskByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First writer", stored.Title)
+	assert.Equal(t, int64(2), stored.Version)
+
+	_, err = repo.UpdateTask(ctx, Domain.Task{ID: Domain.NewID(), Version: 1})
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestMemoryTaskRepository_Trash(t *testing.T) {
+	testTaskTrash(t, Repositories.NewMemoryTaskRepository())
+}
+
+// testTaskTrash checks that deleted tasks leave every regular read and can be restored or purged
+func testTaskTrash(t *testing.T, repo Repositories.TaskRepository) {
+	ctx := context.Background()
+	owner, admin := Domain.NewID(), Domain.NewID()
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	kept, err := repo.AddTask(ctx, Domain.Task{Title: "Kept", OwnerID: owner, DueDate: due, Status: Domain.StatusPending})
+	require.NoError(t, err)
+	trashed, err := repo.AddTask(ctx, Domain.Task{Title: "Trashed", OwnerID: owner, DueDate: due, Status: Domain.StatusPending})
+	require.NoError(t, err)
+
+	deletedAt := time.Date(2030, 2, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.DeleteTask(ctx, trashed.ID, admin, deletedAt))
+
+	_, err = repo.GetTaskByID(ctx, trashed.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	_, err = repo.UpdateTask(ctx, *trashed)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	all, err := repo.GetAllTasks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Kept"}, taskTitles(all))
+	owned, err := repo.GetTasksByOwner(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Kept"}, taskTitles(owned))
+	page, err := repo.ListTasks(ctx, Domain.TaskQuery{OwnerID: owner, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Kept"}, taskTitles(page.Tasks))
+
+	trash, err := repo.ListTasks(ctx, Domain.TaskQuery{OwnerID: owner, Deleted: true, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, trash.Tasks, 1)
+	assert.Equal(t, "Trashed", trash.Tasks[0].Title)
+	assert.Equal(t, admin, trash.Tasks[0].DeletedBy)
+	assert.True(t, deletedAt.Equal(trash.Tasks[0].DeletedAt))
+
+	inTrash, err := repo.GetDeletedTaskByID(ctx, trashed.ID)
+	require.NoError(t, err)
+	assert.True(t, inTrash.IsDeleted())
+	_, err = repo.GetDeletedTaskByID(ctx, kept.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	// Only tasks in the trash can be purged or restored
+	assert.ErrorIs(t, repo.PurgeTask(ctx, kept.ID), Domain.ErrTaskNotFound)
+	_, err = repo.RestoreTask(ctx, kept.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	restored, err := repo.RestoreTask(ctx, trashed.ID)
+	require.NoError(t, err)
+	assert.False(t, restored.IsDeleted())
+	assert.Equal(t, int64(3), restored.Version, "deleting and restoring are writes")
+	found, err := repo.GetTaskByID(ctx, trashed.ID)
+	require.NoError(t, err)
+	assert.True(t, found.DeletedBy.IsZero())
+
+	require.NoError(t, repo.DeleteTask(ctx, trashed.ID, admin, deletedAt))
+	require.NoError(t, repo.PurgeTask(ctx, trashed.ID))
+	_, err = repo.GetDeletedTaskByID(ctx, trashed.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+func TestMemoryAuditRepository(t *testing.T) {
+	testAuditRepository(t, Repositories.NewMemoryAuditRepository())
+}
+
+// testAuditRepository checks that events are listed per task, oldest first, with their changes
+func testAuditRepository(t *testing.T, repo Repositories.AuditRepository) {
+	ctx := context.Background()
+	taskID, otherID, actorID := Domain.NewID(), Domain.NewID(), Domain.NewID()
+	at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	_, err := repo.AddEvent(ctx, Domain.AuditEvent{TaskID: taskID, Action: Domain.AuditCreated, ActorID: actorID, ActorUsername: "alice", At: at,
+		Changes: []Domain.FieldChange{{Field: "title", After: "Write docs"}}})
+	require.NoError(t, err)
+	_, err = repo.AddEvent(ctx, Domain.AuditEvent{TaskID: otherID, Action: Domain.AuditCreated, ActorID: actorID, At: at})
+	require.NoError(t, err)
+	updated, err := repo.AddEvent(ctx, Domain.AuditEvent{TaskID: taskID, Action: Domain.AuditUpdated, ActorID: actorID, ActorUsername: "alice", At: at.Add(time.Hour),
+		Changes: []Domain.FieldChange{{Field: "status", Before: "Pending", After: "Completed"}}})
+	require.NoError(t, err)
+	assert.False(t, updated.ID.IsZero())
+
+	events, err := repo.ListTaskEvents(ctx, taskID)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, Domain.AuditCreated, events[0].Action)
+	assert.Equal(t, Domain.AuditUpdated, events[1].Action)
+	assert.Equal(t, updated.ID, events[1].ID)
+	assert.Equal(t, actorID, events[1].ActorID)
+	assert.Equal(t, "alice", events[1].ActorUsername)
+	assert.True(t, at.Add(time.Hour).Equal(events[1].At))
+	assert.Equal(t, []Domain.FieldChange{{Field: "status", Before: "Pending", After: "Completed"}}, events[1].Changes)
+
+	events, err = repo.ListTaskEvents(ctx, Domain.NewID())
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestMemoryCommentRepository(t *testing.T) {
+	testCommentRepository(t, Repositories.NewMemoryCommentRepository())
+}
+
+// testCommentRepository checks that comments are listed per task in posting order and can be edited and deleted
+func testCommentRepository(t *testing.T, repo Repositories.CommentRepository) {
+	ctx := context.Background()
+	taskID, authorID := Domain.NewID(), Domain.NewID()
+	at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	first, err := repo.AddComment(ctx, Domain.Comment{TaskID: taskID, AuthorID: authorID, AuthorUsername: "alice", Body: "First", CreatedAt: at, UpdatedAt: at})
+	require.NoError(t, err)
+	assert.False(t, first.ID.IsZero())
+	_, err = repo.AddComment(ctx, Domain.Comment{TaskID: Domain.NewID(), AuthorID: authorID, Body: "Elsewhere", CreatedAt: at, UpdatedAt: at})
+	require.NoError(t, err)
+	second, err := repo.AddComment(ctx, Domain.Comment{TaskID: taskID, AuthorID: authorID, Body: "Second", CreatedAt: at.Add(time.Minute), UpdatedAt: at.Add(time.Minute)})
+	require.NoError(t, err)
+
+	comments, err := repo.ListTaskComments(ctx, taskID)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, "First", comments[0].Body)
+	assert.Equal(t, "alice", comments[0].AuthorUsername)
+	assert.Equal(t, "Second", comments[1].Body)
+
+	first.Body = "First, edited"
+	first.UpdatedAt = at.Add(time.Hour)
+	updated, err := repo.UpdateComment(ctx, *first)
+	require.NoError(t, err)
+	assert.Equal(t, "First, edited", updated.Body)
+	assert.True(t, at.Equal(updated.CreatedAt))
+	assert.True(t, updated.IsEdited())
+
+	got, err := repo.GetCommentByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First, edited", got.Body)
+	assert.Equal(t, taskID, got.TaskID)
+	assert.Equal(t, authorID, got.AuthorID)
+
+	require.NoError(t, repo.DeleteComment(ctx, second.ID))
+	assert.ErrorIs(t, repo.DeleteComment(ctx, second.ID), Domain.ErrCommentNotFound)
+	_, err = repo.GetCommentByID(ctx, second.ID)
+	assert.ErrorIs(t, err, Domain.ErrCommentNotFound)
+	_, err = repo.UpdateComment(ctx, Domain.Comment{ID: Domain.NewID(), Body: "Missing"})
+	assert.ErrorIs(t, err, Domain.ErrCommentNotFound)
+
+	comments, err = repo.ListTaskComments(ctx, taskID)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+}
+
+func TestMemoryReminderRepository(t *testing.T) {
+	testReminderRepository(t, Repositories.NewMemoryReminderRepository())
+}
+
+// testReminderRepository checks that a reminder is claimed once per task, kind and due date, and can be claimed again once released
+func testReminderRepository(t *testing.T, repo Repositories.ReminderRepository) {
+	ctx := context.Background()
+	at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	key := Domain.ReminderKey{TaskID: Domain.NewID(), Kind: Domain.ReminderDueSoon, DueDate: at.Add(12 * time.Hour)}
+
+	claimed, err := repo.ClaimReminder(ctx, key, at)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	// The same instant in another zone is the same key
+	claimed, err = repo.ClaimReminder(ctx, Domain.ReminderKey{TaskID: key.TaskID, Kind: key.Kind, DueDate: key.DueDate.Local()}, at)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+
+	for _, other := range []Domain.ReminderKey{
+		{TaskID: key.TaskID, Kind: Domain.ReminderOverdue, DueDate: key.DueDate},
+		{TaskID: key.TaskID, Kind: key.Kind, DueDate: key.DueDate.AddDate(0, 0, 1)},
+		{TaskID: Domain.NewID(), Kind: key.Kind, DueDate: key.DueDate},
+	} {
+		claimed, err = repo.ClaimReminder(ctx, other, at)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+	}
+
+	require.NoError(t, repo.ReleaseReminder(ctx, key))
+	require.NoError(t, repo.ReleaseReminder(ctx, key))
+	claimed, err = repo.ClaimReminder(ctx, key, at)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestMemoryWebhookRepository(t *testing.T) {
+	testWebhookRepository(t, Repositories.NewMemoryWebhookRepository())
+}
+
+// testWebhookRepository checks that subscriptions and dead letters round-trip, are listed in order and can be deleted
+func testWebhookRepository(t *testing.T, repo Repositories.WebhookRepository) {
+	ctx := context.Background()
+	at := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+	adminID := Domain.NewID()
+
+	first, err := repo.AddSubscription(ctx, Domain.WebhookSubscription{
+		URL: "https://ci.example.com/hook", Secret: "0123456789abcdef", Events: []Domain.TaskEventType{Domain.EventTaskCreated, Domain.EventTaskDeleted},
+		CreatedBy: adminID, CreatedAt: at,
+	})
+	require.NoError(t, err)
+	assert.False(t, first.ID.IsZero())
+	second, err := repo.AddSubscription(ctx, Domain.WebhookSubscription{URL: "https://chat.example.com/hook", Secret: "fedcba9876543210", CreatedBy: adminID, CreatedAt: at.Add(time.Minute)})
+	require.NoError(t, err)
+
+	got, err := repo.GetSubscription(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", got.Secret)
+	assert.Equal(t, []Domain.TaskEventType{Domain.EventTaskCreated, Domain.EventTaskDeleted}, got.Events)
+	assert.Equal(t, adminID, got.CreatedBy)
+	assert.True(t, at.Equal(got.CreatedAt))
+
+	subs, err := repo.ListSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Len(t, subs, 2)
+	assert.Equal(t, first.ID, subs[0].ID)
+	assert.Empty(t, subs[1].Events)
+
+	require.NoError(t, repo.DeleteSubscription(ctx, second.ID))
+	assert.ErrorIs(t, repo.DeleteSubscription(ctx, second.ID), Domain.ErrWebhookNotFound)
+	_, err = repo.GetSubscription(ctx, second.ID)
+	assert.ErrorIs(t, err, Domain.ErrWebhookNotFound)
+
+	older, err := repo.AddDeadLetter(ctx, Domain.WebhookDeadLetter{
+		SubscriptionID: first.ID, URL: first.URL, EventID: Domain.NewID(), EventType: Domain.EventTaskCreated,
+		Payload: []byte(`{"type":"task.created"}`), Attempts: 5, LastError: "webhook returned 500 Internal Server Error", FailedAt: at,
+	})
+	require.NoError(t, err)
+	newer, err := repo.AddDeadLetter(ctx, Domain.WebhookDeadLetter{
+		SubscriptionID: first.ID, URL: first.URL, EventID: Domain.NewID(), EventType: Domain.EventTaskDeleted,
+		Payload: []byte(`{"type":"task.deleted"}`), Attempts: 1, FailedAt: at.Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	letter, err := repo.GetDeadLetter(ctx, older.ID)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"task.created"}`, string(letter.Payload))
+	assert.Equal(t, 5, letter.Attempts)
+	assert.Equal(t, older.EventID, letter.EventID)
+	assert.Equal(t, "webhook returned 500 Internal Server Error", letter.LastError)
+
+	letters, err := repo.ListDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	assert.Equal(t, newer.ID, letters[0].ID)
+	assert.Equal(t, older.ID, letters[1].ID)
+
+	require.NoError(t, repo.DeleteDeadLetter(ctx, older.ID))
+	assert.ErrorIs(t, repo.DeleteDeadLetter(ctx, older.ID), Domain.ErrDeadLetterNotFound)
+	_, err = repo.GetDeadLetter(ctx, older.ID)
+	assert.ErrorIs(t, err, Domain.ErrDeadLetterNotFound)
+}
+
+func TestMemoryUserRepository_Administration(t *testing.T) {
+	testUserAdministration(t, Repositories.NewMemoryUserRepository())
+}
+
+// testUserAdministration checks listing, role changes and deletion shared by every user backend
+func testUserAdministration(t *testing.T, repo Repositories.UserRepository) {
+	ctx := context.Background()
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "alice", Password: "hash", Role: Domain.RoleUser}))
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "bob", Password: "hash", Role: Domain.RoleUser}))
+
+	users, err := repo.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "alice", users[0].Username)
+	assert.Equal(t, "bob", users[1].Username)
+
+	updated, err := repo.UpdateUserRole(ctx, users[1].ID, Domain.RoleAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, Domain.RoleAdmin, updated.Role)
+	assert.Equal(t, "bob", updated.Username)
+	_, err = repo.UpdateUserRole(ctx, Domain.NewID(), Domain.RoleAdmin)
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+
+	updated, err = repo.UpdateUserTeam(ctx, users[1].ID, "platform")
+	require.NoError(t, err)
+	assert.Equal(t, "platform", updated.Team)
+	assert.Equal(t, Domain.RoleAdmin, updated.Role)
+	found, err := repo.GetUserByID(ctx, users[1].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "platform", found.Team)
+	_, err = repo.UpdateUserTeam(ctx, Domain.NewID(), "platform")
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+
+	require.NoError(t, repo.DeleteUser(ctx, users[0].ID))
+	assert.ErrorIs(t, repo.DeleteUser(ctx, users[0].ID), Domain.ErrUserNotFound)
+	users, err = repo.ListUsers(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "bob", users[0].Username)
+}
+
+func newMemoryStores() Repositories.MemoryStores {
+	return Repositories.MemoryStores{
+		Tasks:     Repositories.NewMemoryTaskRepository(),
+		Users:     Repositories.NewMemoryUserRepository(),
+		Audit:     Repositories.NewMemoryAuditRepository(),
+		Reminders: Repositories.NewMemoryReminderRepository(),
+		Comments:  Repositories.NewMemoryCommentRepository(),
+		Webhooks:  Repositories.NewMemoryWebhookRepository(),
+	}
+}
+
+// roundTripMemorySnapshot saves stores and loads them into new repositories
+func roundTripMemorySnapshot(t *testing.T, stores Repositories.MemoryStores) Repositories.MemoryStores {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, Repositories.SaveMemorySnapshot(path, stores))
+	restored := newMemoryStores()
+	require.NoError(t, Repositories.LoadMemorySnapshot(path, restored))
+	return restored
+}
+
+func TestMemorySnapshot_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	stores := newMemoryStores()
+	due := time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)
+	created, err := stores.Tasks.AddTask(ctx, Domain.Task{Title: "Persist me", DueDate: due, OwnerID: Domain.NewID()})
+	require.NoError(t, err)
+	require.NoError(t, stores.Users.RegisterUser(ctx, Domain.User{Username: "alice"}))
+	for _, action := range []Domain.AuditAction{Domain.AuditCreated, Domain.AuditUpdated} {
+		_, err := stores.Audit.AddEvent(ctx, Domain.AuditEvent{TaskID: created.ID, Action: action, ActorID: created.OwnerID, At: due,
+			Changes: []Domain.FieldChange{{Field: "title", After: "Persist me"}}})
+		require.NoError(t, err)
+	}
+
+	restored := roundTripMemorySnapshot(t, stores)
+
+	task, err := restored.Tasks.GetTaskByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.OwnerID, task.OwnerID)
+	assert.True(t, due.Equal(task.DueDate))
+	_, err = restored.Users.GetUserByUsername(ctx, "alice")
+	assert.NoError(t, err)
+	history, err := restored.Audit.ListTaskEvents(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, Domain.AuditCreated, history[0].Action)
+	assert.Equal(t, Domain.AuditUpdated, history[1].Action)
+	assert.Equal(t, []Domain.FieldChange{{Field: "title", After: "Persist me"}}, history[1].Changes)
+	assert.True(t, due.Equal(history[1].At))
+}
+
+func TestMemorySnapshot_Reminders(t *testing.T) {
+	ctx := context.Background()
+	stores := newMemoryStores()
+	sent := Domain.ReminderKey{TaskID: Domain.NewID(), Kind: Domain.ReminderDueSoon, DueDate: time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC)}
+	released := Domain.ReminderKey{TaskID: sent.TaskID, Kind: Domain.ReminderOverdue, DueDate: sent.DueDate}
+	for _, key := range []Domain.ReminderKey{sent, released} {
+		claimed, err := stores.Reminders.ClaimReminder(ctx, key, time.Now())
+		require.NoError(t, err)
+		require.True(t, claimed)
+	}
+	require.NoError(t, stores.Reminders.ReleaseReminder(ctx, released))
+
+	restored := roundTripMemorySnapshot(t, stores)
+
+	// A reminder sent before the restart is not sent again, a released one still is
+	claimed, err := restored.Reminders.ClaimReminder(ctx, Domain.ReminderKey{TaskID: sent.TaskID, Kind: sent.Kind, DueDate: sent.DueDate.Local()}, time.Now())
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	claimed, err = restored.Reminders.ClaimReminder(ctx, released, time.Now())
+	require.NoError(t, err)
+	assert.True(t, claimed)
+}
+
+func TestMemorySnapshot_Comments(t *testing.T) {
+	ctx := context.Background()
+	stores := newMemoryStores()
+	taskID, at := Domain.NewID(), time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC)
+	var posted []*Domain.Comment
+	for _, body := range []string{"First", "Second", "Third"} {
+		comment, err := stores.Comments.AddComment(ctx, Domain.Comment{TaskID: taskID, AuthorID: Domain.NewID(), AuthorUsername: "alice", Body: body, CreatedAt: at, UpdatedAt: at})
+		require.NoError(t, err)
+		posted = append(posted, comment)
+	}
+	require.NoError(t, stores.Comments.DeleteComment(ctx, posted[1].ID))
+
+	restored := roundTripMemorySnapshot(t, stores)
+
+	comments, err := restored.Comments.ListTaskComments(ctx, taskID)
+	require.NoError(t, err)
+	require.Len(t, comments, 2)
+	assert.Equal(t, posted[0].ID, comments[0].ID)
+	assert.Equal(t, "Third", comments[1].Body)
+	assert.Equal(t, "alice", comments[1].AuthorUsername)
+	assert.True(t, at.Equal(comments[1].CreatedAt))
+	// Comments posted after the restart come after the restored ones
+	_, err = restored.Comments.AddComment(ctx, Domain.Comment{TaskID: taskID, Body: "Fourth", CreatedAt: at, UpdatedAt: at})
+	require.NoError(t, err)
+	comments, err = restored.Comments.ListTaskComments(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "Fourth", comments[2].Body)
+}
+
+func TestMemorySnapshot_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	stores := newMemoryStores()
+	at := time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC)
+	sub, err := stores.Webhooks.AddSubscription(ctx, Domain.WebhookSubscription{URL: "https://example.com/hook", Secret: "s3cret",
+		Events: []Domain.TaskEventType{Domain.EventTaskCreated}, CreatedBy: Domain.NewID(), CreatedAt: at})
+	require.NoError(t, err)
+	for i, failedAt := range []time.Time{at, at.Add(time.Minute)} {
+		_, err := stores.Webhooks.AddDeadLetter(ctx, Domain.WebhookDeadLetter{SubscriptionID: sub.ID, URL: sub.URL, EventID: Domain.NewID(),
+			EventType: Domain.EventTaskCreated, Payload: []byte(`{"n":` + strconv.Itoa(i) + `}`), Attempts: 3, LastError: "503", FailedAt: failedAt})
+		require.NoError(t, err)
+	}
+
+	restored := roundTripMemorySnapshot(t, stores)
+
+	restoredSub, err := restored.Webhooks.GetSubscription(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", restoredSub.Secret, "deliveries are still signed with the same secret")
+	assert.Equal(t, sub.Events, restoredSub.Events)
+	letters, err := restored.Webhooks.ListDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	assert.Equal(t, []byte(`{"n":1}`), letters[0].Payload, "most recent failure first")
+	assert.Equal(t, sub.ID, letters[1].SubscriptionID)
+	assert.Equal(t, 3, letters[1].Attempts)
+}
+
+func TestMemorySnapshot_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.json")
+	err := Repositories.LoadMemorySnapshot(path, newMemoryStores())
+	assert.NoError(t, err)
+}
+
+func TestMemoryTokenRepository(t *testing.T) {
+	testTokenRepository(t, Repositories.NewMemoryTokenRepository())
+}
+
+// testTokenRepository checks the TokenRepository behaviour shared by every backend
+func testTokenRepository(t *testing.T, repo Repositories.TokenRepository) {
+	ctx := context.Background()
+	userID := Domain.NewID()
+	expiresAt := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+
+	// Refresh tokens can be consumed exactly once
+	require.NoError(t, repo.SaveRefreshToken(ctx, Domain.RefreshToken{TokenHash: "hash-1", UserID: userID, ExpiresAt: expiresAt}))
+	token, err := repo.ConsumeRefreshToken(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, userID, token.UserID)
+	assert.True(t, expiresAt.Equal(token.ExpiresAt))
+	_, err = repo.ConsumeRefreshToken(ctx, "hash-1")
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefreshToken)
+
+	// Only the owner can delete a refresh token
+	require.NoError(t, repo.SaveRefreshToken(ctx, Domain.RefreshToken{TokenHash: "hash-2", UserID: userID, ExpiresAt: expiresAt}))
+	require.NoError(t, repo.DeleteRefreshToken(ctx, "hash-2", Domain.NewID()))
+	require.NoError(t, repo.DeleteRefreshToken(ctx, "hash-2", userID))
+	_, err = repo.ConsumeRefreshToken(ctx, "hash-2")
+	assert.ErrorIs(t, err, Domain.ErrInvalidRefreshToken)
+	require.NoError(t, repo.DeleteRefreshToken(ctx, "unknown", userID))
+
+	// Revocation is idempotent
+	revoked, err := repo.IsAccessTokenRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	require.NoError(t, repo.RevokeAccessToken(ctx, "jti-1", expiresAt))
+	require.NoError(t, repo.RevokeAccessToken(ctx, "jti-1", expiresAt))
+	revoked, err = repo.IsAccessTokenRevoked(ctx, "jti-1")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+}
+
+func taskTitles(tasks []Domain.Task) []string {
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	return titles
+}
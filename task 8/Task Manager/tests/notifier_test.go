@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"taskmanager/Domain"
+	"taskmanager/Infrastructure"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testReminder() Domain.Reminder {
+	return Domain.Reminder{
+		Kind: Domain.ReminderOverdue,
+		Task: Domain.Task{ID: Domain.NewID(), Title: "Café\r\nBcc: evil@example.com", DueDate: day(2030, 5, 1), Status: Domain.StatusPending},
+		Recipients: []Domain.Recipient{
+			{UserID: Domain.NewID(), Username: "alice", Email: "alice@example.com"},
+			{UserID: Domain.NewID(), Username: "bob"},
+		},
+	}
+}
+
+// smtpMessage is what the fake SMTP server received in one session
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startFakeSMTPServer accepts one session speaking just enough SMTP for net/smtp, without STARTTLS or AUTH
+func startFakeSMTPServer(t *testing.T) (addr string, received <-chan smtpMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	messages := make(chan smtpMessage, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var msg smtpMessage
+		reply("220 localhost ESMTP fake")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			switch cmd := strings.ToUpper(line); {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				msg.from = strings.Trim(line[len("MAIL FROM:"):], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<>"))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				msg.data = data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				messages <- msg
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), messages
+}
+
+func TestSMTPNotifier_Notify(t *testing.T) {
+	addr, received := startFakeSMTPServer(t)
+	notifier := Infrastructure.NewSMTPNotifier(Infrastructure.SMTPConfig{Addr: addr, From: "tasks@example.com"})
+	reminder := testReminder()
+
+	require.NoError(t, notifier.Notify(context.Background(), reminder))
+
+	select {
+	case msg := <-received:
+		assert.Equal(t, "tasks@example.com", msg.from)
+		assert.Equal(t, []string{"alice@example.com"}, msg.to) // bob has no email
+		assert.Contains(t, msg.data, "To: alice@example.com\r\n")
+		assert.Contains(t, msg.data, "Subject: =?utf-8?q?")
+		assert.NotContains(t, msg.data, "\r\nBcc:")
+		assert.Contains(t, msg.data, "Due date: 01-05-2030")
+		assert.Contains(t, msg.data, "Task ID: "+reminder.Task.ID.String())
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fake SMTP server received no message")
+	}
+}
+
+func TestSMTPNotifier_Notify_NoEmails(t *testing.T) {
+	// Nothing listens on the address, so a connection attempt would fail
+	notifier := Infrastructure.NewSMTPNotifier(Infrastructure.SMTPConfig{Addr: "127.0.0.1:1", From: "tasks@example.com"})
+	reminder := testReminder()
+	reminder.Recipients = reminder.Recipients[1:]
+
+	assert.NoError(t, notifier.Notify(context.Background(), reminder))
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	reminder := testReminder()
+
+	require.NoError(t, Infrastructure.NewWebhookNotifier(server.URL, nil).Notify(context.Background(), reminder))
+
+	assert.Equal(t, "overdue", body["kind"])
+	task := body["task"].(map[string]interface{})
+	assert.Equal(t, reminder.Task.ID.String(), task["id"])
+	assert.Equal(t, "01-05-2030", task["due_date"])
+	assert.Len(t, body["recipients"], 2)
+}
+
+func TestWebhookNotifier_Notify_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := Infrastructure.NewWebhookNotifier(server.URL, nil).Notify(context.Background(), testReminder())
+
+	assert.ErrorContains(t, err, "502")
+}
+
+func TestLogNotifier_Notify(t *testing.T) {
+	var buf bytes.Buffer
+	notifier := Infrastructure.NewLogNotifier(log.New(&buf, "", 0))
+
+	require.NoError(t, notifier.Notify(context.Background(), testReminder()))
+
+	assert.Contains(t, buf.String(), "is overdue")
+	assert.Contains(t, buf.String(), "alice, bob")
+}
+
+func TestScheduler_RunsUntilStopped(t *testing.T) {
+	var runs atomic.Int32
+	scheduler := Infrastructure.NewScheduler("test", 10*time.Millisecond, func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	scheduler.Start()
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, 5*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, scheduler.Stop(context.Background()))
+	stopped := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
+
+func TestScheduler_StopCancelsSlowJob(t *testing.T) {
+	started := make(chan struct{})
+	scheduler := Infrastructure.NewScheduler("test", time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	scheduler.Start()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, scheduler.Stop(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,82 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"taskmanager/Delivery/middleware"
+	"taskmanager/Domain"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// doProblemRequest runs a handler that fails with err behind the error handler
+func doProblemRequest(t *testing.T, err error) (*httptest.ResponseRecorder, middleware.Problem) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/fail", func(c *gin.Context) { _ = c.Error(err) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	var p middleware.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	return w, p
+}
+
+func TestErrorHandler_DomainErrors(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{Domain.ErrTaskNotFound, http.StatusNotFound, "task_not_found"},
+		{Domain.ErrUsernameTaken, http.StatusConflict, "username_taken"},
+		{Domain.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+		{Domain.ErrForbidden, http.StatusForbidden, "forbidden"},
+		{Domain.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+		{Domain.ErrVersionConflict, http.StatusPreconditionFailed, "version_mismatch"},
+		{Domain.ErrWebhooksStopped, http.StatusServiceUnavailable, "webhooks_stopped"},
+		// Wrapping keeps the kind and the code
+		{fmt.Errorf("loading user: %w", Domain.ErrUserNotFound), http.StatusNotFound, "user_not_found"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.code, func(t *testing.T) {
+			w, p := doProblemRequest(t, tc.err)
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, middleware.ProblemContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.status, p.Status)
+			assert.Equal(t, tc.code, p.Code)
+			assert.Equal(t, http.StatusText(tc.status), p.Title)
+			assert.Equal(t, "/fail", p.Instance)
+		})
+	}
+}
+
+func TestErrorHandler_ValidationFields(t *testing.T) {
+	verr := Domain.NewValidationError("title", "is required")
+	verr.Add("due_date", "invalid due date")
+
+	w, p := doProblemRequest(t, verr)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "validation_failed", p.Code)
+	assert.Equal(t, []middleware.FieldProblem{
+		{Field: "title", Message: "is required"},
+		{Field: "due_date", Message: "invalid due date"},
+	}, p.Errors)
+}
+
+func TestErrorHandler_HidesInternalErrors(t *testing.T) {
+	w, p := doProblemRequest(t, errors.New("connection refused to 10.0.0.5"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "internal_error", p.Code)
+	assert.Empty(t, p.Detail)
+	assert.NotContains(t, w.Body.String(), "10.0.0.5")
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"taskmanager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeNotifier records the reminders it is given, and fails while err is set
+type fakeNotifier struct {
+	sent []Domain.Reminder
+	err  error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, reminder Domain.Reminder) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, reminder)
+	return nil
+}
+
+type reminderFixture struct {
+	tasks    *Repositories.MemoryTaskRepository
+	users    *Repositories.MemoryUserRepository
+	notifier *fakeNotifier
+	now      time.Time
+	usecase  Usecases.ReminderUsecase
+	owner    Domain.User
+}
+
+// newReminderFixture reminds tasks due within a day, at a clock the test can move
+func newReminderFixture(t *testing.T) *reminderFixture {
+	f := &reminderFixture{
+		tasks:    Repositories.NewMemoryTaskRepository(),
+		users:    Repositories.NewMemoryUserRepository(),
+		notifier: &fakeNotifier{},
+		now:      time.Date(2030, 5, 1, 9, 0, 0, 0, time.UTC),
+	}
+	f.usecase = Usecases.NewReminderUsecase(f.tasks, f.users, Repositories.NewMemoryReminderRepository(), f.notifier, 24*time.Hour,
+		Usecases.WithReminderClock(func() time.Time { return f.now }))
+	f.owner = f.addUser(t, "alice", "alice@example.com")
+	return f
+}
+
+func (f *reminderFixture) addUser(t *testing.T, username, email string) Domain.User {
+	require.NoError(t, f.users.RegisterUser(context.Background(), Domain.User{Username: username, Email: email, Role: "user"}))
+	user, err := f.users.GetUserByUsername(context.Background(), username)
+	require.NoError(t, err)
+	return *user
+}
+
+func (f *reminderFixture) addTask(t *testing.T, title string, due time.Time, status Domain.TaskStatus) *Domain.Task {
+	task, err := f.tasks.AddTask(context.Background(), Domain.Task{OwnerID: f.owner.ID, Title: title, DueDate: due, Status: status})
+	require.NoError(t, err)
+	return task
+}
+
+func (f *reminderFixture) sentTitles() map[string]Domain.ReminderKind {
+	kinds := make(map[string]Domain.ReminderKind)
+	for _, r := range f.notifier.sent {
+		kinds[r.Task.Title] = r.Kind
+	}
+	return kinds
+}
+
+func TestReminderUsecase_SendReminders(t *testing.T) {
+	f := newReminderFixture(t)
+	f.addTask(t, "Due soon", f.now.Add(3*time.Hour), Domain.StatusPending)
+	f.addTask(t, "In progress", f.now.Add(20*time.Hour), Domain.StatusInProgress)
+	f.addTask(t, "Overdue", f.now.Add(-48*time.Hour), Domain.StatusPending)
+	f.addTask(t, "Later", f.now.Add(72*time.Hour), Domain.StatusPending)
+	f.addTask(t, "Done", f.now.Add(-time.Hour), Domain.StatusCompleted)
+
+	sent, err := f.usecase.SendReminders(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Equal(t, map[string]Domain.ReminderKind{
+		"Due soon":    Domain.ReminderDueSoon,
+		"In progress": Domain.ReminderDueSoon,
+		"Overdue":     Domain.ReminderOverdue,
+	}, f.sentTitles())
+	require.Len(t, f.notifier.sent[0].Recipients, 1)
+	assert.Equal(t, Domain.Recipient{UserID: f.owner.ID, Username: "alice", Email: "alice@example.com"}, f.notifier.sent[0].Recipients[0])
+}
+
+func TestReminderUsecase_SendReminders_NoDuplicates(t *testing.T) {
+	f := newReminderFixture(t)
+	task := f.addTask(t, "Report", f.now.Add(3*time.Hour), Domain.StatusPending)
+	ctx := context.Background()
+
+	sent, err := f.usecase.SendReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = f.usecase.SendReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// Once the due date passes the task is reminded again, as overdue, and only once
+	f.now = f.now.Add(4 * time.Hour)
+	sent, err = f.usecase.SendReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, Domain.ReminderOverdue, f.notifier.sent[1].Kind)
+	sent, err = f.usecase.SendReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, sent)
+
+	// A new due date brings a new reminder
+	task.DueDate = f.now.Add(6 * time.Hour)
+	_, err = f.tasks.UpdateTask(ctx, *task)
+	require.NoError(t, err)
+	sent, err = f.usecase.SendReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, Domain.ReminderDueSoon, f.notifier.sent[2].Kind)
+}
+
+func TestReminderUsecase_SendReminders_RetriesFailedDelivery(t *testing.T) {
+	f := newReminderFixture(t)
+	f.addTask(t, "Report", f.now.Add(3*time.Hour), Domain.StatusPending)
+	ctx := context.Background()
+
+	f.notifier.err = errors.New("smtp unavailable")
+	sent, err := f.usecase.SendReminders(ctx)
+	assert.ErrorIs(t, err, f.notifier.err)
+	assert.Equal(t, 0, sent)
+
+	f.notifier.err = nil
+	sent, err = f.usecase.SendReminders(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+}
+
+func TestReminderUsecase_SendReminders_Assignees(t *testing.T) {
+	f := newReminderFixture(t)
+	bob := f.addUser(t, "bob", "")
+	task, err := f.tasks.AddTask(context.Background(), Domain.Task{
+		OwnerID:     f.owner.ID,
+		Title:       "Shared",
+		DueDate:     f.now.Add(time.Hour),
+		Status:      Domain.StatusPending,
+		AssigneeIDs: []Domain.ID{f.owner.ID, bob.ID, Domain.NewID()}, // the owner twice, and a deleted user
+	})
+	require.NoError(t, err)
+
+	sent, err := f.usecase.SendReminders(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, task.ID, f.notifier.sent[0].Task.ID)
+	assert.Equal(t, []Domain.Recipient{
+		{UserID: f.owner.ID, Username: "alice", Email: "alice@example.com"},
+		{UserID: bob.ID, Username: "bob"},
+	}, f.notifier.sent[0].Recipients)
+}
+
+// ReminderMockCollection is a mock for the ReminderCollection interface
+type ReminderMockCollection struct{ mock.Mock }
+
+func (m *ReminderMockCollection) InsertOne(ctx context.Context, doc interface{}, opts ...interface{}) (*Repositories.InsertOneResult, error) {
+	args := m.Called(ctx, doc)
+	result, _ := args.Get(0).(*Repositories.InsertOneResult)
+	return result, args.Error(1)
+}
+
+func (m *ReminderMockCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.DeleteResult, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(Repositories.DeleteResult), args.Error(1)
+}
+
+func (m *ReminderMockCollection) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	return m.Called(ctx, models).Error(0)
+}
+
+func TestMongoReminderRepository_ClaimReminder_Duplicate(t *testing.T) {
+	mockColl := new(ReminderMockCollection)
+	repo := Repositories.NewMongoReminderRepository(mockColl)
+	duplicate := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+	mockColl.On("InsertOne", mock.Anything, mock.Anything).Return(nil, duplicate)
+
+	claimed, err := repo.ClaimReminder(context.Background(), Domain.ReminderKey{TaskID: Domain.NewID(), Kind: Domain.ReminderOverdue, DueDate: time.Now()}, time.Now())
+
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	mockColl.AssertExpectations(t)
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSQLDB opens a migrated SQLite database in a temporary directory
+func newTestSQLDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, Repositories.MigrateSQL(context.Background(), db, Repositories.DialectSQLite))
+	return db
+}
+
+func TestMigrateSQL_IsIdempotent(t *testing.T) {
+	db := newTestSQLDB(t)
+	require.NoError(t, Repositories.MigrateSQL(context.Background(), db, Repositories.DialectSQLite))
+
+	var applied int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied))
+	assert.Equal(t, 14, applied)
+}
+
+func TestDialectForDriver_OnlyImportedDrivers(t *testing.T) {
+	dialect, err := Repositories.DialectForDriver("sqlite3")
+	require.NoError(t, err)
+	assert.Equal(t, Repositories.DialectSQLite, dialect)
+	dialect, err = Repositories.DialectForDriver("postgres")
+	require.NoError(t, err)
+	assert.Equal(t, Repositories.DialectPostgres, dialect)
+
+	for _, driver := range []string{"pgx", "sqlite", "mysql"} {
+		_, err := Repositories.DialectForDriver(driver)
+		assert.Error(t, err, driver)
+	}
+}
+
+func TestSQLTaskRepository_CRUD(t *testing.T) {
+	repo := Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite)
+	ctx := context.Background()
+	ownerID := Domain.NewID()
+	due := time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)
+
+	created, err := repo.AddTask(ctx, Domain.Task{Title: "Write docs", Status: "Pending", DueDate: due, OwnerID: ownerID})
+	require.NoError(t, err)
+	assert.False(t, created.ID.IsZero())
+
+	found, err := repo.GetTaskByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write docs", found.Title)
+	assert.Equal(t, ownerID, found.OwnerID)
+	assert.True(t, due.Equal(found.DueDate))
+
+	found.Status = "Completed"
+	_, err = repo.UpdateTask(ctx, *found)
+	require.NoError(t, err)
+	owned, err := repo.GetTasksByOwner(ctx, ownerID)
+	require.NoError(t, err)
+	require.Len(t, owned, 1)
+	assert.Equal(t, Domain.StatusCompleted, owned[0].Status)
+
+	require.NoError(t, repo.DeleteTask(ctx, created.ID, Domain.NewID(), time.Now()))
+	_, err = repo.GetTaskByID(ctx, created.ID)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.ErrorIs(t, repo.DeleteTask(ctx, created.ID, Domain.NewID(), time.Now()), Domain.ErrNotFound)
+	_, err = repo.UpdateTask(ctx, Domain.Task{ID: Domain.NewID()})
+	assert.ErrorIs(t, err, Domain.ErrNotFound)
+}
+
+func TestSQLTaskRepository_ListTasks(t *testing.T) {
+	repo := Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite)
+	ctx := context.Background()
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Delta", "alpha", "Charlie", "Bravo"} {
+		_, err := repo.AddTask(ctx, Domain.Task{Title: title, Status: "Pending", DueDate: day.AddDate(0, 0, 3-i)})
+		require.NoError(t, err)
+	}
+	_, err := repo.AddTask(ctx, Domain.Task{Title: "100%_done", Status: "Completed", DueDate: day})
+	require.NoError(t, err)
+
+	page, err := repo.ListTasks(ctx, Domain.TaskQuery{Status: "Pending", Sort: Domain.SortByTitle, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bravo", "Charlie", "Delta"}, taskTitles(page.Tasks))
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{Status: "Pending", Sort: Domain.SortByTitle, Limit: 3, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha"}, taskTitles(page.Tasks))
+	assert.Empty(t, page.NextCursor)
+
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{DueAfter: day.AddDate(0, 0, 1), DueBefore: day.AddDate(0, 0, 2), Sort: Domain.SortByDueDateDesc, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha", "Charlie"}, taskTitles(page.Tasks))
+
+	// LIKE wildcards in the search text are matched literally
+	page, err = repo.ListTasks(ctx, Domain.TaskQuery{Title: "%_", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"100%_done"}, taskTitles(page.Tasks))
+}
+
+func TestSQLUserRepository_RegisterAndFind(t *testing.T) {
+	repo := Repositories.NewSQLUserRepository(newTestSQLDB(t), Repositories.DialectSQLite)
+	ctx := context.Background()
+
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "alice", Password: "hash", Email: "alice@example.com", Role: "user"}))
+	err := repo.RegisterUser(ctx, Domain.User{Username: "alice", Password: "hash"})
+	assert.ErrorIs(t, err, Domain.ErrUsernameTaken)
+	err = repo.RegisterUser(ctx, Domain.User{Username: "bob", Password: "hash", Email: "alice@example.com"})
+	assert.ErrorIs(t, err, Domain.ErrEmailTaken)
+	// Email is optional and an empty one is never a duplicate
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "carol", Password: "hash"}))
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "dave", Password: "hash"}))
+	// The violated index decides, not the text of the error
+	require.NoError(t, repo.RegisterUser(ctx, Domain.User{Username: "email", Password: "hash", Email: "email@example.com"}))
+	err = repo.RegisterUser(ctx, Domain.User{Username: "email", Password: "hash"})
+	assert.ErrorIs(t, err, Domain.ErrUsernameTaken)
+
+	user, err := repo.GetUserByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "user", user.Role)
+
+	byID, err := repo.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", byID.Username)
+
+	_, err = repo.GetUserByID(ctx, Domain.NewID())
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
+
+func TestSQLTaskRepository_ListTasks_Team(t *testing.T) {
+	testTaskTeamListing(t, Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLTaskRepository_Versioning(t *testing.T) {
+	testTaskVersioning(t, Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLTaskRepository_Assignees(t *testing.T) {
+	testTaskAssignees(t, Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLTaskRepository_LabelsAndPriority(t *testing.T) {
+	testTaskLabels(t, Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLTaskRepository_Hierarchy(t *testing.T) {
+	testTaskHierarchy(t, Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLTaskRepository_Trash(t *testing.T) {
+	testTaskTrash(t, Repositories.NewSQLTaskRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLAuditRepository(t *testing.T) {
+	testAuditRepository(t, Repositories.NewSQLAuditRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLCommentRepository(t *testing.T) {
+	testCommentRepository(t, Repositories.NewSQLCommentRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLUserRepository_Administration(t *testing.T) {
+	testUserAdministration(t, Repositories.NewSQLUserRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLTokenRepository(t *testing.T) {
+	testTokenRepository(t, Repositories.NewSQLTokenRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLReminderRepository(t *testing.T) {
+	testReminderRepository(t, Repositories.NewSQLReminderRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
+
+func TestSQLWebhookRepository(t *testing.T) {
+	testWebhookRepository(t, Repositories.NewSQLWebhookRepository(newTestSQLDB(t), Repositories.DialectSQLite))
+}
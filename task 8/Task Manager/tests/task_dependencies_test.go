@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"taskmanager/Usecases"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDependencyUsecase() Usecases.TaskUsecase {
+	return Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+}
+
+func TestTaskUsecase_RejectsDependencyCycles(t *testing.T) {
+	usecase := newDependencyUsecase()
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	a, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "A", DueDate: futureDue})
+	require.NoError(t, err)
+	b, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "B", DueDate: futureDue, BlockedBy: []Domain.ID{a.ID}})
+	require.NoError(t, err)
+	child, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Child of B", DueDate: futureDue, ParentID: b.ID})
+	require.NoError(t, err)
+
+	// A blocked by B while B is blocked by A
+	blockedByB := []Domain.ID{b.ID}
+	_, err = usecase.PatchTask(ctx, actor, a.ID.String(), Domain.TaskPatch{BlockedBy: &blockedByB})
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+
+	// A subtask cannot be blocked by its parent, which waits for it
+	blockedByParent := []Domain.ID{b.ID}
+	_, err = usecase.PatchTask(ctx, actor, child.ID.String(), Domain.TaskPatch{BlockedBy: &blockedByParent})
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+	_, err = usecase.AddTask(ctx, actor, Domain.Task{Title: "New child", DueDate: futureDue, ParentID: b.ID, BlockedBy: []Domain.ID{b.ID}})
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+
+	// Moving a task under its own subtask
+	_, err = usecase.PatchTask(ctx, actor, b.ID.String(), Domain.TaskPatch{ParentID: &child.ID})
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+	_, err = usecase.PatchTask(ctx, actor, b.ID.String(), Domain.TaskPatch{ParentID: &b.ID})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+
+	// Moving the child elsewhere is fine, and so is blocking A by it afterwards
+	_, err = usecase.PatchTask(ctx, actor, child.ID.String(), Domain.TaskPatch{ParentID: &a.ID})
+	require.NoError(t, err)
+	other, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Other", DueDate: futureDue})
+	require.NoError(t, err)
+	blockedByOther := []Domain.ID{other.ID}
+	_, err = usecase.PatchTask(ctx, actor, a.ID.String(), Domain.TaskPatch{BlockedBy: &blockedByOther})
+	require.NoError(t, err)
+}
+
+func TestTaskUsecase_RestoreRejectsDependencyCycles(t *testing.T) {
+	usecase := newDependencyUsecase()
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	c, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "C", DueDate: futureDue})
+	require.NoError(t, err)
+	b, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "B", DueDate: futureDue, BlockedBy: []Domain.ID{c.ID}})
+	require.NoError(t, err)
+	a, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "A", DueDate: futureDue, BlockedBy: []Domain.ID{b.ID}})
+	require.NoError(t, err)
+
+	// With B in the trash, C may wait for A
+	require.NoError(t, usecase.DeleteTask(ctx, actor, b.ID.String()))
+	blockedByA := []Domain.ID{a.ID}
+	_, err = usecase.PatchTask(ctx, actor, c.ID.String(), Domain.TaskPatch{BlockedBy: &blockedByA})
+	require.NoError(t, err)
+
+	// Restoring B would close the cycle B -> C -> A -> B
+	_, err = usecase.RestoreTask(ctx, actor, b.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrDependencyCycle)
+	_, err = usecase.GetTaskByID(ctx, actor, b.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	// Once C no longer waits for A, B can come back
+	var none []Domain.ID
+	_, err = usecase.PatchTask(ctx, actor, c.ID.String(), Domain.TaskPatch{BlockedBy: &none})
+	require.NoError(t, err)
+	_, err = usecase.RestoreTask(ctx, actor, b.ID.String())
+	require.NoError(t, err)
+}
+
+func TestTaskUsecase_RejectsUnknownRelations(t *testing.T) {
+	usecase := newDependencyUsecase()
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	hidden, err := usecase.AddTask(ctx, Domain.Actor{UserID: Domain.NewID(), Role: "user"}, Domain.Task{Title: "Someone else's", DueDate: futureDue})
+	require.NoError(t, err)
+
+	_, err = usecase.AddTask(ctx, actor, Domain.Task{Title: "Child", DueDate: futureDue, ParentID: hidden.ID})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	_, err = usecase.AddTask(ctx, actor, Domain.Task{Title: "Blocked", DueDate: futureDue, BlockedBy: []Domain.ID{Domain.NewID()}})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+}
+
+func TestTaskUsecase_CompletionWaitsForBlockersAndSubtasks(t *testing.T) {
+	usecase := newDependencyUsecase()
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	done := Domain.StatusCompleted
+
+	blocker, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Blocker", DueDate: futureDue})
+	require.NoError(t, err)
+	parent, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Parent", DueDate: futureDue, BlockedBy: []Domain.ID{blocker.ID}})
+	require.NoError(t, err)
+	child, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Child", DueDate: futureDue, ParentID: parent.ID})
+	require.NoError(t, err)
+
+	_, err = usecase.PatchTask(ctx, actor, parent.ID.String(), Domain.TaskPatch{Status: &done})
+	assert.ErrorIs(t, err, Domain.ErrTaskBlocked)
+
+	_, err = usecase.PatchTask(ctx, actor, blocker.ID.String(), Domain.TaskPatch{Status: &done})
+	require.NoError(t, err)
+	_, err = usecase.PatchTask(ctx, actor, parent.ID.String(), Domain.TaskPatch{Status: &done})
+	assert.ErrorIs(t, err, Domain.ErrTaskBlocked, "the child is still pending")
+
+	_, err = usecase.PatchTask(ctx, actor, child.ID.String(), Domain.TaskPatch{Status: &done})
+	require.NoError(t, err)
+	completed, err := usecase.PatchTask(ctx, actor, parent.ID.String(), Domain.TaskPatch{Status: &done})
+	require.NoError(t, err)
+	assert.Equal(t, Domain.StatusCompleted, completed.Status)
+}
+
+func TestTaskUsecase_GetTaskTree(t *testing.T) {
+	usecase := newDependencyUsecase()
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Role: "user", Team: "platform"}
+
+	root, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Root", DueDate: futureDue})
+	require.NoError(t, err)
+	child, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Child", DueDate: futureDue, ParentID: root.ID})
+	require.NoError(t, err)
+	_, err = usecase.AddTask(ctx, owner, Domain.Task{Title: "Grandchild", DueDate: futureDue, ParentID: child.ID})
+	require.NoError(t, err)
+	// The team's manager adds a subtask the owner cannot read; the parent must be readable to do so
+	mate := Domain.Actor{UserID: Domain.NewID(), Role: "manager", Team: "platform"}
+	_, err = usecase.AddTask(ctx, Domain.Actor{UserID: mate.UserID, Role: "manager"}, Domain.Task{Title: "Hidden", DueDate: futureDue, ParentID: root.ID})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	_, err = usecase.AddTask(ctx, mate, Domain.Task{Title: "Team subtask", DueDate: futureDue, ParentID: root.ID})
+	require.NoError(t, err)
+
+	tree, err := usecase.GetTaskTree(ctx, owner, root.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Root", tree.Task.Title)
+	require.Len(t, tree.Subtasks, 1, "the team mate's subtask is not the owner's")
+	assert.Equal(t, "Child", tree.Subtasks[0].Task.Title)
+	require.Len(t, tree.Subtasks[0].Subtasks, 1)
+	assert.Equal(t, "Grandchild", tree.Subtasks[0].Subtasks[0].Task.Title)
+
+	tree, err = usecase.GetTaskTree(ctx, mate, root.ID.String())
+	require.NoError(t, err)
+	assert.Len(t, tree.Subtasks, 2)
+}
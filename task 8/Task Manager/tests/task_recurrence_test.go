@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"taskmanager/Domain"
+	"taskmanager/Repositories"
+	"taskmanager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParseRecurrence(t *testing.T) {
+	rule, err := Domain.ParseRecurrence("rrule:freq=weekly;byday=fr,mo;interval=2;count=3")
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR;COUNT=3", rule.String())
+
+	rule, err = Domain.ParseRecurrence("FREQ=MONTHLY;UNTIL=20301231T235959Z")
+	require.NoError(t, err)
+	assert.Equal(t, day(2030, 12, 31), rule.Until)
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20301231",
+		"FREQ=MONTHLY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=DAILY;FREQ=WEEKLY",
+	}
+	for _, s := range invalid {
+		_, err := Domain.ParseRecurrence(s)
+		assert.ErrorIs(t, err, Domain.ErrValidation, s)
+	}
+}
+
+func TestRecurrenceAfter(t *testing.T) {
+	cases := []struct {
+		rule string
+		from time.Time
+		want time.Time
+	}{
+		{"FREQ=DAILY", day(2030, 5, 1), day(2030, 5, 2)},
+		{"FREQ=DAILY;INTERVAL=3", day(2030, 5, 1), day(2030, 5, 4)},
+		{"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", day(2030, 5, 3), day(2030, 5, 6)}, // Friday to Monday
+		{"FREQ=WEEKLY", day(2030, 5, 1), day(2030, 5, 8)},
+		{"FREQ=WEEKLY;BYDAY=MO,TH", day(2030, 5, 6), day(2030, 5, 9)},             // Monday to Thursday
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", day(2030, 5, 9), day(2030, 5, 20)}, // Thursday to Monday two weeks on
+		{"FREQ=WEEKLY;BYDAY=SU", day(2030, 5, 5), day(2030, 5, 12)},
+		{"FREQ=MONTHLY", day(2030, 1, 15), day(2030, 2, 15)},
+		{"FREQ=MONTHLY", day(2030, 1, 31), day(2030, 3, 31)}, // no February 31
+		{"FREQ=MONTHLY;INTERVAL=3", day(2030, 11, 30), day(2031, 5, 30)},
+		{"FREQ=YEARLY", day(2028, 2, 29), day(2032, 2, 29)},
+	}
+	for _, tc := range cases {
+		rule, err := Domain.ParseRecurrence(tc.rule)
+		require.NoError(t, err)
+		got, ok := rule.After(tc.from)
+		require.True(t, ok, tc.rule)
+		assert.Equal(t, tc.want, got, "%s after %s", tc.rule, tc.from.Format("2006-01-02"))
+	}
+
+	never, err := Domain.ParseRecurrence("FREQ=DAILY;INTERVAL=7;BYDAY=MO")
+	require.NoError(t, err)
+	_, ok := never.After(day(2030, 5, 7)) // a Tuesday, every 7 days is always a Tuesday
+	assert.False(t, ok)
+}
+
+func TestTaskNextOccurrence(t *testing.T) {
+	owner := Domain.NewID()
+	task := Domain.Task{ID: Domain.NewID(), OwnerID: owner, Title: "Water plants", DueDate: day(2030, 5, 1), Status: Domain.StatusCompleted,
+		Priority: Domain.PriorityLow, Labels: []string{"home"}, Recurrence: "FREQ=DAILY;COUNT=5", Occurrence: 1}
+
+	next, ok, err := task.NextOccurrence(day(2030, 5, 1).Add(10 * time.Hour))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, day(2030, 5, 2), next.DueDate)
+	assert.Equal(t, 2, next.Occurrence)
+	assert.Equal(t, Domain.StatusPending, next.Status)
+	assert.Equal(t, owner, next.OwnerID)
+	assert.True(t, next.ID.IsZero())
+	assert.Equal(t, []string{"home"}, next.Labels)
+
+	// Completed three days late: the missed days are skipped but count towards COUNT
+	next, ok, err = task.NextOccurrence(day(2030, 5, 4))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, day(2030, 5, 4), next.DueDate)
+	assert.Equal(t, 4, next.Occurrence)
+
+	_, ok, err = task.NextOccurrence(day(2030, 5, 6))
+	require.NoError(t, err)
+	assert.False(t, ok, "the fifth occurrence was due on the 5th, the series is over")
+
+	task.Recurrence = "FREQ=WEEKLY;UNTIL=20300510"
+	next, ok, err = task.NextOccurrence(day(2030, 5, 1))
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, day(2030, 5, 8), next.DueDate)
+	next.Occurrence, next.DueDate = 2, day(2030, 5, 8)
+	_, ok, err = next.NextOccurrence(day(2030, 5, 8))
+	require.NoError(t, err)
+	assert.False(t, ok, "May 15 is after UNTIL")
+}
+
+func TestTaskNextOccurrence_UntilIncludesItsDay(t *testing.T) {
+	due := day(2025, 12, 24).Add(9 * time.Hour)
+	for _, rule := range []string{"FREQ=WEEKLY;UNTIL=20251231", "FREQ=WEEKLY;UNTIL=20251231T235959Z"} {
+		t.Run(rule, func(t *testing.T) {
+			task := Domain.Task{Title: "Standup notes", DueDate: due, Recurrence: rule, Occurrence: 1}
+			next, ok, err := task.NextOccurrence(due)
+			require.NoError(t, err)
+			require.True(t, ok, "Dec 31 at 09:00 is on the UNTIL day")
+			assert.Equal(t, day(2025, 12, 31).Add(9*time.Hour), next.DueDate)
+
+			_, ok, err = next.NextOccurrence(next.DueDate)
+			require.NoError(t, err)
+			assert.False(t, ok, "Jan 7 is after UNTIL")
+		})
+	}
+}
+
+func TestTaskUsecase_CompletingRecurringTaskCreatesNextOccurrence(t *testing.T) {
+	taskRepo := Repositories.NewMemoryTaskRepository()
+	now := day(2030, 5, 1).Add(9 * time.Hour)
+	usecase := Usecases.NewTaskUsecase(taskRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy(),
+		Usecases.WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	task, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Weekly report", DueDate: day(2030, 5, 3), Recurrence: "freq=weekly;byday=fr;count=2"})
+	require.NoError(t, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=FR;COUNT=2", task.Recurrence)
+	assert.Equal(t, 1, task.Occurrence)
+
+	completed := Domain.StatusCompleted
+	done, err := usecase.PatchTask(ctx, owner, task.ID.String(), Domain.TaskPatch{Status: &completed})
+	require.NoError(t, err)
+	assert.Empty(t, done.Recurrence, "the rule moves on to the next occurrence")
+
+	page, err := usecase.ListTasks(ctx, owner, Domain.TaskQuery{Status: Domain.StatusPending, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	next := page.Tasks[0]
+	assert.Equal(t, "Weekly report", next.Title)
+	assert.True(t, day(2030, 5, 10).Equal(next.DueDate), "due %s", next.DueDate)
+	assert.Equal(t, 2, next.Occurrence)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=FR;COUNT=2", next.Recurrence)
+
+	// Reopening and completing again does not create another occurrence
+	admin := adminActor
+	pending := Domain.StatusPending
+	_, err = usecase.PatchTask(ctx, admin, task.ID.String(), Domain.TaskPatch{Status: &pending})
+	require.NoError(t, err)
+	_, err = usecase.PatchTask(ctx, admin, task.ID.String(), Domain.TaskPatch{Status: &completed})
+	require.NoError(t, err)
+
+	// The second occurrence is the last one
+	now = day(2030, 5, 10)
+	_, err = usecase.UpdateTask(ctx, owner, next.ID.String(), Domain.Task{Title: next.Title, DueDate: next.DueDate, Status: Domain.StatusCompleted, Recurrence: next.Recurrence})
+	require.NoError(t, err)
+	all, err := usecase.GetAllTasks(ctx, owner)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+// flakyOccurrenceRepository fails to store next occurrences, or to store completed tasks
+type flakyOccurrenceRepository struct {
+	*Repositories.MemoryTaskRepository
+	failOccurrences, failCompletions bool
+}
+
+func (r *flakyOccurrenceRepository) AddTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	if r.failOccurrences && task.Occurrence > 1 {
+		return nil, errors.New("database unavailable")
+	}
+	return r.MemoryTaskRepository.AddTask(ctx, task)
+}
+
+func (r *flakyOccurrenceRepository) UpdateTask(ctx context.Context, task Domain.Task) (*Domain.Task, error) {
+	if r.failCompletions && task.Status == Domain.StatusCompleted {
+		return nil, Domain.ErrVersionConflict
+	}
+	return r.MemoryTaskRepository.UpdateTask(ctx, task)
+}
+
+func TestTaskUsecase_CompletingRecurringTaskKeepsTheSeriesOnFailure(t *testing.T) {
+	taskRepo := &flakyOccurrenceRepository{MemoryTaskRepository: Repositories.NewMemoryTaskRepository()}
+	now := day(2030, 5, 1).Add(9 * time.Hour)
+	usecase := Usecases.NewTaskUsecase(taskRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy(),
+		Usecases.WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	task, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Weekly report", DueDate: day(2030, 5, 3), Recurrence: "FREQ=WEEKLY"})
+	require.NoError(t, err)
+	completed := Domain.StatusCompleted
+
+	// The occurrence cannot be stored: the task is not completed and keeps its rule
+	taskRepo.failOccurrences = true
+	_, err = usecase.PatchTask(ctx, owner, task.ID.String(), Domain.TaskPatch{Status: &completed})
+	require.Error(t, err)
+	stored, err := taskRepo.GetTaskByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Domain.StatusPending, stored.Status)
+	assert.Equal(t, "FREQ=WEEKLY", stored.Recurrence)
+
+	// The completion cannot be stored: the occurrence stored for it is removed again
+	taskRepo.failOccurrences, taskRepo.failCompletions = false, true
+	_, err = usecase.PatchTask(ctx, owner, task.ID.String(), Domain.TaskPatch{Status: &completed})
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	all, err := taskRepo.GetAllTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	// Retrying once the repository recovered completes the task and continues the series
+	taskRepo.failCompletions = false
+	done, err := usecase.PatchTask(ctx, owner, task.ID.String(), Domain.TaskPatch{Status: &completed})
+	require.NoError(t, err)
+	assert.Empty(t, done.Recurrence)
+	all, err = taskRepo.GetAllTasks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestTaskUsecase_RejectsInvalidRecurrence(t *testing.T) {
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	_, err := usecase.AddTask(context.Background(), Domain.Actor{UserID: Domain.NewID(), Role: "user"},
+		Domain.Task{Title: "Broken", DueDate: futureDue, Recurrence: "FREQ=SOMETIMES"})
+
+	var verr *Domain.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Equal(t, "recurrence", verr.Fields[0].Field)
+}
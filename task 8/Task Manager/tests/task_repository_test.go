@@ -5,10 +5,15 @@ import (
 	"taskmanager/Domain"
 	"taskmanager/Repositories"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // // MockCollection is a simple mock for the Collection interface
@@ -32,9 +37,13 @@ func (m *MockCollection) DeleteOne(ctx context.Context, filter interface{}, opts
 	return args.Get(0).(Repositories.DeleteResult), args.Error(1)
 }
 
-// Add a stub Find to satisfy the interface
+// Find records the filter and options so tests can inspect the generated query
 func (m *MockCollection) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.Cursor, error) {
-	return nil, nil
+	args := m.Called(ctx, filter, opts)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(Repositories.Cursor), args.Error(1)
 }
 
 // Add a stub FindOne to satisfy the interface
@@ -43,9 +52,28 @@ func (m *MockCollection) FindOne(ctx context.Context, filter interface{}, opts .
 	return args.Get(0).(Repositories.SingleResult)
 }
 
-// Add a stub FindOneAndUpdate to satisfy the interface
+// FindOneAndUpdate records the filter and update so tests can inspect the conditional write
 func (m *MockCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) Repositories.SingleResult {
-	return nil
+	args := m.Called(ctx, filter, update)
+	return args.Get(0).(Repositories.SingleResult)
+}
+
+// Aggregate records the pipeline so tests can inspect the generated stages
+func (m *MockCollection) Aggregate(ctx context.Context, pipeline interface{}, opts ...interface{}) (Repositories.Cursor, error) {
+	args := m.Called(ctx, pipeline)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(Repositories.Cursor), args.Error(1)
+}
+
+func (m *MockCollection) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	return m.Called(ctx, models).Error(0)
+}
+
+func (m *MockCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}) (int64, error) {
+	args := m.Called(ctx, filter, update)
+	return args.Get(0).(int64), args.Error(1)
 }
 
 func (m *MockSingleResult) Decode(val interface{}) error {
@@ -56,6 +84,49 @@ func (m *MockSingleResult) Decode(val interface{}) error {
 	return nil
 }
 
+// MockCursor iterates over a fixed slice of task entities
+type MockCursor struct {
+	entities []Repositories.TaskEntity
+	pos      int
+}
+
+func (c *MockCursor) Next(context.Context) bool {
+	c.pos++
+	return c.pos <= len(c.entities)
+}
+
+func (c *MockCursor) Decode(val interface{}) error {
+	*(val.(*Repositories.TaskEntity)) = c.entities[c.pos-1]
+	return nil
+}
+
+func (c *MockCursor) Close(context.Context) error { return nil }
+
+func (c *MockCursor) Err() error { return nil }
+
+// MockDocumentCursor iterates over raw documents, for results that are not task entities
+type MockDocumentCursor struct {
+	docs []bson.M
+	pos  int
+}
+
+func (c *MockDocumentCursor) Next(context.Context) bool {
+	c.pos++
+	return c.pos <= len(c.docs)
+}
+
+func (c *MockDocumentCursor) Decode(val interface{}) error {
+	raw, err := bson.Marshal(c.docs[c.pos-1])
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, val)
+}
+
+func (c *MockDocumentCursor) Close(context.Context) error { return nil }
+
+func (c *MockDocumentCursor) Err() error { return nil }
+
 type MockDeleteResult struct {
 	deleted int64
 }
@@ -82,7 +153,7 @@ func TestMongoTaskRepository_AddTask(t *testing.T) {
 	// Check the result
 	assert.NoError(t, err)
 	assert.Equal(t, "Minimal Real Test", result.Title)
-	assert.Equal(t, insertedID, result.ID)
+	assert.Equal(t, Repositories.DomainIDFromObjectID(insertedID), result.ID)
 
 	mockColl.AssertExpectations(t)
 }
@@ -94,43 +165,285 @@ func TestMongoTaskRepository_GetTaskByID_Found(t *testing.T) {
 	entity := Repositories.TaskEntity{ID: fakeID, Title: "Found Task"}
 	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(&MockSingleResult{entity: entity, err: nil})
 
-	result, err := repo.GetTaskByID(context.Background(), fakeID)
+	result, err := repo.GetTaskByID(context.Background(), Repositories.DomainIDFromObjectID(fakeID))
 	assert.NoError(t, err)
 	assert.Equal(t, "Found Task", result.Title)
-	assert.Equal(t, fakeID, result.ID)
+	assert.Equal(t, Repositories.DomainIDFromObjectID(fakeID), result.ID)
 	mockColl.AssertExpectations(t)
 }
 
 func TestMongoTaskRepository_GetTaskByID_NotFound(t *testing.T) {
 	mockColl := new(MockCollection)
 	repo := Repositories.NewMongoTaskRepository(mockColl)
-	fakeID := primitive.NewObjectID()
-	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(&MockSingleResult{err: assert.AnError})
+	fakeID := Domain.NewID()
+	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(&MockSingleResult{err: mongo.ErrNoDocuments})
 
 	result, err := repo.GetTaskByID(context.Background(), fakeID)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	assert.Nil(t, result)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoTaskRepository_GetTaskByID_DatabaseError(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(&MockSingleResult{err: assert.AnError})
+
+	// A failing database must not be reported as a missing task
+	result, err := repo.GetTaskByID(context.Background(), Domain.NewID())
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NotErrorIs(t, err, Domain.ErrNotFound)
 	assert.Nil(t, result)
+}
+
+func TestMongoTaskRepository_UpdateTask_IncrementsVersion(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	id := primitive.NewObjectID()
+	stored := Repositories.TaskEntity{ID: id, Title: "Renamed", Version: 4}
+	mockColl.On("FindOneAndUpdate", mock.Anything, bson.M{"_id": id, "version": int64(3), "deleted_at": nil}, mock.Anything).
+		Return(&MockSingleResult{entity: stored})
+
+	result, err := repo.UpdateTask(context.Background(), Domain.Task{ID: Repositories.DomainIDFromObjectID(id), Title: "Renamed", Version: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), result.Version)
+
+	update := mockColl.Calls[0].Arguments.Get(2).(bson.M)
+	assert.Equal(t, int64(4), update["$set"].(Repositories.TaskEntity).Version)
 	mockColl.AssertExpectations(t)
 }
 
+func TestMongoTaskRepository_UpdateTask_VersionConflict(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	id := primitive.NewObjectID()
+	mockColl.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(&MockSingleResult{err: mongo.ErrNoDocuments})
+	// The task still exists, so it was its version that did not match
+	mockColl.On("FindOne", mock.Anything, bson.M{"_id": id, "deleted_at": nil}).Return(&MockSingleResult{entity: Repositories.TaskEntity{ID: id, Version: 5}})
+
+	result, err := repo.UpdateTask(context.Background(), Domain.Task{ID: Repositories.DomainIDFromObjectID(id), Version: 3})
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	assert.Nil(t, result)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoTaskRepository_UpdateTask_NotFound(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	mockColl.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(&MockSingleResult{err: mongo.ErrNoDocuments})
+	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(&MockSingleResult{err: mongo.ErrNoDocuments})
+
+	_, err := repo.UpdateTask(context.Background(), Domain.Task{ID: Domain.NewID(), Version: 1})
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
 func TestMongoTaskRepository_DeleteTask_Found(t *testing.T) {
 	mockColl := new(MockCollection)
 	repo := Repositories.NewMongoTaskRepository(mockColl)
-	fakeID := primitive.NewObjectID()
-	mockColl.On("DeleteOne", mock.Anything, mock.Anything).Return(&MockDeleteResult{deleted: 1}, nil)
+	id, deletedBy := primitive.NewObjectID(), primitive.NewObjectID()
+	at := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	// Deleting only marks the document, and only if it is not in the trash already
+	mockColl.On("FindOneAndUpdate", mock.Anything, bson.M{"_id": id, "deleted_at": nil}, bson.M{
+		"$set": bson.M{"deleted_at": primitive.NewDateTimeFromTime(at), "deleted_by": deletedBy},
+		"$inc": bson.M{"version": 1},
+	}).Return(&MockSingleResult{entity: Repositories.TaskEntity{ID: id}})
 
-	err := repo.DeleteTask(context.Background(), fakeID)
+	err := repo.DeleteTask(context.Background(), Repositories.DomainIDFromObjectID(id), Repositories.DomainIDFromObjectID(deletedBy), at)
 	assert.NoError(t, err)
+	mockColl.AssertNotCalled(t, "DeleteOne", mock.Anything, mock.Anything)
 	mockColl.AssertExpectations(t)
 }
 
 func TestMongoTaskRepository_DeleteTask_NotFound(t *testing.T) {
 	mockColl := new(MockCollection)
 	repo := Repositories.NewMongoTaskRepository(mockColl)
-	fakeID := primitive.NewObjectID()
-	mockColl.On("DeleteOne", mock.Anything, mock.Anything).Return(&MockDeleteResult{deleted: 0}, nil)
+	fakeID := Domain.NewID()
+	mockColl.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).Return(&MockSingleResult{err: mongo.ErrNoDocuments})
+
+	err := repo.DeleteTask(context.Background(), fakeID, Domain.NewID(), time.Now())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoTaskRepository_GetTaskByID_ExcludesTrash(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	id := primitive.NewObjectID()
+	mockColl.On("FindOne", mock.Anything, bson.M{"_id": id, "deleted_at": nil}).Return(&MockSingleResult{err: mongo.ErrNoDocuments})
 
-	err := repo.DeleteTask(context.Background(), fakeID)
-	assert.Error(t, err)
+	_, err := repo.GetTaskByID(context.Background(), Repositories.DomainIDFromObjectID(id))
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
 	mockColl.AssertExpectations(t)
 }
+
+func TestMongoTaskRepository_PurgeTask_OnlyFromTrash(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	id := primitive.NewObjectID()
+	mockColl.On("DeleteOne", mock.Anything, bson.M{"_id": id, "deleted_at": bson.M{"$ne": nil}}).Return(&MockDeleteResult{deleted: 0}, nil)
+
+	err := repo.PurgeTask(context.Background(), Repositories.DomainIDFromObjectID(id))
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoTaskRepository_ListTasks_Paginates(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	ownerID := Domain.NewID()
+	ownerObjectID, err := Repositories.ObjectIDFromDomain(ownerID)
+	assert.NoError(t, err)
+	entities := []Repositories.TaskEntity{
+		{ID: primitive.NewObjectID(), Title: "A"},
+		{ID: primitive.NewObjectID(), Title: "B"},
+		{ID: primitive.NewObjectID(), Title: "C"},
+	}
+	var gotFilter bson.D
+	var gotOpts *options.FindOptions
+	mockColl.On("Find", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			gotFilter = args.Get(1).(bson.D)
+			gotOpts = args.Get(2).([]interface{})[0].(*options.FindOptions)
+		}).
+		Return(&MockCursor{entities: entities}, nil)
+
+	page, err := repo.ListTasks(context.Background(), Domain.TaskQuery{
+		OwnerID: ownerID,
+		Status:  "Pending",
+		Sort:    Domain.SortByTitle,
+		Limit:   2,
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Equal(t, int64(3), *gotOpts.Limit)
+	assert.Equal(t, bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}}, gotOpts.Sort)
+	assert.Equal(t, bson.A{bson.M{"owner_id": ownerObjectID}, bson.M{"status": "Pending"}, bson.M{"deleted_at": nil}}, gotFilter[0].Value)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoTaskRepository_ListTasks_Assignee(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	assigneeID := primitive.NewObjectID()
+	var gotFilter bson.D
+	mockColl.On("Find", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { gotFilter = args.Get(1).(bson.D) }).
+		Return(&MockCursor{entities: []Repositories.TaskEntity{{ID: primitive.NewObjectID(), AssigneeIDs: []primitive.ObjectID{assigneeID}}}}, nil)
+
+	page, err := repo.ListTasks(context.Background(), Domain.TaskQuery{AssigneeID: Repositories.DomainIDFromObjectID(assigneeID), Limit: 10})
+
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, []Domain.ID{Repositories.DomainIDFromObjectID(assigneeID)}, page.Tasks[0].AssigneeIDs)
+	assert.Equal(t, bson.A{bson.M{"assignee_ids": assigneeID}, bson.M{"deleted_at": nil}}, gotFilter[0].Value)
+}
+
+func TestMongoTaskRepository_ListTasks_LabelAndPriority(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	var gotFilter bson.D
+	mockColl.On("Find", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { gotFilter = args.Get(1).(bson.D) }).
+		Return(&MockCursor{entities: []Repositories.TaskEntity{{ID: primitive.NewObjectID(), Priority: "high", Labels: []string{"backend"}}}}, nil)
+
+	page, err := repo.ListTasks(context.Background(), Domain.TaskQuery{Label: "backend", Priority: Domain.PriorityHigh, Limit: 10})
+
+	require.NoError(t, err)
+	require.Len(t, page.Tasks, 1)
+	assert.Equal(t, Domain.PriorityHigh, page.Tasks[0].Priority)
+	assert.Equal(t, []string{"backend"}, page.Tasks[0].Labels)
+	assert.Equal(t, bson.A{bson.M{"priority": "high"}, bson.M{"labels": "backend"}, bson.M{"deleted_at": nil}}, gotFilter[0].Value)
+}
+
+func TestMongoTaskRepository_CountLabels(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	ownerID := primitive.NewObjectID()
+	var gotPipeline mongo.Pipeline
+	mockColl.On("Aggregate", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { gotPipeline = args.Get(1).(mongo.Pipeline) }).
+		Return(&MockDocumentCursor{docs: []bson.M{{"_id": "backend", "count": 3}, {"_id": "bug", "count": 1}}}, nil)
+
+	counts, err := repo.CountLabels(context.Background(), Domain.TaskQuery{OwnerID: Repositories.DomainIDFromObjectID(ownerID), Cursor: "ignored"})
+
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.LabelCount{{Label: "backend", Count: 3}, {Label: "bug", Count: 1}}, counts)
+	require.Len(t, gotPipeline, 4)
+	assert.Equal(t, bson.D{{Key: "$and", Value: bson.A{bson.M{"owner_id": ownerID}, bson.M{"deleted_at": nil}}}}, gotPipeline[0][0].Value)
+	assert.Equal(t, "$unwind", gotPipeline[1][0].Key)
+	assert.Equal(t, "$group", gotPipeline[2][0].Key)
+}
+
+func TestMongoTaskRepository_EnsureIndexes(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	var gotModels []mongo.IndexModel
+	mockColl.On("CreateIndexes", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { gotModels = args.Get(1).([]mongo.IndexModel) }).
+		Return(nil)
+
+	require.NoError(t, repo.EnsureIndexes(context.Background()))
+	require.Len(t, gotModels, 2)
+	assert.Equal(t, bson.D{{Key: "labels", Value: 1}}, gotModels[0].Keys)
+	assert.Equal(t, bson.D{{Key: "priority", Value: 1}}, gotModels[1].Keys)
+}
+
+func TestMongoTaskRepository_BackfillVersions(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	var gotFilter, gotUpdate bson.M
+	mockColl.On("UpdateMany", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			gotFilter = args.Get(1).(bson.M)
+			gotUpdate = args.Get(2).(bson.M)
+		}).
+		Return(int64(2), nil)
+
+	require.NoError(t, repo.BackfillVersions(context.Background()))
+	assert.Equal(t, bson.A{bson.M{"version": 0}, bson.M{"version": bson.M{"$exists": false}}}, gotFilter["$or"])
+	assert.Equal(t, bson.M{"$set": bson.M{"version": 1}}, gotUpdate)
+}
+
+func TestMongoTaskRepository_GetSubtasks(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	parentID := primitive.NewObjectID()
+	childID := primitive.NewObjectID()
+	mockColl.On("Find", mock.Anything, bson.M{"parent_id": parentID, "deleted_at": nil}, mock.Anything).
+		Return(&MockCursor{entities: []Repositories.TaskEntity{{ID: childID, ParentID: parentID}}}, nil)
+
+	subtasks, err := repo.GetSubtasks(context.Background(), Repositories.DomainIDFromObjectID(parentID))
+
+	require.NoError(t, err)
+	require.Len(t, subtasks, 1)
+	assert.Equal(t, Repositories.DomainIDFromObjectID(parentID), subtasks[0].ParentID)
+	mockColl.AssertExpectations(t)
+}
+
+func TestMongoTaskRepository_ListTasks_LastPage(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	entities := []Repositories.TaskEntity{{ID: primitive.NewObjectID(), Title: "Only"}}
+	mockColl.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(&MockCursor{entities: entities}, nil)
+
+	page, err := repo.ListTasks(context.Background(), Domain.TaskQuery{Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestMongoTaskRepository_ListTasks_CursorForOtherSort(t *testing.T) {
+	mockColl := new(MockCollection)
+	repo := Repositories.NewMongoTaskRepository(mockColl)
+	entities := []Repositories.TaskEntity{{ID: primitive.NewObjectID()}, {ID: primitive.NewObjectID()}}
+	mockColl.On("Find", mock.Anything, mock.Anything, mock.Anything).Return(&MockCursor{entities: entities}, nil).Once()
+
+	page, err := repo.ListTasks(context.Background(), Domain.TaskQuery{Sort: Domain.SortByTitle, Limit: 1})
+	assert.NoError(t, err)
+
+	_, err = repo.ListTasks(context.Background(), Domain.TaskQuery{Sort: Domain.SortByDueDate, Cursor: page.NextCursor, Limit: 1})
+	assert.ErrorIs(t, err, Domain.ErrInvalidCursor)
+}
@@ -3,13 +3,16 @@ package tests
 
 import (
 	"context"
+	"errors"
 	"taskmanager/Domain"
+	"taskmanager/Repositories"
 	"taskmanager/Usecases"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"github.com/stretchr/testify/require"
 )
 
 // MockTaskRepository implements the TaskRepository interface for testing
@@ -22,7 +25,30 @@ func (m *MockTaskRepository) GetAllTasks(ctx context.Context) ([]Domain.Task, er
 	return args.Get(0).([]Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) GetTaskByID(ctx context.Context, id primitive.ObjectID) (*Domain.Task, error) {
+func (m *MockTaskRepository) GetSubtasks(ctx context.Context, parentID Domain.ID) ([]Domain.Task, error) {
+	args := m.Called(ctx, parentID)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountLabels(ctx context.Context, query Domain.TaskQuery) ([]Domain.LabelCount, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]Domain.LabelCount), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTasksByOwner(ctx context.Context, ownerID Domain.ID) ([]Domain.Task, error) {
+	args := m.Called(ctx, ownerID)
+	return args.Get(0).([]Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) ListTasks(ctx context.Context, query Domain.TaskQuery) (*Domain.TaskPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Domain.TaskPage), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
 	args := m.Called(ctx, id)
 	return args.Get(0).(*Domain.Task), args.Error(1)
 }
@@ -37,82 +63,564 @@ func (m *MockTaskRepository) UpdateTask(ctx context.Context, task Domain.Task) (
 	return args.Get(0).(*Domain.Task), args.Error(1)
 }
 
-func (m *MockTaskRepository) DeleteTask(ctx context.Context, id primitive.ObjectID) error {
+func (m *MockTaskRepository) DeleteTask(ctx context.Context, id Domain.ID, deletedBy Domain.ID, at time.Time) error {
+	args := m.Called(ctx, id, deletedBy, at)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) GetDeletedTaskByID(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) RestoreTask(ctx context.Context, id Domain.ID) (*Domain.Task, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*Domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) PurgeTask(ctx context.Context, id Domain.ID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
 func TestTaskUsecase_AddTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
 
-	inputTask := Domain.Task{Title: "Learn Go Usecases"}
-	expectedTask := &Domain.Task{Title: "Learn Go Usecases"}
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user", Team: "platform"}
+	inputTask := Domain.Task{Title: "Learn Go Usecases", DueDate: futureDue}
+	expectedTask := &Domain.Task{Title: "Learn Go Usecases", DueDate: futureDue, OwnerID: actor.UserID, Team: "platform", Status: Domain.StatusPending, Priority: Domain.PriorityMedium}
 
-	mockRepo.On("AddTask", mock.Anything, inputTask).Return(expectedTask, nil)
+	// The owner and team always come from the actor, the status defaults to Pending and the priority to medium
+	mockRepo.On("AddTask", mock.Anything, *expectedTask).Return(expectedTask, nil)
 
-	result, err := usecase.AddTask(context.Background(), inputTask)
+	result, err := usecase.AddTask(context.Background(), actor, inputTask)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTask, result)
 	mockRepo.AssertExpectations(t)
 }
 
+var adminActor = Domain.Actor{UserID: Domain.NewID(), Username: "admin", Role: "admin"}
+
+// futureDue is a due date every new task may use
+var futureDue = time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func TestTaskUsecase_GetAllTasks(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
 
 	expectedTasks := []Domain.Task{{Title: "Task 1"}, {Title: "Task 2"}}
 	mockRepo.On("GetAllTasks", mock.Anything).Return(expectedTasks, nil)
 
-	result, err := usecase.GetAllTasks(context.Background())
+	result, err := usecase.GetAllTasks(context.Background(), adminActor)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTasks, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskUsecase_GetAllTasks_ScopedToOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	expectedTasks := []Domain.Task{{Title: "Mine", OwnerID: actor.UserID}}
+	mockRepo.On("GetTasksByOwner", mock.Anything, actor.UserID).Return(expectedTasks, nil)
+
+	result, err := usecase.GetAllTasks(context.Background(), actor)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTasks, result)
+	mockRepo.AssertNotCalled(t, "GetAllTasks", mock.Anything)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestTaskUsecase_ListTasks_ScopedToOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	expectedPage := &Domain.TaskPage{Tasks: []Domain.Task{{Title: "Mine"}}}
+	// The requested owner is replaced by the caller and the default page size is applied
+	mockRepo.On("ListTasks", mock.Anything, Domain.TaskQuery{OwnerID: actor.UserID, Status: "Pending", Limit: Domain.DefaultTaskPageSize}).Return(expectedPage, nil)
+
+	result, err := usecase.ListTasks(context.Background(), actor, Domain.TaskQuery{OwnerID: Domain.NewID(), Status: "Pending"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPage, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskUsecase_ListTasks_InvalidSort(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	result, err := usecase.ListTasks(context.Background(), adminActor, Domain.TaskQuery{Sort: "priority"})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	mockRepo.AssertNotCalled(t, "ListTasks", mock.Anything, mock.Anything)
+}
+
 func TestTaskUsecase_GetTaskByID(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
 
-	fakeID := primitive.NewObjectID()
+	fakeID := Domain.NewID()
 	expectedTask := &Domain.Task{ID: fakeID, Title: "Task by ID"}
 	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(expectedTask, nil)
 
-	result, err := usecase.GetTaskByID(context.Background(), fakeID.Hex())
+	result, err := usecase.GetTaskByID(context.Background(), adminActor, fakeID.String())
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTask, result)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestTaskUsecase_GetTaskByID_OtherOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	foreignTask := &Domain.Task{ID: fakeID, OwnerID: Domain.NewID(), Title: "Not yours"}
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(foreignTask, nil)
+
+	result, err := usecase.GetTaskByID(context.Background(), actor, fakeID.String())
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestTaskUsecase_UpdateTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
 
-	fakeID := primitive.NewObjectID()
-	inputTask := Domain.Task{Title: "Updated Task"}
-	expectedTask := &Domain.Task{ID: fakeID, Title: "Updated Task"}
-	// The usecase will set inputTask.ID = fakeID before calling UpdateTask
-	mockRepo.On("UpdateTask", mock.Anything, Domain.Task{ID: fakeID, Title: "Updated Task"}).Return(expectedTask, nil)
+	fakeID := Domain.NewID()
+	ownerID := Domain.NewID()
+	actor := Domain.Actor{UserID: ownerID, Role: "user"}
+	inputTask := Domain.Task{Title: "Updated Task", DueDate: futureDue, Status: Domain.StatusInProgress}
+	expectedTask := &Domain.Task{ID: fakeID, OwnerID: ownerID, Title: "Updated Task", DueDate: futureDue, Status: Domain.StatusInProgress}
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID, OwnerID: ownerID, Status: Domain.StatusPending}, nil)
+	// The usecase will set the ID and keep the stored owner before calling UpdateTask
+	mockRepo.On("UpdateTask", mock.Anything, *expectedTask).Return(expectedTask, nil)
 
-	result, err := usecase.UpdateTask(context.Background(), fakeID.Hex(), inputTask)
+	result, err := usecase.UpdateTask(context.Background(), actor, fakeID.String(), inputTask)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTask, result)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestTaskUsecase_UpdateTask_VersionConflict(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID, Status: Domain.StatusPending, Version: 3}, nil)
+
+	// The client read version 2, the task has moved on since
+	input := Domain.Task{Title: "Stale", DueDate: futureDue, Status: Domain.StatusPending, Version: 2}
+	_, err := usecase.UpdateTask(context.Background(), adminActor, fakeID.String(), input)
+
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
+
+func TestTaskUsecase_PatchTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	ownerID := Domain.NewID()
+	actor := Domain.Actor{UserID: ownerID, Role: "user"}
+	existing := &Domain.Task{ID: fakeID, OwnerID: ownerID, Title: "Old title", Description: "Kept", DueDate: futureDue, Status: Domain.StatusPending, Version: 3}
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(existing, nil)
+	// Only the patched field changes, the write is conditional on the loaded version
+	expected := *existing
+	expected.Title = "New title"
+	saved := expected
+	saved.Version = 4
+	mockRepo.On("UpdateTask", mock.Anything, expected).Return(&saved, nil)
+
+	title := "New title"
+	result, err := usecase.PatchTask(context.Background(), actor, fakeID.String(), Domain.TaskPatch{Title: &title, Version: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &saved, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskUsecase_PatchTask_Invalid(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID, Title: "Title", DueDate: futureDue, Status: Domain.StatusPending, Version: 1}, nil)
+
+	// Clearing a required field with null fails validation
+	empty := ""
+	_, err := usecase.PatchTask(context.Background(), adminActor, fakeID.String(), Domain.TaskPatch{Title: &empty})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+
+	_, err = usecase.PatchTask(context.Background(), adminActor, fakeID.String(), Domain.TaskPatch{Version: 2})
+	assert.ErrorIs(t, err, Domain.ErrVersionConflict)
+	mockRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
+
 func TestTaskUsecase_DeleteTask(t *testing.T) {
 	mockRepo := new(MockTaskRepository)
-	usecase := Usecases.NewTaskUsecase(mockRepo)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
 
-	fakeID := primitive.NewObjectID()
-	mockRepo.On("DeleteTask", mock.Anything, fakeID).Return(nil)
+	fakeID := Domain.NewID()
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID}, nil)
+	// The task goes to the trash, recording who deleted it
+	mockRepo.On("DeleteTask", mock.Anything, fakeID, adminActor.UserID, mock.AnythingOfType("time.Time")).Return(nil)
 
-	err := usecase.DeleteTask(context.Background(), fakeID.Hex())
+	err := usecase.DeleteTask(context.Background(), adminActor, fakeID.String())
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestTaskUsecase_DeleteTask_OtherOwner(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID, OwnerID: Domain.NewID()}, nil)
+
+	err := usecase.DeleteTask(context.Background(), actor, fakeID.String())
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "DeleteTask", mock.Anything, fakeID, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskUsecase_RestoreTask(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	ownerID := Domain.NewID()
+	owned, other := Domain.NewID(), Domain.NewID()
+	deletedAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetDeletedTaskByID", mock.Anything, owned).Return(&Domain.Task{ID: owned, OwnerID: ownerID, DeletedAt: deletedAt}, nil)
+	mockRepo.On("GetDeletedTaskByID", mock.Anything, other).Return(&Domain.Task{ID: other, OwnerID: Domain.NewID(), DeletedAt: deletedAt}, nil)
+	mockRepo.On("GetSubtasks", mock.Anything, owned).Return([]Domain.Task{}, nil)
+	mockRepo.On("RestoreTask", mock.Anything, owned).Return(&Domain.Task{ID: owned, OwnerID: ownerID}, nil)
+	actor := Domain.Actor{UserID: ownerID, Role: "user"}
+
+	restored, err := usecase.RestoreTask(context.Background(), actor, owned.String())
+	assert.NoError(t, err)
+	assert.False(t, restored.IsDeleted())
+
+	// Someone else's trash is as invisible as their tasks
+	_, err = usecase.RestoreTask(context.Background(), actor, other.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+	mockRepo.AssertNumberOfCalls(t, "RestoreTask", 1)
+}
+
+func TestTaskUsecase_PurgeTask_RequiresPermission(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	mockRepo.On("PurgeTask", mock.Anything, fakeID).Return(nil)
+
+	err := usecase.PurgeTask(context.Background(), Domain.Actor{UserID: Domain.NewID(), Role: "user"}, fakeID.String())
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "PurgeTask", mock.Anything, mock.Anything)
+
+	assert.NoError(t, usecase.PurgeTask(context.Background(), adminActor, fakeID.String()))
+	mockRepo.AssertExpectations(t)
+}
+
+var managerActor = Domain.Actor{UserID: Domain.NewID(), Username: "manager", Role: "manager", Team: "platform"}
+
+func TestTaskUsecase_UpdateTask_ManagerOfTeam(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	ownerID := Domain.NewID()
+	stored := &Domain.Task{ID: fakeID, OwnerID: ownerID, Team: "platform", Title: "Team task", Status: Domain.StatusPending}
+	expectedTask := &Domain.Task{ID: fakeID, OwnerID: ownerID, Team: "platform", Title: "Edited by manager", DueDate: futureDue, Status: Domain.StatusPending}
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(stored, nil)
+	mockRepo.On("UpdateTask", mock.Anything, *expectedTask).Return(expectedTask, nil)
+
+	result, err := usecase.UpdateTask(context.Background(), managerActor, fakeID.String(), Domain.Task{Title: "Edited by manager", DueDate: futureDue, Status: Domain.StatusPending})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedTask, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskUsecase_UpdateTask_ManagerOfOtherTeam(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	fakeID := Domain.NewID()
+	mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID, OwnerID: Domain.NewID(), Team: "sales"}, nil)
+
+	_, err := usecase.UpdateTask(context.Background(), managerActor, fakeID.String(), Domain.Task{Title: "Nope"})
+
+	assert.Error(t, err)
+	mockRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+}
+
+func TestTaskUsecase_ListTasks_ScopedToTeam(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+
+	expectedPage := &Domain.TaskPage{Tasks: []Domain.Task{{Title: "Team task"}}}
+	mockRepo.On("ListTasks", mock.Anything, Domain.TaskQuery{OwnerID: managerActor.UserID, Team: "platform", Limit: Domain.DefaultTaskPageSize}).Return(expectedPage, nil)
+
+	result, err := usecase.ListTasks(context.Background(), managerActor, Domain.TaskQuery{Team: "sales"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPage, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTaskUsecase_AddTask_WithoutPermission(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	policy, err := Domain.NewPolicy(map[string][]Domain.Permission{"user": {Domain.PermTasksRead}})
+	assert.NoError(t, err)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), policy)
+
+	_, err = usecase.AddTask(context.Background(), Domain.Actor{UserID: Domain.NewID(), Role: "user"}, Domain.Task{Title: "Read only"})
+
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "AddTask", mock.Anything, mock.Anything)
+}
+
+func TestTaskUsecase_AddTask_Invalid(t *testing.T) {
+	mockRepo := new(MockTaskRepository)
+	usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	cases := map[string]Domain.Task{
+		"Empty title":    {Title: "  ", DueDate: futureDue},
+		"Past due date":  {Title: "Late", DueDate: time.Now().AddDate(0, 0, -2)},
+		"Created done":   {Title: "Done", DueDate: futureDue, Status: Domain.StatusCompleted},
+		"Unknown status": {Title: "Odd", DueDate: futureDue, Status: "Blocked"},
+	}
+	for name, task := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := usecase.AddTask(context.Background(), actor, task)
+			assert.ErrorIs(t, err, Domain.ErrValidation)
+		})
+	}
+	mockRepo.AssertNotCalled(t, "AddTask", mock.Anything, mock.Anything)
+}
+
+func TestTaskUsecase_UpdateTask_Reopen(t *testing.T) {
+	ownerID := Domain.NewID()
+	owner := Domain.Actor{UserID: ownerID, Role: "user"}
+	cases := []struct {
+		name     string
+		actor    Domain.Actor
+		expected error
+	}{
+		{"Owner", owner, Domain.ErrForbidden},
+		{"Admin", adminActor, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockTaskRepository)
+			usecase := Usecases.NewTaskUsecase(mockRepo, Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+			fakeID := Domain.NewID()
+			mockRepo.On("GetTaskByID", mock.Anything, fakeID).Return(&Domain.Task{ID: fakeID, OwnerID: ownerID, Status: Domain.StatusCompleted}, nil)
+			reopened := Domain.Task{ID: fakeID, OwnerID: ownerID, Title: "Again", DueDate: futureDue, Status: Domain.StatusPending}
+			mockRepo.On("UpdateTask", mock.Anything, reopened).Return(&reopened, nil).Maybe()
+
+			_, err := usecase.UpdateTask(context.Background(), tc.actor, fakeID.String(), Domain.Task{Title: "Again", DueDate: futureDue, Status: Domain.StatusPending})
+
+			if tc.expected != nil {
+				assert.ErrorIs(t, err, tc.expected)
+				mockRepo.AssertNotCalled(t, "UpdateTask", mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTaskUsecase_RecordsHistory(t *testing.T) {
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Username: "alice", Role: "user"}
+
+	created, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Audited", DueDate: futureDue})
+	require.NoError(t, err)
+	done := Domain.StatusCompleted
+	_, err = usecase.PatchTask(ctx, actor, created.ID.String(), Domain.TaskPatch{Status: &done})
+	require.NoError(t, err)
+	require.NoError(t, usecase.DeleteTask(ctx, actor, created.ID.String()))
+
+	// The history of a task in the trash is still available
+	history, err := usecase.GetTaskHistory(ctx, actor, created.ID.String())
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Equal(t, Domain.AuditCreated, history[0].Action)
+	assert.Equal(t, Domain.AuditUpdated, history[1].Action)
+	assert.Equal(t, []Domain.FieldChange{{Field: "status", Before: "Pending", After: "Completed"}}, history[1].Changes)
+	assert.Equal(t, actor.UserID, history[1].ActorID)
+	assert.Equal(t, "alice", history[1].ActorUsername)
+	assert.Equal(t, Domain.AuditDeleted, history[2].Action)
+
+	// Others may not see the history of a task they cannot read
+	other := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	_, err = usecase.GetTaskHistory(ctx, other, created.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+}
+
+// failingAuditRepository cannot store any event
+type failingAuditRepository struct{ Repositories.AuditRepository }
+
+func (failingAuditRepository) AddEvent(ctx context.Context, event Domain.AuditEvent) (*Domain.AuditEvent, error) {
+	return nil, errors.New("audit store unavailable")
+}
+
+func TestTaskUsecase_AuditFailureDoesNotFailTheChange(t *testing.T) {
+	taskRepo := Repositories.NewMemoryTaskRepository()
+	usecase := Usecases.NewTaskUsecase(taskRepo, failingAuditRepository{}, Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	// The change is stored either way, an error would make the client retry it
+	created, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Unaudited", DueDate: futureDue})
+	require.NoError(t, err)
+	done := Domain.StatusCompleted
+	_, err = usecase.PatchTask(ctx, actor, created.ID.String(), Domain.TaskPatch{Status: &done})
+	require.NoError(t, err)
+	require.NoError(t, usecase.DeleteTask(ctx, actor, created.ID.String()))
+	_, err = taskRepo.GetDeletedTaskByID(ctx, created.ID)
+	assert.NoError(t, err)
+}
+
+func TestTaskUsecase_AssignTask(t *testing.T) {
+	userRepo := Repositories.NewMemoryUserRepository()
+	require.NoError(t, userRepo.RegisterUser(context.Background(), Domain.User{Username: "bob", Password: "hash", Role: Domain.RoleUser}))
+	bobUser, err := userRepo.GetUserByUsername(context.Background(), "bob")
+	require.NoError(t, err)
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), userRepo, Domain.DefaultPolicy())
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Username: "alice", Role: "user"}
+	bob := Domain.Actor{UserID: bobUser.ID, Username: "bob", Role: "user"}
+
+	created, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Shared", DueDate: futureDue})
+	require.NoError(t, err)
+
+	// Unknown and malformed users are rejected
+	_, err = usecase.AssignTask(ctx, owner, created.ID.String(), []string{Domain.NewID().String(), "nope"}, 0)
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+	// Only whoever may change the task may assign it
+	_, err = usecase.AssignTask(ctx, bob, created.ID.String(), []string{bob.UserID.String()}, 0)
+	assert.ErrorIs(t, err, Domain.ErrTaskNotFound)
+
+	assigned, err := usecase.AssignTask(ctx, owner, created.ID.String(), []string{bob.UserID.String(), bob.UserID.String()}, created.Version)
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.ID{bob.UserID}, assigned.AssigneeIDs)
+
+	// The assignee reads and changes the task and finds it in their list
+	_, err = usecase.GetTaskByID(ctx, bob, created.ID.String())
+	require.NoError(t, err)
+	inProgress := Domain.StatusInProgress
+	patched, err := usecase.PatchTask(ctx, bob, created.ID.String(), Domain.TaskPatch{Status: &inProgress})
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.ID{bob.UserID}, patched.AssigneeIDs, "updates keep the assignees")
+	// but may neither delete it nor change who is assigned
+	assert.ErrorIs(t, usecase.DeleteTask(ctx, bob, created.ID.String()), Domain.ErrForbidden)
+	_, err = usecase.AssignTask(ctx, bob, created.ID.String(), []string{bob.UserID.String()}, 0)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	_, err = usecase.UnassignTask(ctx, bob, created.ID.String(), []string{bob.UserID.String()}, 0)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	page, err := usecase.ListAssignedTasks(ctx, bob, Domain.TaskQuery{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Shared"}, taskTitles(page.Tasks))
+
+	unassigned, err := usecase.UnassignTask(ctx, owner, created.ID.String(), []string{bob.UserID.String()}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, unassigned.AssigneeIDs)
+	page, err = usecase.ListAssignedTasks(ctx, bob, Domain.TaskQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, page.Tasks)
+
+	history, err := usecase.GetTaskHistory(ctx, owner, created.ID.String())
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, Domain.AuditAssigned, history[1].Action)
+	assert.Equal(t, []Domain.FieldChange{{Field: "assignee_ids", Before: "", After: bob.UserID.String()}}, history[1].Changes)
+	assert.Equal(t, Domain.AuditUnassigned, history[3].Action)
+}
+
+func TestTaskUsecase_AssigneeCannotRestore(t *testing.T) {
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	assignee := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	created, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Shared", DueDate: futureDue, AssigneeIDs: []Domain.ID{assignee.UserID}})
+	require.NoError(t, err)
+	require.NoError(t, usecase.DeleteTask(ctx, owner, created.ID.String()))
+
+	_, err = usecase.RestoreTask(ctx, assignee, created.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	_, err = usecase.RestoreTask(ctx, owner, created.ID.String())
+	assert.NoError(t, err)
+}
+
+func TestTaskUsecase_LabelsAndPriority(t *testing.T) {
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	ctx := context.Background()
+	actor := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	task, err := usecase.AddTask(ctx, actor, Domain.Task{Title: "Labelled", DueDate: futureDue, Labels: []string{"Backend", "backend ", "bug"}})
+	require.NoError(t, err)
+	assert.Equal(t, Domain.PriorityMedium, task.Priority)
+	assert.Equal(t, []string{"backend", "bug"}, task.Labels)
+
+	// A replacement without a priority keeps the current one
+	urgent := Domain.PriorityUrgent
+	task, err = usecase.PatchTask(ctx, actor, task.ID.String(), Domain.TaskPatch{Priority: &urgent})
+	require.NoError(t, err)
+	task, err = usecase.UpdateTask(ctx, actor, task.ID.String(), Domain.Task{Title: "Labelled", DueDate: futureDue, Status: Domain.StatusPending, Labels: []string{"backend"}})
+	require.NoError(t, err)
+	assert.Equal(t, Domain.PriorityUrgent, task.Priority)
+
+	bad := Domain.TaskPriority("asap")
+	_, err = usecase.PatchTask(ctx, actor, task.ID.String(), Domain.TaskPatch{Priority: &bad})
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+
+	history, err := usecase.GetTaskHistory(ctx, actor, task.ID.String())
+	require.NoError(t, err)
+	assert.Contains(t, history[1].Changes, Domain.FieldChange{Field: "priority", Before: "medium", After: "urgent"})
+	assert.Contains(t, history[2].Changes, Domain.FieldChange{Field: "labels", Before: "backend,bug", After: "backend"})
+}
+
+func TestTaskUsecase_ListLabels_ScopedToReadableTasks(t *testing.T) {
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy())
+	ctx := context.Background()
+	alice := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+	bob := Domain.Actor{UserID: Domain.NewID(), Role: "user"}
+
+	_, err := usecase.AddTask(ctx, alice, Domain.Task{Title: "Mine", DueDate: futureDue, Labels: []string{"backend"}})
+	require.NoError(t, err)
+	_, err = usecase.AddTask(ctx, bob, Domain.Task{Title: "Bob's", DueDate: futureDue, Labels: []string{"backend", "secret"}})
+	require.NoError(t, err)
+
+	labels, err := usecase.ListLabels(ctx, alice)
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.LabelCount{{Label: "backend", Count: 1}}, labels)
+
+	labels, err = usecase.ListLabels(ctx, adminActor)
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.LabelCount{{Label: "backend", Count: 2}, {Label: "secret", Count: 1}}, labels)
+}
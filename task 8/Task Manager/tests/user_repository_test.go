@@ -9,9 +9,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-
-	// "go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // --- Tests ---
@@ -28,6 +27,26 @@ func (m *UserMockCollection) FindOne(ctx context.Context, filter interface{}, op
 	return args.Get(0).(Repositories.SingleResult)
 }
 
+func (m *UserMockCollection) Find(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.Cursor, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(Repositories.Cursor), args.Error(1)
+}
+
+func (m *UserMockCollection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...interface{}) Repositories.SingleResult {
+	args := m.Called(ctx, filter, update)
+	return args.Get(0).(Repositories.SingleResult)
+}
+
+func (m *UserMockCollection) DeleteOne(ctx context.Context, filter interface{}, opts ...interface{}) (Repositories.DeleteResult, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(Repositories.DeleteResult), args.Error(1)
+}
+
+func (m *UserMockCollection) CreateIndexes(ctx context.Context, models []mongo.IndexModel) error {
+	args := m.Called(ctx, models)
+	return args.Error(0)
+}
+
 type UserMockSingleResult struct {
 	user Domain.User
 	err  error
@@ -37,7 +56,7 @@ func (m *UserMockSingleResult) Decode(val interface{}) error {
 	if m.err != nil {
 		return m.err
 	}
-	*(val.(*Domain.User)) = m.user
+	*(val.(*Repositories.UserEntity)) = Repositories.UserFromDomain(m.user)
 	return nil
 }
 
@@ -48,31 +67,79 @@ func TestRegisterUser(t *testing.T) {
 	mockColl := new(UserMockCollection)
 	repo := Repositories.NewMongoUserRepository(mockColl)
 	user := Domain.User{Username: "testuser"}
-	mockColl.On("InsertOne", mock.Anything, user).Return(nil, nil)
+	mockColl.On("InsertOne", mock.Anything, Repositories.UserFromDomain(user)).Return(nil, nil)
 	err := repo.RegisterUser(context.Background(), user)
 	assert.NoError(t, err)
 	mockColl.AssertExpectations(t)
 }
 
-func TestAuthenticateUser_Found(t *testing.T) {
+func TestRegisterUser_Duplicate(t *testing.T) {
+	cases := []struct {
+		name     string
+		message  string
+		expected error
+	}{
+		{"Duplicate username", "index: username_1", Domain.ErrUsernameTaken},
+		{"Duplicate email", "index: email_1", Domain.ErrEmailTaken},
+		{"Username mentioning email", `index: username_1 dup key: { username: "email" }`, Domain.ErrUsernameTaken},
+		{"Email of a user named email", `index: email_1 dup key: { email: "username_1@example.com" }`, Domain.ErrEmailTaken},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockColl := new(UserMockCollection)
+			repo := Repositories.NewMongoUserRepository(mockColl)
+			dupErr := mongo.WriteException{WriteErrors: []mongo.WriteError{{
+				Code:    11000,
+				Message: "E11000 duplicate key error collection: testdb.users " + tc.message,
+			}}}
+			mockColl.On("InsertOne", mock.Anything, mock.Anything).Return(nil, dupErr)
+			err := repo.RegisterUser(context.Background(), Domain.User{Username: "testuser"})
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestEnsureIndexes(t *testing.T) {
 	mockColl := new(UserMockCollection)
 	repo := Repositories.NewMongoUserRepository(mockColl)
-	user := Domain.User{Username: "testuser", Password: "pass"}
+	mockColl.On("CreateIndexes", mock.Anything, mock.MatchedBy(func(models []mongo.IndexModel) bool {
+		return len(models) == 2 && *models[0].Options.Unique && *models[1].Options.Unique
+	})).Return(nil)
+	assert.NoError(t, repo.EnsureIndexes(context.Background()))
+	mockColl.AssertExpectations(t)
+}
+
+func TestGetUserByUsername_Found(t *testing.T) {
+	mockColl := new(UserMockCollection)
+	repo := Repositories.NewMongoUserRepository(mockColl)
+	user := Domain.User{Username: "testuser", Password: "hash"}
 	mockResult := &UserMockSingleResult{user: user}
 	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(mockResult)
-	result, err := repo.AuthenticateUser(context.Background(), "testuser", "pass")
+	result, err := repo.GetUserByUsername(context.Background(), "testuser")
 	assert.NoError(t, err)
 	assert.Equal(t, &user, result)
 	mockColl.AssertExpectations(t)
 }
 
-func TestAuthenticateUser_NotFound(t *testing.T) {
+func TestGetUserByUsername_NotFound(t *testing.T) {
+	mockColl := new(UserMockCollection)
+	repo := Repositories.NewMongoUserRepository(mockColl)
+	mockResult := &UserMockSingleResult{err: mongo.ErrNoDocuments}
+	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(mockResult)
+	result, err := repo.GetUserByUsername(context.Background(), "testuser")
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+	assert.Nil(t, result)
+	mockColl.AssertExpectations(t)
+}
+
+func TestGetUserByUsername_DatabaseError(t *testing.T) {
 	mockColl := new(UserMockCollection)
 	repo := Repositories.NewMongoUserRepository(mockColl)
 	mockResult := &UserMockSingleResult{err: assert.AnError}
 	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(mockResult)
-	result, err := repo.AuthenticateUser(context.Background(), "testuser", "pass")
-	assert.Error(t, err)
+	result, err := repo.GetUserByUsername(context.Background(), "testuser")
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.NotErrorIs(t, err, Domain.ErrUserNotFound)
 	assert.Nil(t, result)
 	mockColl.AssertExpectations(t)
 }
@@ -80,7 +147,7 @@ func TestAuthenticateUser_NotFound(t *testing.T) {
 func TestGetUserByID_Found(t *testing.T) {
 	mockColl := new(UserMockCollection)
 	repo := Repositories.NewMongoUserRepository(mockColl)
-	fakeID := struct{ primitive.ObjectID }{primitive.NewObjectID()}.ObjectID
+	fakeID := Domain.NewID()
 	user := Domain.User{ID: fakeID, Username: "testuser"}
 	mockResult := &UserMockSingleResult{user: user}
 	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(mockResult)
@@ -93,11 +160,40 @@ func TestGetUserByID_Found(t *testing.T) {
 func TestGetUserByID_NotFound(t *testing.T) {
 	mockColl := new(UserMockCollection)
 	repo := Repositories.NewMongoUserRepository(mockColl)
-	fakeID := struct{ primitive.ObjectID }{primitive.NewObjectID()}.ObjectID
-	mockResult := &UserMockSingleResult{err: assert.AnError}
+	fakeID := Domain.NewID()
+	mockResult := &UserMockSingleResult{err: mongo.ErrNoDocuments}
 	mockColl.On("FindOne", mock.Anything, mock.Anything).Return(mockResult)
 	result, err := repo.GetUserByID(context.Background(), fakeID)
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	mockColl.AssertExpectations(t)
 }
+
+func TestUpdateUserRole_Mongo(t *testing.T) {
+	mockColl := new(UserMockCollection)
+	repo := Repositories.NewMongoUserRepository(mockColl)
+	user := Domain.User{ID: Domain.NewID(), Username: "testuser", Role: Domain.RoleAdmin}
+	mockColl.On("FindOneAndUpdate", mock.Anything, mock.Anything, bson.M{"$set": bson.M{"role": Domain.RoleAdmin}}).
+		Return(&UserMockSingleResult{user: user})
+	result, err := repo.UpdateUserRole(context.Background(), user.ID, Domain.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, &user, result)
+	mockColl.AssertExpectations(t)
+}
+
+func TestUpdateUserRole_Mongo_NotFound(t *testing.T) {
+	mockColl := new(UserMockCollection)
+	repo := Repositories.NewMongoUserRepository(mockColl)
+	mockColl.On("FindOneAndUpdate", mock.Anything, mock.Anything, mock.Anything).
+		Return(&UserMockSingleResult{err: mongo.ErrNoDocuments})
+	_, err := repo.UpdateUserRole(context.Background(), Domain.NewID(), Domain.RoleAdmin)
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
+
+func TestDeleteUser_Mongo_NotFound(t *testing.T) {
+	mockColl := new(UserMockCollection)
+	repo := Repositories.NewMongoUserRepository(mockColl)
+	mockColl.On("DeleteOne", mock.Anything, mock.Anything).Return(&MockDeleteResult{deleted: 0}, nil)
+	err := repo.DeleteUser(context.Background(), Domain.NewID())
+	assert.ErrorIs(t, err, Domain.ErrUserNotFound)
+}
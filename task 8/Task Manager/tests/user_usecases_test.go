@@ -3,13 +3,13 @@ package tests
 import (
 	"context"
 	"errors"
+	"strings"
 	"taskmanager/Domain"
 	"taskmanager/Usecases"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // MockUserRepository implements the UserRepository interface for usecase tests
@@ -20,15 +20,15 @@ func (m *MockUserRepository) RegisterUser(ctx context.Context, user Domain.User)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) AuthenticateUser(ctx context.Context, username, password string) (*Domain.User, error) {
-	args := m.Called(ctx, username, password)
+func (m *MockUserRepository) GetUserByUsername(ctx context.Context, username string) (*Domain.User, error) {
+	args := m.Called(ctx, username)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*Domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetUserByID(ctx context.Context, id primitive.ObjectID) (*Domain.User, error) {
+func (m *MockUserRepository) GetUserByID(ctx context.Context, id Domain.ID) (*Domain.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -36,44 +36,154 @@ func (m *MockUserRepository) GetUserByID(ctx context.Context, id primitive.Objec
 	return args.Get(0).(*Domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) ListUsers(ctx context.Context) ([]Domain.User, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserRole(ctx context.Context, id Domain.ID, role string) (*Domain.User, error) {
+	args := m.Called(ctx, id, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserTeam(ctx context.Context, id Domain.ID, team string) (*Domain.User, error) {
+	args := m.Called(ctx, id, team)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*Domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, id Domain.ID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// fakePasswordService "hashes" by prefixing, so tests can tell hashed from plain passwords
+type fakePasswordService struct{}
+
+func (fakePasswordService) HashPassword(password string) (string, error) {
+	return "hashed:" + password, nil
+}
+
+func (fakePasswordService) CheckPasswordHash(hashedPassword, password string) bool {
+	return hashedPassword == "hashed:"+password
+}
+
 func TestRegisterUser_Usecase(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := Usecases.NewUserUsecase(mockRepo)
-	user := Domain.User{Username: "testuser"}
-	mockRepo.On("RegisterUser", mock.Anything, user).Return(nil)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	// The requested role is ignored, registration always creates a regular user
+	user := Domain.User{Username: "testuser", Password: "password", Role: Domain.RoleAdmin}
+	hashed := Domain.User{Username: "testuser", Password: "hashed:password", Role: Domain.RoleUser}
+	mockRepo.On("RegisterUser", mock.Anything, hashed).Return(nil)
 	err := usecase.RegisterUser(context.Background(), user)
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestRegisterUser_Usecase_Duplicate(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	mockRepo.On("RegisterUser", mock.Anything, mock.Anything).Return(Domain.ErrUsernameTaken)
+	err := usecase.RegisterUser(context.Background(), Domain.User{Username: "testuser", Password: "password"})
+	assert.ErrorIs(t, err, Domain.ErrUsernameTaken)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRegisterUser_Usecase_Invalid(t *testing.T) {
+	cases := []struct {
+		name  string
+		user  Domain.User
+		field string
+	}{
+		{"Missing username", Domain.User{Username: " ", Password: "password"}, "username"},
+		{"Short password", Domain.User{Username: "testuser", Password: "pass"}, "password"},
+		{"Long password", Domain.User{Username: "testuser", Password: strings.Repeat("p", Domain.MaxPasswordLength+1)}, "password"},
+		{"Invalid email", Domain.User{Username: "testuser", Password: "password", Email: "x"}, "email"},
+		{"Email with line break", Domain.User{Username: "testuser", Password: "password", Email: "a@example.com\r\nBcc: b@example.com"}, "email"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+			err := usecase.RegisterUser(context.Background(), tc.user)
+			assert.ErrorIs(t, err, Domain.ErrValidation)
+			assert.Equal(t, []string{tc.field}, fieldsOf(t, err))
+			mockRepo.AssertNotCalled(t, "RegisterUser", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestAuthenticateUser_Usecase_Found(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := Usecases.NewUserUsecase(mockRepo)
-	user := &Domain.User{Username: "testuser", Password: "pass"}
-	mockRepo.On("AuthenticateUser", mock.Anything, "testuser", "pass").Return(user, nil)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	user := &Domain.User{Username: "testuser", Password: "hashed:pass"}
+	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
 	result, err := usecase.AuthenticateUser(context.Background(), "testuser", "pass")
 	assert.NoError(t, err)
 	assert.Equal(t, user, result)
 	mockRepo.AssertExpectations(t)
 }
 
+func TestAuthenticateUser_Usecase_WrongPassword(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	user := &Domain.User{Username: "testuser", Password: "hashed:pass"}
+	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(user, nil)
+	result, err := usecase.AuthenticateUser(context.Background(), "testuser", "wrong")
+	assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
+	assert.Nil(t, result)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestAuthenticateUser_Usecase_NotFound(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := Usecases.NewUserUsecase(mockRepo)
-	mockRepo.On("AuthenticateUser", mock.Anything, "testuser", "pass").Return(nil, errors.New("not found"))
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(nil, Domain.ErrUserNotFound)
 	result, err := usecase.AuthenticateUser(context.Background(), "testuser", "pass")
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
 	assert.Nil(t, result)
 	mockRepo.AssertExpectations(t)
 }
 
+// countingPasswordService counts the hash checks, to see unknown usernames cost as much as wrong passwords
+type countingPasswordService struct {
+	fakePasswordService
+	checks int
+}
+
+func (s *countingPasswordService) CheckPasswordHash(hashedPassword, password string) bool {
+	s.checks++
+	return s.fakePasswordService.CheckPasswordHash(hashedPassword, password)
+}
+
+func TestAuthenticateUser_Usecase_NotFoundChecksDummyHash(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	passwords := &countingPasswordService{}
+	usecase := Usecases.NewUserUsecase(mockRepo, passwords, Domain.DefaultPolicy())
+	mockRepo.On("GetUserByUsername", mock.Anything, "ghost").Return(nil, Domain.ErrUserNotFound)
+	mockRepo.On("GetUserByUsername", mock.Anything, "testuser").Return(&Domain.User{Username: "testuser", Password: "hashed:pass"}, nil)
+
+	_, err := usecase.AuthenticateUser(context.Background(), "ghost", "not-a-real-password")
+	assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
+	assert.Equal(t, 1, passwords.checks)
+
+	_, err = usecase.AuthenticateUser(context.Background(), "testuser", "wrong")
+	assert.ErrorIs(t, err, Domain.ErrInvalidCredentials)
+	assert.Equal(t, 2, passwords.checks)
+}
+
 func TestGetUserByID_Usecase_Found(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := Usecases.NewUserUsecase(mockRepo)
-	fakeID := primitive.NewObjectID()
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	fakeID := Domain.NewID()
 	user := &Domain.User{Username: "testuser"}
 	mockRepo.On("GetUserByID", mock.Anything, fakeID).Return(user, nil)
-	result, err := usecase.GetUserByID(context.Background(), fakeID.Hex())
+	result, err := usecase.GetUserByID(context.Background(), fakeID.String())
 	assert.NoError(t, err)
 	assert.Equal(t, user, result)
 	mockRepo.AssertExpectations(t)
@@ -81,11 +191,104 @@ func TestGetUserByID_Usecase_Found(t *testing.T) {
 
 func TestGetUserByID_Usecase_NotFound(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := Usecases.NewUserUsecase(mockRepo)
-	fakeID := primitive.NewObjectID()
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	fakeID := Domain.NewID()
 	mockRepo.On("GetUserByID", mock.Anything, fakeID).Return(nil, errors.New("not found"))
-	result, err := usecase.GetUserByID(context.Background(), fakeID.Hex())
+	result, err := usecase.GetUserByID(context.Background(), fakeID.String())
 	assert.Error(t, err)
 	assert.Nil(t, result)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestBootstrapAdmin_Usecase(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	admin := Domain.User{Username: "root", Password: "hashed:secret-password", Role: Domain.RoleAdmin}
+	mockRepo.On("RegisterUser", mock.Anything, admin).Return(nil)
+	created, err := usecase.BootstrapAdmin(context.Background(), Domain.User{Username: "root", Password: "secret-password"})
+	assert.NoError(t, err)
+	assert.True(t, created)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBootstrapAdmin_Usecase_AlreadyExists(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	mockRepo.On("RegisterUser", mock.Anything, mock.Anything).Return(Domain.ErrUsernameTaken)
+	created, err := usecase.BootstrapAdmin(context.Background(), Domain.User{Username: "root", Password: "secret-password"})
+	assert.NoError(t, err)
+	assert.False(t, created)
+}
+
+func TestUpdateUserRole_Usecase(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	targetID := Domain.NewID()
+	updated := &Domain.User{ID: targetID, Username: "bob", Role: Domain.RoleAdmin}
+	mockRepo.On("UpdateUserRole", mock.Anything, targetID, Domain.RoleAdmin).Return(updated, nil)
+	result, err := usecase.UpdateUserRole(context.Background(), adminActor, targetID.String(), Domain.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateUserRole_Usecase_Rejected(t *testing.T) {
+	cases := []struct {
+		name     string
+		id       string
+		role     string
+		expected error
+	}{
+		{"Unknown role", Domain.NewID().String(), "superuser", Domain.ErrInvalidRole},
+		{"Own role", adminActor.UserID.String(), Domain.RoleUser, Domain.ErrCannotModifySelf},
+		{"Malformed ID", "not-an-id", Domain.RoleUser, Domain.ErrUserNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+			_, err := usecase.UpdateUserRole(context.Background(), adminActor, tc.id, tc.role)
+			assert.ErrorIs(t, err, tc.expected)
+			mockRepo.AssertNotCalled(t, "UpdateUserRole", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestDeleteUser_Usecase(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	targetID := Domain.NewID()
+	mockRepo.On("DeleteUser", mock.Anything, targetID).Return(nil)
+	assert.NoError(t, usecase.DeleteUser(context.Background(), adminActor, targetID.String()))
+
+	assert.ErrorIs(t, usecase.DeleteUser(context.Background(), adminActor, adminActor.UserID.String()), Domain.ErrCannotModifySelf)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateUserTeam_Usecase(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	targetID := Domain.NewID()
+	updated := &Domain.User{ID: targetID, Username: "bob", Role: Domain.RoleManager, Team: "platform"}
+	mockRepo.On("UpdateUserTeam", mock.Anything, targetID, "platform").Return(updated, nil)
+	result, err := usecase.UpdateUserTeam(context.Background(), adminActor, targetID.String(), "platform")
+	assert.NoError(t, err)
+	assert.Equal(t, updated, result)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserAdministration_Usecase_RequiresPermission(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := Usecases.NewUserUsecase(mockRepo, fakePasswordService{}, Domain.DefaultPolicy())
+	manager := Domain.Actor{UserID: Domain.NewID(), Role: Domain.RoleManager, Team: "platform"}
+	targetID := Domain.NewID().String()
+
+	_, err := usecase.ListUsers(context.Background(), manager)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	_, err = usecase.UpdateUserRole(context.Background(), manager, targetID, Domain.RoleAdmin)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	_, err = usecase.UpdateUserTeam(context.Background(), manager, targetID, "sales")
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+	assert.ErrorIs(t, usecase.DeleteUser(context.Background(), manager, targetID), Domain.ErrForbidden)
+	mockRepo.AssertExpectations(t)
+}
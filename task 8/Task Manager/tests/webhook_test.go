@@ -0,0 +1,373 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"taskmanager/Domain"
+	"taskmanager/Infrastructure"
+	"taskmanager/Repositories"
+	"taskmanager/Usecases"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recordingPublisher keeps the task events published by the task usecase
+type recordingPublisher struct {
+	mu     sync.Mutex
+	events []Domain.TaskEvent
+}
+
+func (p *recordingPublisher) PublishTaskEvent(event Domain.TaskEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+}
+
+func (p *recordingPublisher) types() []Domain.TaskEventType {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var types []Domain.TaskEventType
+	for _, e := range p.events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestWebhookSubscription_Validate(t *testing.T) {
+	valid := Domain.WebhookSubscription{URL: "https://ci.example.com/hook", Secret: "0123456789abcdef", Events: []Domain.TaskEventType{Domain.EventTaskCreated}}
+	require.NoError(t, valid.Validate())
+	assert.True(t, valid.Wants(Domain.EventTaskCreated))
+	assert.False(t, valid.Wants(Domain.EventTaskDeleted))
+	assert.True(t, (&Domain.WebhookSubscription{}).Wants(Domain.EventTaskDeleted), "no filter means every event")
+
+	invalid := Domain.WebhookSubscription{URL: "ftp://example.com", Secret: "short", Events: []Domain.TaskEventType{"task.exploded"}}
+	err := invalid.Validate()
+	var verr *Domain.ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Fields, 3)
+}
+
+func TestTaskUsecase_PublishesTaskEvents(t *testing.T) {
+	publisher := &recordingPublisher{}
+	usecase := Usecases.NewTaskUsecase(Repositories.NewMemoryTaskRepository(), Repositories.NewMemoryAuditRepository(), Repositories.NewMemoryUserRepository(), Domain.DefaultPolicy(),
+		Usecases.WithEventPublisher(publisher))
+	ctx := context.Background()
+	owner := Domain.Actor{UserID: Domain.NewID(), Username: "alice", Role: "user"}
+
+	task, err := usecase.AddTask(ctx, owner, Domain.Task{Title: "Release", DueDate: time.Now().AddDate(0, 0, 7)})
+	require.NoError(t, err)
+	title := "Release 1.0"
+	_, err = usecase.PatchTask(ctx, owner, task.ID.String(), Domain.TaskPatch{Title: &title})
+	require.NoError(t, err)
+	inProgress := Domain.StatusInProgress
+	_, err = usecase.PatchTask(ctx, owner, task.ID.String(), Domain.TaskPatch{Status: &inProgress})
+	require.NoError(t, err)
+	require.NoError(t, usecase.DeleteTask(ctx, owner, task.ID.String()))
+	_, err = usecase.RestoreTask(ctx, owner, task.ID.String())
+	require.NoError(t, err)
+
+	assert.Equal(t, []Domain.TaskEventType{
+		Domain.EventTaskCreated,
+		Domain.EventTaskUpdated,
+		Domain.EventTaskUpdated, Domain.EventTaskStatusChanged,
+		Domain.EventTaskDeleted,
+		Domain.EventTaskRestored,
+	}, publisher.types())
+	statusChanged := publisher.events[3]
+	assert.Equal(t, Domain.StatusPending, statusChanged.PreviousStatus)
+	assert.Equal(t, Domain.StatusInProgress, statusChanged.Task.Status)
+	assert.Equal(t, owner.UserID, statusChanged.ActorID)
+	assert.Equal(t, "alice", statusChanged.ActorUsername)
+	assert.NotEqual(t, publisher.events[2].ID, statusChanged.ID)
+}
+
+// CommentMockCollection also provides every method of the WebhookCollection interface
+
+func TestMongoWebhookRepository_AddSubscription(t *testing.T) {
+	subscriptions, deadLetters := new(CommentMockCollection), new(CommentMockCollection)
+	repo := Repositories.NewMongoWebhookRepository(subscriptions, deadLetters)
+	insertedID := primitive.NewObjectID()
+	subscriptions.On("InsertOne", mock.Anything, mock.Anything).Return(&Repositories.InsertOneResult{InsertedID: insertedID}, nil)
+
+	sub, err := repo.AddSubscription(context.Background(), Domain.WebhookSubscription{URL: "https://ci.example.com/hook", Secret: "0123456789abcdef", Events: []Domain.TaskEventType{Domain.EventTaskCreated}})
+
+	require.NoError(t, err)
+	assert.Equal(t, Repositories.DomainIDFromObjectID(insertedID), sub.ID)
+	assert.Equal(t, []Domain.TaskEventType{Domain.EventTaskCreated}, sub.Events)
+	subscriptions.AssertExpectations(t)
+	deadLetters.AssertNotCalled(t, "InsertOne", mock.Anything, mock.Anything)
+}
+
+func TestMongoWebhookRepository_NotFound(t *testing.T) {
+	subscriptions, deadLetters := new(CommentMockCollection), new(CommentMockCollection)
+	repo := Repositories.NewMongoWebhookRepository(subscriptions, deadLetters)
+	id := primitive.NewObjectID()
+	subscriptions.On("FindOne", mock.Anything, bson.M{"_id": id}).Return(&CommentMockSingleResult{err: mongo.ErrNoDocuments})
+	deadLetters.On("DeleteOne", mock.Anything, mock.Anything).Return(&MockDeleteResult{deleted: 0}, nil)
+
+	_, err := repo.GetSubscription(context.Background(), Repositories.DomainIDFromObjectID(id))
+	assert.ErrorIs(t, err, Domain.ErrWebhookNotFound)
+	assert.ErrorIs(t, repo.DeleteDeadLetter(context.Background(), Domain.NewID()), Domain.ErrDeadLetterNotFound)
+}
+
+// recordingRedeliverer keeps the dead letters the webhook usecase asked to deliver again
+// or refuses them with err
+type recordingRedeliverer struct {
+	letters []Domain.WebhookDeadLetter
+	err     error
+}
+
+func (r *recordingRedeliverer) Redeliver(sub Domain.WebhookSubscription, letter Domain.WebhookDeadLetter) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.letters = append(r.letters, letter)
+	return nil
+}
+
+func TestWebhookUsecase_CreateSubscription(t *testing.T) {
+	usecase := Usecases.NewWebhookUsecase(Repositories.NewMemoryWebhookRepository(), &recordingRedeliverer{}, Domain.DefaultPolicy())
+	ctx := context.Background()
+	admin := Domain.Actor{UserID: Domain.NewID(), Role: "admin"}
+	input := Domain.WebhookSubscription{
+		URL:    "https://ci.example.com/hook",
+		Secret: "0123456789abcdef",
+		Events: []Domain.TaskEventType{Domain.EventTaskStatusChanged, Domain.EventTaskCreated, Domain.EventTaskCreated},
+	}
+
+	_, err := usecase.CreateSubscription(ctx, Domain.Actor{UserID: Domain.NewID(), Role: "user"}, input)
+	assert.ErrorIs(t, err, Domain.ErrForbidden)
+
+	sub, err := usecase.CreateSubscription(ctx, admin, input)
+	require.NoError(t, err)
+	assert.Equal(t, []Domain.TaskEventType{Domain.EventTaskCreated, Domain.EventTaskStatusChanged}, sub.Events)
+	assert.Equal(t, admin.UserID, sub.CreatedBy)
+
+	input.Secret = "short"
+	_, err = usecase.CreateSubscription(ctx, admin, input)
+	assert.ErrorIs(t, err, Domain.ErrValidation)
+
+	_, err = usecase.GetSubscription(ctx, admin, "not-an-id")
+	assert.ErrorIs(t, err, Domain.ErrWebhookNotFound)
+}
+
+func TestWebhookUsecase_RetryDeadLetter(t *testing.T) {
+	repo := Repositories.NewMemoryWebhookRepository()
+	redeliverer := &recordingRedeliverer{}
+	usecase := Usecases.NewWebhookUsecase(repo, redeliverer, Domain.DefaultPolicy())
+	ctx := context.Background()
+	admin := Domain.Actor{UserID: Domain.NewID(), Role: "admin"}
+	sub, err := usecase.CreateSubscription(ctx, admin, Domain.WebhookSubscription{URL: "https://ci.example.com/hook", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	letter, err := repo.AddDeadLetter(ctx, Domain.WebhookDeadLetter{SubscriptionID: sub.ID, URL: sub.URL, EventID: Domain.NewID(), EventType: Domain.EventTaskCreated, Payload: []byte(`{}`), FailedAt: time.Now()})
+	require.NoError(t, err)
+
+	require.NoError(t, usecase.RetryDeadLetter(ctx, admin, letter.ID.String()))
+
+	require.Len(t, redeliverer.letters, 1)
+	assert.Equal(t, letter.EventID, redeliverer.letters[0].EventID)
+	letters, err := usecase.ListDeadLetters(ctx, admin)
+	require.NoError(t, err)
+	assert.Empty(t, letters, "the letter is removed, a new one is added if the delivery fails again")
+	assert.ErrorIs(t, usecase.RetryDeadLetter(ctx, admin, letter.ID.String()), Domain.ErrDeadLetterNotFound)
+
+	// Letters of a deleted subscription cannot be retried and are kept
+	orphan, err := repo.AddDeadLetter(ctx, Domain.WebhookDeadLetter{SubscriptionID: Domain.NewID(), EventID: Domain.NewID(), Payload: []byte(`{}`), FailedAt: time.Now()})
+	require.NoError(t, err)
+	assert.ErrorIs(t, usecase.RetryDeadLetter(ctx, admin, orphan.ID.String()), Domain.ErrWebhookNotFound)
+	_, err = repo.GetDeadLetter(ctx, orphan.ID)
+	assert.NoError(t, err)
+}
+
+func TestWebhookUsecase_RetryDeadLetter_WhileStopping(t *testing.T) {
+	repo := Repositories.NewMemoryWebhookRepository()
+	usecase := Usecases.NewWebhookUsecase(repo, &recordingRedeliverer{err: Domain.ErrWebhooksStopped}, Domain.DefaultPolicy())
+	ctx := context.Background()
+	admin := Domain.Actor{UserID: Domain.NewID(), Role: "admin"}
+	sub, err := usecase.CreateSubscription(ctx, admin, Domain.WebhookSubscription{URL: "https://ci.example.com/hook", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	letter, err := repo.AddDeadLetter(ctx, Domain.WebhookDeadLetter{SubscriptionID: sub.ID, URL: sub.URL, EventID: Domain.NewID(), EventType: Domain.EventTaskCreated, Payload: []byte(`{}`), FailedAt: time.Now()})
+	require.NoError(t, err)
+
+	err = usecase.RetryDeadLetter(ctx, admin, letter.ID.String())
+	assert.ErrorIs(t, err, Domain.ErrWebhooksStopped)
+	assert.ErrorIs(t, err, Domain.ErrUnavailable)
+
+	// The letter is still there to be retried after the restart
+	letters, err := usecase.ListDeadLetters(ctx, admin)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	assert.Equal(t, letter.EventID, letters[0].EventID)
+	assert.Equal(t, letter.Payload, letters[0].Payload)
+}
+
+// webhookReceiver is a test endpoint answering with the given status codes in turn, the last one repeatedly
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rcv *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	rcv.requests = append(rcv.requests, r)
+	rcv.bodies = append(rcv.bodies, body)
+	status := rcv.statuses[min(len(rcv.requests), len(rcv.statuses))-1]
+	w.WriteHeader(status)
+}
+
+func (rcv *webhookReceiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return len(rcv.requests)
+}
+
+func newTestDispatcher(t *testing.T, repo *Repositories.MemoryWebhookRepository, config Infrastructure.WebhookConfig) *Infrastructure.WebhookDispatcher {
+	dispatcher := Infrastructure.NewWebhookDispatcher(repo, config)
+	dispatcher.Start()
+	t.Cleanup(func() { _ = dispatcher.Stop(context.Background()) })
+	return dispatcher
+}
+
+func testTaskEvent(t Domain.TaskEventType) Domain.TaskEvent {
+	return Domain.TaskEvent{
+		ID:            Domain.NewID(),
+		Type:          t,
+		Task:          Domain.Task{ID: Domain.NewID(), Title: "Release", DueDate: day(2030, 5, 1), Status: Domain.StatusCompleted, Version: 3},
+		ActorID:       Domain.NewID(),
+		ActorUsername: "alice",
+		OccurredAt:    time.Date(2030, 4, 30, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookDispatcher_DeliversSignedEvents(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusOK}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	repo := Repositories.NewMemoryWebhookRepository()
+	ctx := context.Background()
+	_, err := repo.AddSubscription(ctx, Domain.WebhookSubscription{URL: server.URL, Secret: "0123456789abcdef", Events: []Domain.TaskEventType{Domain.EventTaskStatusChanged}})
+	require.NoError(t, err)
+	dispatcher := newTestDispatcher(t, repo, Infrastructure.WebhookConfig{})
+
+	event := testTaskEvent(Domain.EventTaskStatusChanged)
+	event.PreviousStatus = Domain.StatusInProgress
+	dispatcher.PublishTaskEvent(testTaskEvent(Domain.EventTaskCreated)) // not subscribed
+	dispatcher.PublishTaskEvent(event)
+	require.NoError(t, dispatcher.Stop(ctx))
+
+	require.Equal(t, 1, receiver.count())
+	req, body := receiver.requests[0], receiver.bodies[0]
+	assert.Equal(t, "task.status_changed", req.Header.Get(Infrastructure.WebhookEventHeader))
+	assert.Equal(t, event.ID.String(), req.Header.Get(Infrastructure.WebhookDeliveryHeader))
+	assert.Equal(t, Infrastructure.SignWebhookPayload("0123456789abcdef", body), req.Header.Get(Infrastructure.WebhookSignatureHeader))
+	assert.NotEqual(t, Infrastructure.SignWebhookPayload("another secret!!", body), req.Header.Get(Infrastructure.WebhookSignatureHeader))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "task.status_changed", payload["type"])
+	assert.Equal(t, "In Progress", payload["previous_status"])
+	assert.Equal(t, "2030-04-30T12:00:00Z", payload["occurred_at"])
+	task := payload["task"].(map[string]interface{})
+	assert.Equal(t, "Completed", task["status"])
+	assert.Equal(t, "01-05-2030", task["due_date"])
+}
+
+func TestWebhookDispatcher_RetriesWithBackoff(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	repo := Repositories.NewMemoryWebhookRepository()
+	ctx := context.Background()
+	_, err := repo.AddSubscription(ctx, Domain.WebhookSubscription{URL: server.URL, Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	dispatcher := newTestDispatcher(t, repo, Infrastructure.WebhookConfig{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+	dispatcher.PublishTaskEvent(testTaskEvent(Domain.EventTaskCreated))
+
+	assert.Eventually(t, func() bool { return receiver.count() == 3 }, 5*time.Second, 5*time.Millisecond)
+	require.NoError(t, dispatcher.Stop(ctx))
+	assert.Equal(t, 3, receiver.count())
+	assert.Equal(t, receiver.bodies[0], receiver.bodies[2], "every attempt posts the same body")
+	letters, err := repo.ListDeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestWebhookDispatcher_DeadLetterAndRedeliver(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	repo := Repositories.NewMemoryWebhookRepository()
+	ctx := context.Background()
+	sub, err := repo.AddSubscription(ctx, Domain.WebhookSubscription{URL: server.URL, Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	dispatcher := newTestDispatcher(t, repo, Infrastructure.WebhookConfig{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	event := testTaskEvent(Domain.EventTaskDeleted)
+
+	dispatcher.PublishTaskEvent(event)
+
+	var letters []Domain.WebhookDeadLetter
+	assert.Eventually(t, func() bool {
+		letters, err = repo.ListDeadLetters(ctx)
+		return err == nil && len(letters) == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	letter := letters[0]
+	assert.Equal(t, sub.ID, letter.SubscriptionID)
+	assert.Equal(t, event.ID, letter.EventID)
+	assert.Equal(t, Domain.EventTaskDeleted, letter.EventType)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Contains(t, letter.LastError, "503")
+
+	require.NoError(t, repo.DeleteDeadLetter(ctx, letter.ID))
+	healthy.Store(true)
+	require.NoError(t, dispatcher.Redeliver(*sub, letter))
+	require.NoError(t, dispatcher.Stop(ctx))
+	letters, err = repo.ListDeadLetters(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, letters)
+
+	// Once stopped the dispatcher refuses redeliveries instead of dropping them
+	assert.ErrorIs(t, dispatcher.Redeliver(*sub, letter), Domain.ErrWebhooksStopped)
+}
+
+func TestWebhookDispatcher_StopKeepsPendingRetries(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{http.StatusInternalServerError}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	repo := Repositories.NewMemoryWebhookRepository()
+	ctx := context.Background()
+	_, err := repo.AddSubscription(ctx, Domain.WebhookSubscription{URL: server.URL, Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	dispatcher := newTestDispatcher(t, repo, Infrastructure.WebhookConfig{BaseDelay: time.Hour})
+
+	dispatcher.PublishTaskEvent(testTaskEvent(Domain.EventTaskCreated))
+	assert.Eventually(t, func() bool { return receiver.count() == 1 }, 5*time.Second, 5*time.Millisecond)
+	stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	require.NoError(t, dispatcher.Stop(stopCtx))
+
+	letters, err := repo.ListDeadLetters(ctx)
+	require.NoError(t, err)
+	require.Len(t, letters, 1, "the retry waiting at shutdown is kept as a dead letter")
+	assert.Equal(t, 1, letters[0].Attempts)
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"taskmanager/Infrastructure"
+)
+
+// newWebhookDispatcher sets up the background delivery of task events to the webhook subscriptions.
+// WEBHOOK_MAX_ATTEMPTS (default 5), WEBHOOK_RETRY_DELAY (default 1s, doubled for each retry),
+// WEBHOOK_MAX_RETRY_DELAY (default 5m) and WEBHOOK_TIMEOUT (default 10s) tune the retries.
+func newWebhookDispatcher(config Infrastructure.WebhookConfig, store *storage) *Infrastructure.WebhookDispatcher {
+	return Infrastructure.NewWebhookDispatcher(store.webhookRepo, config)
+}